@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,6 +15,7 @@ import (
 	"github.com/spf13/viper"
 
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/database"
 )
 
 var adminCmd = &cobra.Command{
@@ -62,15 +64,74 @@ var adminConfigSecurityCmd = &cobra.Command{
 	RunE:  runAdminConfigSecurity,
 }
 
+var adminChannelsCmd = &cobra.Command{
+	Use:   "channels",
+	Short: "Channel membership management",
+	Long: `Manage who belongs to channels in the local database and what they may do.
+
+Owners manage roles; moderators add, remove and mute members ranked below them.`,
+}
+
+var adminChannelsMembersCmd = &cobra.Command{
+	Use:   "members <channel-id>",
+	Short: "List channel members",
+	Long:  "List a channel's members with their roles",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminChannelsMembers,
+}
+
+var adminChannelsAddMemberCmd = &cobra.Command{
+	Use:   "add-member <channel-id> <user-id>",
+	Short: "Add a member to a channel",
+	Long:  "Add a user to a channel; requires the moderator role, or owner to add above member",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAdminChannelsAddMember,
+}
+
+var adminChannelsRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member <channel-id> <user-id>",
+	Short: "Remove a member from a channel",
+	Long:  "Remove a user from a channel; anyone may remove themselves",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAdminChannelsRemoveMember,
+}
+
+var adminChannelsSetRoleCmd = &cobra.Command{
+	Use:   "set-role <channel-id> <user-id> <owner|moderator|member>",
+	Short: "Change a member's role",
+	Long:  "Change a member's role; requires the owner role",
+	Args:  cobra.ExactArgs(3),
+	RunE:  runAdminChannelsSetRole,
+}
+
+var adminChannelsMuteCmd = &cobra.Command{
+	Use:   "mute <channel-id> <user-id>",
+	Short: "Mute a member",
+	Long:  "Stop a member posting in a channel; requires the moderator role",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runAdminChannelsMute,
+}
+
 func init() {
 	rootCmd.AddCommand(adminCmd)
 	adminCmd.AddCommand(adminUsersCmd)
 	adminCmd.AddCommand(adminStatsCmd)
 	adminCmd.AddCommand(adminConfigCmd)
+	adminCmd.AddCommand(adminChannelsCmd)
 
 	adminUsersCmd.AddCommand(adminUsersListCmd)
 	adminConfigCmd.AddCommand(adminConfigRateLimitCmd)
 	adminConfigCmd.AddCommand(adminConfigSecurityCmd)
+	adminChannelsCmd.AddCommand(adminChannelsMembersCmd)
+	adminChannelsCmd.AddCommand(adminChannelsAddMemberCmd)
+	adminChannelsCmd.AddCommand(adminChannelsRemoveMemberCmd)
+	adminChannelsCmd.AddCommand(adminChannelsSetRoleCmd)
+	adminChannelsCmd.AddCommand(adminChannelsMuteCmd)
+
+	// Channel membership flags
+	adminChannelsCmd.PersistentFlags().String("path", "", "Database file (default: database.path from the config)")
+	adminChannelsAddMemberCmd.Flags().String("role", string(database.RoleMember), "Role to give the new member (owner, moderator, member)")
+	adminChannelsMuteCmd.Flags().Bool("unmute", false, "Unmute the member instead")
 
 	// Users list flags
 	adminUsersListCmd.Flags().IntP("limit", "l", 50, "Number of users to retrieve")
@@ -106,8 +167,10 @@ func runAdminUsersList(cmd *cobra.Command, args []string) error {
 	page, _ := cmd.Flags().GetInt("page")
 	userType, _ := cmd.Flags().GetString("type")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -177,8 +240,10 @@ func runAdminStats(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -218,8 +283,10 @@ func runAdminConfigRateLimit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -277,8 +344,10 @@ func runAdminConfigSecurity(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -330,3 +399,215 @@ func runAdminConfigSecurity(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// openChannelDatabase opens the local database and returns the logged-in user's ID
+func openChannelDatabase(cmd *cobra.Command) (*database.Database, string, error) {
+	userID := viper.GetString("user_id")
+	if userID == "" {
+		return nil, "", fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	db, err := database.NewDatabase(localDatabasePath(cmd))
+	if err != nil {
+		return nil, "", err
+	}
+	return db, userID, nil
+}
+
+// requireChannelRole returns the user's membership in a channel, failing unless
+// they hold at least the given role
+func requireChannelRole(ctx context.Context, db *database.Database, channelID, userID string, role database.MemberRole) (*database.ChannelMember, error) {
+	member, err := db.GetMember(ctx, channelID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrMemberNotFound) {
+			return nil, fmt.Errorf("you are not a member of channel %s", channelID)
+		}
+		return nil, err
+	}
+	if !member.Role.AtLeast(role) {
+		return nil, fmt.Errorf("this requires the %s role in channel %s; you are a %s", role, channelID, member.Role)
+	}
+	return member, nil
+}
+
+// requireOutranks fails unless actor may manage target: owners manage everyone,
+// moderators only those ranked below them
+func requireOutranks(actor, target *database.ChannelMember) error {
+	if actor.Role == database.RoleOwner || !target.Role.AtLeast(actor.Role) {
+		return nil
+	}
+	return fmt.Errorf("a %s cannot manage another %s", actor.Role, target.Role)
+}
+
+func runAdminChannelsMembers(cmd *cobra.Command, args []string) error {
+	channelID := args[0]
+
+	db, userID, err := openChannelDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	channel, err := db.GetChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if channel.Private {
+		if _, err := requireChannelRole(ctx, db, channelID, userID, database.RoleMember); err != nil {
+			return err
+		}
+	}
+
+	members, err := db.ListMembers(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("User ID", "Role", "Muted", "Joined")
+
+	for _, member := range members {
+		muted := "No"
+		if member.Muted {
+			muted = "Yes"
+		}
+		table.Append([]string{
+			member.UserID,
+			string(member.Role),
+			muted,
+			member.JoinedAt.Local().Format("2006-01-02 15:04"),
+		})
+	}
+
+	fmt.Printf("Members of %s\n", channel.Name)
+	table.Render()
+	fmt.Printf("Total members: %d\n", len(members))
+
+	return nil
+}
+
+func runAdminChannelsAddMember(cmd *cobra.Command, args []string) error {
+	channelID, targetID := args[0], args[1]
+
+	roleName, _ := cmd.Flags().GetString("role")
+	role, err := database.ParseMemberRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	db, userID, err := openChannelDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	required := database.RoleModerator
+	if role != database.RoleMember {
+		required = database.RoleOwner
+	}
+
+	ctx := context.Background()
+	if _, err := requireChannelRole(ctx, db, channelID, userID, required); err != nil {
+		return err
+	}
+	if err := db.AddMember(ctx, channelID, targetID, role); err != nil {
+		return err
+	}
+
+	color.Green("✓ Added %s to %s as %s", targetID, channelID, role)
+	return nil
+}
+
+func runAdminChannelsRemoveMember(cmd *cobra.Command, args []string) error {
+	channelID, targetID := args[0], args[1]
+
+	db, userID, err := openChannelDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if targetID != userID {
+		actor, err := requireChannelRole(ctx, db, channelID, userID, database.RoleModerator)
+		if err != nil {
+			return err
+		}
+		target, err := db.GetMember(ctx, channelID, targetID)
+		if err != nil {
+			return err
+		}
+		if err := requireOutranks(actor, target); err != nil {
+			return err
+		}
+	}
+
+	if err := db.RemoveMember(ctx, channelID, targetID); err != nil {
+		return err
+	}
+
+	color.Green("✓ Removed %s from %s", targetID, channelID)
+	return nil
+}
+
+func runAdminChannelsSetRole(cmd *cobra.Command, args []string) error {
+	channelID, targetID := args[0], args[1]
+
+	role, err := database.ParseMemberRole(args[2])
+	if err != nil {
+		return err
+	}
+
+	db, userID, err := openChannelDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := requireChannelRole(ctx, db, channelID, userID, database.RoleOwner); err != nil {
+		return err
+	}
+	if err := db.SetMemberRole(ctx, channelID, targetID, role); err != nil {
+		return err
+	}
+
+	color.Green("✓ %s is now a %s of %s", targetID, role, channelID)
+	return nil
+}
+
+func runAdminChannelsMute(cmd *cobra.Command, args []string) error {
+	channelID, targetID := args[0], args[1]
+	unmute, _ := cmd.Flags().GetBool("unmute")
+
+	db, userID, err := openChannelDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	actor, err := requireChannelRole(ctx, db, channelID, userID, database.RoleModerator)
+	if err != nil {
+		return err
+	}
+	target, err := db.GetMember(ctx, channelID, targetID)
+	if err != nil {
+		return err
+	}
+	if err := requireOutranks(actor, target); err != nil {
+		return err
+	}
+
+	if err := db.SetMemberMuted(ctx, channelID, targetID, !unmute); err != nil {
+		return err
+	}
+
+	if unmute {
+		color.Green("✓ Unmuted %s in %s", targetID, channelID)
+	} else {
+		color.Green("✓ Muted %s in %s", targetID, channelID)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 	"syscall"
 	"time"
 
@@ -69,7 +68,10 @@ func init() {
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
-	c := client.NewClient(viper.GetString("url"))
+	c, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
 	username, _ := cmd.Flags().GetString("username")
 	password, _ := cmd.Flags().GetString("password")
@@ -114,19 +116,12 @@ func runLogin(cmd *cobra.Command, args []string) error {
 
 	// Save token if requested
 	if save {
-		viper.Set("token", loginResp.AccessToken)
-		viper.Set("refresh_token", loginResp.RefreshToken)
-		viper.Set("username", loginResp.Username)
-		viper.Set("user_id", loginResp.UserID)
-
-		// Save to config file
-		configFile := viper.ConfigFileUsed()
-		if configFile == "" {
-			home, _ := os.UserHomeDir()
-			configFile = home + "/.plexichat-client.yaml"
-		}
-
-		err = viper.WriteConfigAs(configFile)
+		_, err = saveSession(map[string]interface{}{
+			"token":         loginResp.AccessToken,
+			"refresh_token": loginResp.RefreshToken,
+			"username":      loginResp.Username,
+			"user_id":       loginResp.UserID,
+		})
 		if err != nil {
 			color.Yellow("Warning: Could not save token to config file: %v", err)
 		}
@@ -144,19 +139,12 @@ func runLogin(cmd *cobra.Command, args []string) error {
 
 func runLogout(cmd *cobra.Command, args []string) error {
 	// Clear stored tokens
-	viper.Set("token", "")
-	viper.Set("refresh_token", "")
-	viper.Set("username", "")
-	viper.Set("user_id", 0)
-
-	// Save to config file
-	configFile := viper.ConfigFileUsed()
-	if configFile == "" {
-		home, _ := os.UserHomeDir()
-		configFile = home + "/.plexichat-client.yaml"
-	}
-
-	err := viper.WriteConfigAs(configFile)
+	_, err := saveSession(map[string]interface{}{
+		"token":         "",
+		"refresh_token": "",
+		"username":      "",
+		"user_id":       "",
+	})
 	if err != nil {
 		color.Yellow("Warning: Could not save config file: %v", err)
 	}
@@ -166,7 +154,10 @@ func runLogout(cmd *cobra.Command, args []string) error {
 }
 
 func runRegister(cmd *cobra.Command, args []string) error {
-	c := client.NewClient(viper.GetString("url"))
+	c, err := newAPIClient()
+	if err != nil {
+		return err
+	}
 
 	username, _ := cmd.Flags().GetString("username")
 	email, _ := cmd.Flags().GetString("email")
@@ -232,8 +223,10 @@ func runWhoami(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -258,3 +251,28 @@ func runWhoami(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// newAuthenticatedClient returns an API client for the configured server that renews
+// an expired access token with the stored refresh token and saves the result
+func newAuthenticatedClient(token string) (*client.Client, error) {
+	c, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetToken(token)
+	c.SetRefreshToken(viper.GetString("refresh_token"))
+	c.OnTokenRefresh(saveRefreshedTokens)
+	return c, nil
+}
+
+// saveRefreshedTokens persists tokens obtained by an automatic refresh
+func saveRefreshedTokens(resp *client.LoginResponse) {
+	_, err := saveSession(map[string]interface{}{
+		"token":         resp.AccessToken,
+		"refresh_token": resp.RefreshToken,
+	})
+	if err != nil {
+		color.Yellow("Warning: Could not save refreshed token to config file: %v", err)
+	}
+}
@@ -5,19 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/signal"
 	"strconv"
-	"strings"
-	"syscall"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/gorilla/websocket"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/database"
 )
 
 var chatCmd = &cobra.Command{
@@ -54,12 +51,92 @@ var roomsCmd = &cobra.Command{
 	RunE:  runRooms,
 }
 
+var editCmd = &cobra.Command{
+	Use:   "edit <message-id>",
+	Short: "Edit a message",
+	Long:  "Replace the content of a message you sent",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEdit,
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <message-id>",
+	Short: "Delete a message",
+	Long:  "Delete a message you sent",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDelete,
+}
+
+var replyCmd = &cobra.Command{
+	Use:   "reply <message-id>",
+	Short: "Reply to a message",
+	Long:  "Post a reply in the thread of a message",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runReply,
+}
+
+var reactCmd = &cobra.Command{
+	Use:   "react <message-id> <emoji>",
+	Short: "React to a message",
+	Long:  "Add or remove an emoji reaction on a message",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runReact,
+}
+
+var roomsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a chat room",
+	Long:  "Create a new chat room and optionally invite users to it",
+	RunE:  runRoomsCreate,
+}
+
+var roomsJoinCmd = &cobra.Command{
+	Use:   "join <room-id>",
+	Short: "Join a chat room",
+	Long:  "Join an existing chat room",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRoomsJoin,
+}
+
+var roomsLeaveCmd = &cobra.Command{
+	Use:   "leave <room-id>",
+	Short: "Leave a chat room",
+	Long:  "Leave a chat room you are a member of",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRoomsLeave,
+}
+
+var roomsMembersCmd = &cobra.Command{
+	Use:   "members <room-id>",
+	Short: "List room members",
+	Long:  "List the members of a chat room",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRoomsMembers,
+}
+
+var editsCmd = &cobra.Command{
+	Use:   "edits <message-id>",
+	Short: "Show a message's edit history",
+	Long:  "Show what a message said before each edit or delete, from the local message database",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEdits,
+}
+
 func init() {
 	rootCmd.AddCommand(chatCmd)
 	chatCmd.AddCommand(sendCmd)
 	chatCmd.AddCommand(listenCmd)
 	chatCmd.AddCommand(historyCmd)
 	chatCmd.AddCommand(roomsCmd)
+	chatCmd.AddCommand(editCmd)
+	chatCmd.AddCommand(deleteCmd)
+	chatCmd.AddCommand(replyCmd)
+	chatCmd.AddCommand(reactCmd)
+	chatCmd.AddCommand(editsCmd)
+	roomsCmd.AddCommand(roomsCreateCmd)
+	roomsCmd.AddCommand(roomsJoinCmd)
+	roomsCmd.AddCommand(roomsLeaveCmd)
+	roomsCmd.AddCommand(roomsMembersCmd)
 
 	// Send flags
 	sendCmd.Flags().StringP("message", "m", "", "Message content")
@@ -75,6 +152,23 @@ func init() {
 	historyCmd.Flags().StringP("recipient", "r", "", "Recipient User ID")
 	historyCmd.Flags().IntP("limit", "l", 50, "Number of messages to retrieve")
 	historyCmd.Flags().IntP("page", "p", 1, "Page number")
+
+	// Edit, reply and react flags
+	editCmd.Flags().StringP("message", "m", "", "New message content")
+	editCmd.MarkFlagRequired("message")
+	replyCmd.Flags().StringP("message", "m", "", "Reply content")
+	replyCmd.MarkFlagRequired("message")
+	reactCmd.Flags().Bool("remove", false, "Remove the reaction instead of adding it")
+
+	// Room flags
+	roomsCreateCmd.Flags().StringP("name", "n", "", "Room name")
+	roomsCreateCmd.Flags().StringP("description", "d", "", "Room description")
+	roomsCreateCmd.Flags().Bool("private", false, "Make the room private")
+	roomsCreateCmd.Flags().IntSlice("invite", nil, "User IDs to invite")
+	roomsCreateCmd.MarkFlagRequired("name")
+
+	roomsMembersCmd.Flags().IntP("limit", "l", 50, "Number of members to retrieve")
+	roomsMembersCmd.Flags().IntP("page", "p", 1, "Page number")
 }
 
 func runSend(cmd *cobra.Command, args []string) error {
@@ -86,8 +180,10 @@ func runSend(cmd *cobra.Command, args []string) error {
 	message, _ := cmd.Flags().GetString("message")
 	recipientID, _ := cmd.Flags().GetString("recipient")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -118,98 +214,169 @@ func runSend(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runListen(cmd *cobra.Command, args []string) error {
+func runEdit(cmd *cobra.Command, args []string) error {
 	token := viper.GetString("token")
 	if token == "" {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	recipientID, _ := cmd.Flags().GetString("recipient")
-	listenAll, _ := cmd.Flags().GetBool("all")
+	messageID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid message ID: %s", args[0])
+	}
+	content, _ := cmd.Flags().GetString("message")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg, err := c.EditMessage(ctx, messageID, content)
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	recordLocalRevision(cmd, func(ctx context.Context, db *database.Database, editorID string) error {
+		return db.UpdateMessage(ctx, int64(messageID), editorID, content)
+	})
+
+	color.Green("✓ Message %d edited", msg.ID)
+	return nil
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	messageID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid message ID: %s", args[0])
+	}
 
-	// Determine WebSocket endpoint
-	endpoint := "/ws/chat"
-	if !listenAll {
-		endpoint = fmt.Sprintf("/ws/chat/room/%s", recipientID)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Connect to WebSocket
-	conn, err := c.ConnectWebSocket(ctx, endpoint)
+	if err := c.DeleteMessage(ctx, messageID); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	recordLocalRevision(cmd, func(ctx context.Context, db *database.Database, editorID string) error {
+		return db.DeleteMessage(ctx, int64(messageID), editorID)
+	})
+
+	color.Green("✓ Message %d deleted", messageID)
+	return nil
+}
+
+func runEdits(cmd *cobra.Command, args []string) error {
+	messageID, err := strconv.ParseInt(args[0], 10, 64)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
-	}
-	defer conn.Close()
-
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	color.Green("✓ Connected to chat!")
-	if listenAll {
-		fmt.Println("Listening to all rooms... (Press Ctrl+C to exit)")
-	} else {
-		fmt.Printf("Listening to room %s... (Press Ctrl+C to exit)\n", recipientID)
-	}
-	fmt.Println(strings.Repeat("-", 50))
-
-	// Listen for messages
-	go func() {
-		for {
-			var wsMsg client.WebSocketMessage
-			err := conn.ReadJSON(&wsMsg)
-			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					color.Red("WebSocket error: %v", err)
-				}
-				cancel()
-				return
-			}
-
-			// Handle different message types
-			switch wsMsg.Type {
-			case "message":
-				// Parse message data
-				msgData, _ := json.Marshal(wsMsg.Data)
-				var msg client.MessageResponse
-				json.Unmarshal(msgData, &msg)
-
-				// Display message
-				timestamp := msg.Timestamp
-				roomInfo := ""
-				if listenAll {
-					roomInfo = fmt.Sprintf("[%s] ", "Direct Message")
-				}
-
-				color.Cyan("[%s] %s%s: %s", timestamp, roomInfo, "Unknown", msg.Content)
-
-			case "user_joined":
-				color.Yellow("→ User joined the room")
-			case "user_left":
-				color.Yellow("← User left the room")
-			case "typing":
-				color.Blue("💬 Someone is typing...")
-			default:
-				if viper.GetBool("verbose") {
-					fmt.Printf("Unknown message type: %s\n", wsMsg.Type)
-				}
-			}
+		return fmt.Errorf("invalid message ID: %s", args[0])
+	}
+
+	revisions, err := loadMessageEdits(localDatabasePath(cmd), messageID)
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) == 0 {
+		fmt.Printf("Message %d has not been edited.\n", messageID)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("#", "Action", "Editor", "When", "Previous Content")
+
+	for i, revision := range revisions {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			string(revision.Action),
+			revision.EditorID,
+			revision.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			revision.Content,
+		})
+	}
+
+	fmt.Printf("Edit history of message %d:\n", messageID)
+	table.Render()
+	return nil
+}
+
+func runReply(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	parentID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid message ID: %s", args[0])
+	}
+	content, _ := cmd.Flags().GetString("message")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg, err := c.ReplyToMessage(ctx, parentID, content)
+	if err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+
+	color.Green("✓ Reply sent successfully!")
+	fmt.Printf("Message ID: %d\n", msg.ID)
+	fmt.Printf("In reply to: %d\n", parentID)
+
+	return nil
+}
+
+func runReact(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	messageID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid message ID: %s", args[0])
+	}
+	emoji := args[1]
+	remove, _ := cmd.Flags().GetBool("remove")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if remove {
+		if err := c.RemoveReaction(ctx, messageID, emoji); err != nil {
+			return fmt.Errorf("failed to remove reaction: %w", err)
 		}
-	}()
+		color.Green("✓ Removed %s from message %d", emoji, messageID)
+		return nil
+	}
 
-	// Wait for signal or context cancellation
-	select {
-	case <-sigChan:
-		fmt.Println("\nDisconnecting...")
-	case <-ctx.Done():
-		fmt.Println("\nConnection closed")
+	reaction, err := c.AddReaction(ctx, messageID, emoji)
+	if err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
 	}
 
+	color.Green("✓ Reacted %s to message %d (%d total)", reaction.Emoji, messageID, reaction.Count)
 	return nil
 }
 
@@ -223,8 +390,10 @@ func runHistory(cmd *cobra.Command, args []string) error {
 	limit, _ := cmd.Flags().GetInt("limit")
 	page, _ := cmd.Flags().GetInt("page")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -282,8 +451,10 @@ func runRooms(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -309,9 +480,11 @@ func runRooms(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	unread := loadUnreadCounts(localDatabasePath(cmd))
+
 	// Display rooms in a table
 	table := tablewriter.NewWriter(os.Stdout)
-	table.Header("ID", "Name", "Description", "Private", "Created")
+	table.Header("ID", "Name", "Description", "Private", "Unread", "Created")
 
 	for _, room := range rooms {
 		description := room.Description
@@ -329,6 +502,7 @@ func runRooms(cmd *cobra.Command, args []string) error {
 			room.Name,
 			description,
 			private,
+			unreadBadge(unread[strconv.Itoa(room.ID)]),
 			room.Created,
 		})
 	}
@@ -339,3 +513,153 @@ func runRooms(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runRoomsCreate(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	description, _ := cmd.Flags().GetString("description")
+	private, _ := cmd.Flags().GetBool("private")
+	invite, _ := cmd.Flags().GetIntSlice("invite")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	room, err := c.CreateRoom(ctx, &client.CreateRoomRequest{
+		Name:        name,
+		Description: description,
+		IsPrivate:   private,
+		MemberIDs:   invite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create room: %w", err)
+	}
+
+	color.Green("✓ Room created successfully!")
+	fmt.Printf("Room ID: %d\n", room.ID)
+	fmt.Printf("Name: %s\n", room.Name)
+	if len(invite) > 0 {
+		fmt.Printf("Invited: %d user(s)\n", len(invite))
+	}
+
+	return nil
+}
+
+func runRoomsJoin(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	roomID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid room ID: %s", args[0])
+	}
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := c.JoinRoom(ctx, roomID); err != nil {
+		return fmt.Errorf("failed to join room: %w", err)
+	}
+
+	color.Green("✓ Joined room %d", roomID)
+	return nil
+}
+
+func runRoomsLeave(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	roomID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid room ID: %s", args[0])
+	}
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := c.LeaveRoom(ctx, roomID); err != nil {
+		return fmt.Errorf("failed to leave room: %w", err)
+	}
+
+	color.Green("✓ Left room %d", roomID)
+	return nil
+}
+
+func runRoomsMembers(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	roomID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid room ID: %s", args[0])
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	page, _ := cmd.Flags().GetInt("page")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	listResp, err := c.GetRoomMembers(ctx, roomID, limit, page)
+	if err != nil {
+		return fmt.Errorf("failed to get room members: %w", err)
+	}
+
+	if len(listResp.Members) == 0 {
+		fmt.Println("No members found.")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("User ID", "Username", "Role", "Online", "Joined")
+
+	for _, member := range listResp.Members {
+		online := "No"
+		if member.IsOnline {
+			online = "Yes"
+		}
+
+		table.Append([]string{
+			strconv.Itoa(member.UserID),
+			member.Username,
+			member.Role,
+			online,
+			member.JoinedAt,
+		})
+	}
+
+	fmt.Printf("Members of Room %d (Page %d of %d)\n", roomID, page, listResp.TotalPages)
+	table.Render()
+	fmt.Printf("Total members: %d\n", listResp.Total)
+
+	return nil
+}
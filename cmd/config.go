@@ -119,6 +119,10 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		color.Yellow("No config file found")
 	}
 
+	if name := viper.GetString("profile"); name != "" {
+		fmt.Printf("Profile: %s\n", name)
+	}
+
 	return nil
 }
 
@@ -138,6 +142,27 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 
 	viper.Set(key, finalValue)
 
+	// Connection settings belong to the active profile, if one is selected
+	if name := viper.GetString("profile"); name != "" {
+		var configFile string
+		var err error
+		if isProfileKey(key) {
+			configFile, err = updateActiveProfile(name, []string{key})
+		} else {
+			configFile, err = mainConfigFile()
+			if err == nil {
+				err = updateMainConfig(map[string]interface{}{key: finalValue})
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+
+		color.Green("✓ Configuration updated: %s = %v", key, finalValue)
+		fmt.Printf("Saved to: %s\n", configFile)
+		return nil
+	}
+
 	// Save to config file
 	configFile := viper.ConfigFileUsed()
 	if configFile == "" {
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"plexichat-client/pkg/config"
+	"plexichat-client/pkg/database"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Local database commands",
+	Long:  "Commands for managing the local message database",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage schema migrations",
+	Long: `Inspect and apply the local database's schema migrations.
+
+The database is migrated to the latest version whenever the client opens it to
+write; listings only read it. These commands are for inspecting it or stepping back after a bad upgrade.`,
+}
+
+var dbMigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	RunE:  runDBMigrateStatus,
+}
+
+var dbMigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	RunE:  runDBMigrateUp,
+}
+
+var dbMigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the newest migrations",
+	RunE:  runDBMigrateDown,
+}
+
+var dbRevisionsCmd = &cobra.Command{
+	Use:   "revisions",
+	Short: "Manage message edit history",
+}
+
+var dbRevisionsPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete old message revisions",
+	Long: `Delete message revisions older than the retention period, which is
+database.revision_retention from the config unless --older-than is given.`,
+	RunE: runDBRevisionsPurge,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbRevisionsCmd)
+	dbMigrateCmd.AddCommand(dbMigrateStatusCmd)
+	dbMigrateCmd.AddCommand(dbMigrateUpCmd)
+	dbMigrateCmd.AddCommand(dbMigrateDownCmd)
+	dbRevisionsCmd.AddCommand(dbRevisionsPurgeCmd)
+
+	dbCmd.PersistentFlags().String("path", "", "Database file (default: database.path from the config)")
+	dbMigrateUpCmd.Flags().Int("steps", 0, "Number of migrations to apply (0 applies all)")
+	dbMigrateDownCmd.Flags().Int("steps", 1, "Number of migrations to revert")
+	dbRevisionsPurgeCmd.Flags().Duration("older-than", 0, "Purge revisions older than this (default: database.revision_retention)")
+}
+
+// localDatabasePath returns the --path flag if the command has one, else the configured database
+func localDatabasePath(cmd *cobra.Command) string {
+	if path, _ := cmd.Flags().GetString("path"); path != "" {
+		return path
+	}
+	return configuredDatabasePath()
+}
+
+// configuredDatabasePath returns database.path from the config, or the default
+func configuredDatabasePath() string {
+	if path := viper.GetString("database.path"); path != "" {
+		return path
+	}
+	return config.DefaultConfig().Database.Path
+}
+
+// openLocalDatabase opens the local database without migrating it
+func openLocalDatabase(cmd *cobra.Command) (*database.Database, error) {
+	return database.OpenDatabase(localDatabasePath(cmd))
+}
+
+func runDBMigrateStatus(cmd *cobra.Command, args []string) error {
+	db, err := openLocalDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	statuses, err := db.MigrationStatus(context.Background())
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Version", "Description", "Status", "Applied At")
+
+	pending := 0
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		table.Append([]string{fmt.Sprintf("%d", status.Version), status.Description, state, appliedAt})
+	}
+	table.Render()
+
+	if pending > 0 {
+		color.Yellow("%d pending migration(s)", pending)
+	} else {
+		color.Green("✓ Schema is up to date")
+	}
+	return nil
+}
+
+func runDBMigrateUp(cmd *cobra.Command, args []string) error {
+	steps, _ := cmd.Flags().GetInt("steps")
+
+	db, err := openLocalDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := db.MigrateUp(context.Background(), steps)
+	if err != nil {
+		return err
+	}
+
+	if applied == 0 {
+		color.Green("✓ Schema is already up to date")
+		return nil
+	}
+	color.Green("✓ Applied %d migration(s)", applied)
+	return nil
+}
+
+func runDBMigrateDown(cmd *cobra.Command, args []string) error {
+	steps, _ := cmd.Flags().GetInt("steps")
+
+	db, err := openLocalDatabase(cmd)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	reverted, err := db.MigrateDown(context.Background(), steps)
+	if err != nil {
+		return err
+	}
+
+	if reverted == 0 {
+		color.Yellow("No migrations to revert")
+		return nil
+	}
+	color.Green("✓ Reverted %d migration(s)", reverted)
+	return nil
+}
+
+// revisionRetention returns database.revision_retention from the config, or the default
+func revisionRetention() time.Duration {
+	if viper.IsSet("database.revision_retention") {
+		return viper.GetDuration("database.revision_retention")
+	}
+	return config.DefaultConfig().Database.RevisionRetention
+}
+
+func runDBRevisionsPurge(cmd *cobra.Command, args []string) error {
+	retention := revisionRetention()
+	if cmd.Flags().Changed("older-than") {
+		retention, _ = cmd.Flags().GetDuration("older-than")
+	}
+	if retention <= 0 {
+		color.Yellow("Revision retention is disabled; nothing purged")
+		return nil
+	}
+
+	db, err := database.NewDatabase(localDatabasePath(cmd))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	purged, err := db.PurgeRevisions(context.Background(), time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	color.Green("✓ Purged %d revision(s) older than %s", purged, retention)
+	return nil
+}
+
+// loadMessageEdits returns a message's revisions from the local database, which
+// it reads without migrating. There are none if there is no database yet.
+func loadMessageEdits(path string, messageID int64) ([]*database.MessageRevision, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+
+	db, err := database.OpenDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return db.GetMessageRevisions(context.Background(), messageID)
+}
+
+// recordLocalRevision applies an edit or delete the server has accepted to the
+// local copy of the message, recording the revision "chat edits" shows. Messages
+// the local database does not hold are skipped. Other failures are only
+// reported, since the server already has the change.
+func recordLocalRevision(cmd *cobra.Command, change func(ctx context.Context, db *database.Database, editorID string) error) {
+	path := localDatabasePath(cmd)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+
+	db, err := database.NewDatabase(path)
+	if err != nil {
+		color.Yellow("⚠ Could not open the local database: %v", err)
+		return
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = change(ctx, db, viper.GetString("user_id"))
+	if err != nil && !errors.Is(err, database.ErrMessageNotFound) {
+		color.Yellow("⚠ Could not record the change locally: %v", err)
+	}
+}
+
+// loadUnreadCounts returns the logged-in user's unread counts from the local
+// database, keyed by channel ID, or nil if there is no database or user yet.
+// Only messages stored through realtime.RealtimeManager.ReceiveMessage count.
+func loadUnreadCounts(path string) map[string]*database.UnreadCount {
+	db := openUnreadDatabase(path)
+	if db == nil {
+		return nil
+	}
+	defer db.Close()
+
+	return unreadCounts(db)
+}
+
+// openUnreadDatabase opens the local database for reading unread counts, without
+// migrating it, or returns nil if there is no database or user yet
+func openUnreadDatabase(path string) *database.Database {
+	if viper.GetString("user_id") == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	db, err := database.OpenDatabase(path)
+	if err != nil {
+		return nil
+	}
+	return db
+}
+
+// unreadCounts returns the logged-in user's unread counts, or nil if the database
+// cannot answer, e.g. because it predates unread tracking
+func unreadCounts(db *database.Database) map[string]*database.UnreadCount {
+	counts, err := db.GetUnreadCounts(context.Background(), viper.GetString("user_id"), viper.GetString("username"))
+	if err != nil {
+		return nil
+	}
+	return counts
+}
+
+// unreadBadge formats a channel's unread count for listings, "" when it has none
+func unreadBadge(count *database.UnreadCount) string {
+	if count == nil || count.Unread == 0 {
+		return ""
+	}
+	if count.Mentions > 0 {
+		return fmt.Sprintf("%d (%d @)", count.Unread, count.Mentions)
+	}
+	return strconv.Itoa(count.Unread)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"plexichat-client/pkg/database"
+)
+
+func TestUnreadBadge(t *testing.T) {
+	tests := []struct {
+		count    *database.UnreadCount
+		expected string
+	}{
+		{nil, ""},
+		{&database.UnreadCount{}, ""},
+		{&database.UnreadCount{Unread: 4}, "4"},
+		{&database.UnreadCount{Unread: 4, Mentions: 1}, "4 (1 @)"},
+	}
+
+	for _, test := range tests {
+		if got := unreadBadge(test.count); got != test.expected {
+			t.Errorf("Expected %q for %+v, got %q", test.expected, test.count, got)
+		}
+	}
+}
+
+func TestLoadUnreadCounts_DoesNotMigrate(t *testing.T) {
+	viper.Set("user_id", "1")
+	defer viper.Set("user_id", "")
+
+	path := filepath.Join(t.TempDir(), "client.db")
+	if counts := loadUnreadCounts(path); counts != nil {
+		t.Errorf("Expected no counts without a database, got %v", counts)
+	}
+
+	db, err := database.OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	if counts := loadUnreadCounts(path); counts != nil {
+		t.Errorf("Expected no counts from an unmigrated database, got %v", counts)
+	}
+	if version, err := db.SchemaVersion(context.Background()); err != nil || version != 0 {
+		t.Errorf("Expected the listing to leave the schema at version 0, got %d (%v)", version, err)
+	}
+}
+
+func TestRecordLocalRevision(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "client.db")
+	viper.Set("database.path", path)
+	viper.Set("user_id", "1")
+	defer viper.Set("database.path", "")
+	defer viper.Set("user_id", "")
+
+	db, err := database.NewDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if err := db.SaveUser(ctx, &database.User{ID: "1", Username: "alice", DisplayName: "Alice"}); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+	if err := db.SaveChannel(ctx, &database.Channel{ID: "5", Name: "general", CreatedBy: "1"}); err != nil {
+		t.Fatalf("Failed to save channel: %v", err)
+	}
+	// Stored under the server's message ID, which the edit command is given
+	msg := &database.Message{ID: 42, ChannelID: "5", UserID: "1", Username: "alice", Content: "helo"}
+	if err := db.SaveMessage(ctx, msg); err != nil || msg.ID != 42 {
+		t.Fatalf("Failed to save message 42: ID %d, %v", msg.ID, err)
+	}
+	db.Close()
+
+	recordLocalRevision(&cobra.Command{}, func(ctx context.Context, db *database.Database, editorID string) error {
+		return db.UpdateMessage(ctx, 42, editorID, "hello")
+	})
+
+	revisions, err := loadMessageEdits(path, 42)
+	if err != nil {
+		t.Fatalf("Failed to load edits: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Content != "helo" || revisions[0].EditorID != "1" {
+		t.Errorf("Expected one edit of \"helo\" by user 1, got %+v", revisions)
+	}
+}
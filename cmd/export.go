@@ -79,9 +79,9 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize client
-	apiClient := client.NewClient(viper.GetString("url"))
-	if token := viper.GetString("token"); token != "" {
-		apiClient.SetToken(token)
+	apiClient, err := newAuthenticatedClient(viper.GetString("token"))
+	if err != nil {
+		return err
 	}
 
 	// Create cached client
@@ -91,7 +91,6 @@ func runExport(cmd *cobra.Command, args []string) error {
 	historyManager := history.NewHistoryManager(cachedClient)
 
 	var exportData map[string]interface{}
-	var err error
 
 	if exportConfig {
 		exportData, err = exportConfiguration()
@@ -175,26 +174,13 @@ func exportSingleConversation(ctx context.Context, historyManager *history.Histo
 	}
 
 	// Get all messages
-	var allMessages []client.Message
-	page := 1
-	limit := 100
-
-	for {
-		resp, err := cachedClient.GetMessages(userID, limit, page)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get messages: %w", err)
-		}
-
-		if len(resp.Messages) == 0 {
-			break
-		}
-
-		allMessages = append(allMessages, resp.Messages...)
-
-		if !resp.HasNext {
-			break
-		}
-		page++
+	pages := client.Paginate(ctx, cachedClient.MessagePages(userID), client.PaginateOptions{
+		PageSize: 100,
+		Prefetch: true,
+	})
+	allMessages, err := pages.All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
 
 	// Reverse to get chronological order
@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
@@ -17,6 +18,7 @@ import (
 	"github.com/spf13/viper"
 
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/files"
 )
 
 var filesCmd = &cobra.Command{
@@ -72,11 +74,18 @@ func init() {
 	filesUploadCmd.Flags().StringP("file", "f", "", "File path to upload")
 	filesUploadCmd.Flags().String("description", "", "File description")
 	filesUploadCmd.Flags().Bool("public", false, "Make file public")
+	filesUploadCmd.Flags().Bool("chunked", false, "Upload in checksummed chunks")
+	filesUploadCmd.Flags().Bool("resume", false, "Resume an interrupted chunked upload (implies --chunked)")
+	filesUploadCmd.Flags().Int64("chunk-size", files.DefaultFileManagerConfig().ChunkSize, "Chunk size in bytes for chunked uploads")
+	filesUploadCmd.Flags().Int("concurrency", files.DefaultFileManagerConfig().ConcurrentUploads, "Chunks uploaded in parallel")
 	filesUploadCmd.MarkFlagRequired("file")
 
 	// Download flags
 	filesDownloadCmd.Flags().IntP("id", "i", 0, "File ID to download")
 	filesDownloadCmd.Flags().StringP("output", "o", "", "Output file path")
+	filesDownloadCmd.Flags().Bool("resume", false, "Resume from an existing .part file")
+	filesDownloadCmd.Flags().Int("segments", 1, "Number of parallel range requests")
+	filesDownloadCmd.Flags().Bool("no-verify", false, "Skip hash verification of the downloaded file")
 	filesDownloadCmd.MarkFlagRequired("id")
 
 	// List flags
@@ -112,10 +121,23 @@ func runFilesUpload(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	chunked, _ := cmd.Flags().GetBool("chunked")
+	resume, _ := cmd.Flags().GetBool("resume")
+	chunked = chunked || resume
 
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second) // 5 minutes for large files
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if chunked {
+		// Chunked uploads can run for hours; stop only on interrupt so the resume token stays consistent
+		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), 300*time.Second) // 5 minutes for large files
+	}
 	defer cancel()
 
 	// Create progress bar
@@ -136,20 +158,41 @@ func runFilesUpload(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Uploading file: %s (%.2f MB)\n", filepath.Base(filePath), float64(fileInfo.Size())/1024/1024)
 
-	// Upload file
-	resp, err := c.UploadFile(ctx, "/api/v1/files", filePath)
-	if err != nil {
-		return fmt.Errorf("failed to upload file: %w", err)
-	}
+	var file *client.File
+	if chunked {
+		opts, err := uploadOptions(cmd)
+		if err != nil {
+			return err
+		}
+		opts.Resume = resume
+		opts.OnProgress = func(progress files.UploadProgress) {
+			bar.Set64(progress.BytesUploaded)
+		}
 
-	bar.Finish()
+		file, err = c.UploadFileChunked(ctx, filePath, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			if client.IsResumableUploadError(err) {
+				color.Yellow("Upload interrupted. Run again with --resume to continue.")
+			}
+			return fmt.Errorf("failed to upload file: %w", err)
+		}
+	} else {
+		// Upload file
+		resp, err := c.UploadFile(ctx, "/api/v1/files", filePath)
+		if err != nil {
+			return fmt.Errorf("failed to upload file: %w", err)
+		}
 
-	var file client.File
-	err = c.ParseResponse(resp, &file)
-	if err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		file = &client.File{}
+		err = c.ParseResponse(resp, file)
+		if err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
 	}
 
+	bar.Finish()
+
 	color.Green("✓ File uploaded successfully!")
 	fmt.Printf("File ID: %d\n", file.ID)
 	fmt.Printf("Filename: %s\n", file.Filename)
@@ -161,6 +204,31 @@ func runFilesUpload(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// uploadOptions builds chunked upload options from flags, storing resume tokens in the config directory
+func uploadOptions(cmd *cobra.Command) (*client.ChunkedUploadOptions, error) {
+	config := files.DefaultFileManagerConfig()
+	config.ChunkSize, _ = cmd.Flags().GetInt64("chunk-size")
+	config.ConcurrentUploads, _ = cmd.Flags().GetInt("concurrency")
+
+	resumeDir, err := uploadResumeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := client.UploadOptionsFromConfig(config)
+	opts.ResumeDir = resumeDir
+	return opts, nil
+}
+
+// uploadResumeDir returns the directory where chunked upload resume tokens are kept
+func uploadResumeDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "uploads"), nil
+}
+
 func runFilesDownload(cmd *cobra.Command, args []string) error {
 	token := viper.GetString("token")
 	if token == "" {
@@ -169,48 +237,30 @@ func runFilesDownload(cmd *cobra.Command, args []string) error {
 
 	fileID, _ := cmd.Flags().GetInt("id")
 	outputPath, _ := cmd.Flags().GetString("output")
+	resume, _ := cmd.Flags().GetBool("resume")
+	segments, _ := cmd.Flags().GetInt("segments")
+	noVerify, _ := cmd.Flags().GetBool("no-verify")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
+	// Stop only on interrupt; the .part file lets a later --resume pick up from here
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Get file info first
-	resp, err := c.Get(ctx, fmt.Sprintf("/api/v1/files/%d", fileID))
+	// Get file info first to determine the output path
+	file, err := c.GetFileInfo(ctx, fileID)
 	if err != nil {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	var file client.File
-	err = c.ParseResponse(resp, &file)
-	if err != nil {
-		return fmt.Errorf("failed to parse file info: %w", err)
-	}
-
 	// Determine output path
 	if outputPath == "" {
 		outputPath = file.Filename
 	}
 
-	// Download file
-	downloadResp, err := c.Get(ctx, fmt.Sprintf("/api/v1/files/%d/download", fileID))
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
-	}
-	defer downloadResp.Body.Close()
-
-	if downloadResp.StatusCode >= 400 {
-		return fmt.Errorf("download failed with status %d", downloadResp.StatusCode)
-	}
-
-	// Create output file
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outFile.Close()
-
 	// Create progress bar
 	bar := progressbar.NewOptions64(
 		file.Size,
@@ -229,9 +279,20 @@ func runFilesDownload(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Downloading file: %s (%.2f MB)\n", file.Filename, float64(file.Size)/1024/1024)
 
-	// Copy with progress
-	_, err = io.Copy(io.MultiWriter(outFile, bar), downloadResp.Body)
+	result, err := c.DownloadFile(ctx, fileID, outputPath, &client.DownloadOptions{
+		Segments:   segments,
+		Resume:     resume,
+		SkipVerify: noVerify,
+		File:       file,
+		OnProgress: func(downloaded, total int64) {
+			bar.Set64(downloaded)
+		},
+	})
 	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		if _, statErr := os.Stat(outputPath + ".part"); statErr == nil {
+			color.Yellow("Download interrupted. Run again with --resume to continue.")
+		}
 		return fmt.Errorf("failed to download file: %w", err)
 	}
 
@@ -239,6 +300,11 @@ func runFilesDownload(cmd *cobra.Command, args []string) error {
 
 	color.Green("✓ File downloaded successfully!")
 	fmt.Printf("Saved to: %s\n", outputPath)
+	if result.Verified {
+		fmt.Println("Integrity: verified")
+	} else if !noVerify && (file.Checksum != "" || file.Hash != "") {
+		color.Yellow("Integrity: not verified (unsupported hash algorithm)")
+	}
 
 	return nil
 }
@@ -253,8 +319,10 @@ func runFilesList(cmd *cobra.Command, args []string) error {
 	page, _ := cmd.Flags().GetInt("page")
 	fileType, _ := cmd.Flags().GetString("type")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -319,8 +387,10 @@ func runFilesDelete(cmd *cobra.Command, args []string) error {
 
 	fileID, _ := cmd.Flags().GetInt("id")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -349,8 +419,10 @@ func runFilesInfo(cmd *cobra.Command, args []string) error {
 
 	fileID, _ := cmd.Flags().GetInt("id")
 
-	c := client.NewClient(viper.GetString("url"))
-	c.SetToken(token)
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
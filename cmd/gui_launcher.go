@@ -3,11 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/database"
+	"plexichat-client/pkg/files"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -28,6 +32,8 @@ type GUIState struct {
 	user       *User
 	groups     []Group
 	messages   map[string][]Message
+	rooms      []client.Room                    // Sidebar channels
+	unread     map[string]*database.UnreadCount // Keyed by channel ID
 	mu         sync.RWMutex
 	isDarkMode bool
 	settings   *AppSettings
@@ -86,6 +92,8 @@ type Message struct {
 	Timestamp time.Time   `json:"timestamp"`
 	Avatar    *UserAvatar `json:"-"`
 	IsOwn     bool        `json:"-"`
+	Delivered bool        `json:"-"` // Own messages only: reached at least one recipient
+	SeenBy    []string    `json:"-"` // Own messages only: usernames that have read it
 }
 
 // RunGUI launches the native Fyne GUI application
@@ -183,6 +191,10 @@ func RunGUIWithOptions(debug bool) error {
 		return fmt.Errorf("failed to create API client for %s", serverURL)
 	}
 
+	if err := apiClient.SetTransportConfig(transportConfig()); err != nil {
+		return fmt.Errorf("failed to configure connection: %w", err)
+	}
+
 	// Initialize the GUI state with proper error checking
 	state := &GUIState{
 		app:        myApp,
@@ -389,23 +401,36 @@ func createLoginUI(state *GUIState) fyne.CanvasObject {
 func createMainUI(state *GUIState) fyne.CanvasObject {
 	// Create simple Discord-style layout
 
-	// Left sidebar with channels
+	// Left sidebar with the user's rooms, each with its unread badge
 	channelList := widget.NewList(
-		func() int { return 5 },
+		func() int {
+			state.mu.RLock()
+			defer state.mu.RUnlock()
+			return len(state.rooms)
+		},
 		func() fyne.CanvasObject {
 			return widget.NewLabel("# channel")
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			channels := []string{"# general", "# development", "# design", "# random", "# announcements"}
-			if id < len(channels) {
-				label := obj.(*widget.Label)
-				label.SetText(channels[id])
+			state.mu.RLock()
+			if id >= len(state.rooms) {
+				state.mu.RUnlock()
+				return
 			}
+			room := state.rooms[id]
+			state.mu.RUnlock()
+
+			label := obj.(*widget.Label)
+			label.SetText(channelLabel(state, room))
 		},
 	)
 
 	// Make sidebar fixed width
 	channelList.Resize(fyne.NewSize(200, 0))
+	go func() {
+		loadSidebarRooms(state, channelList)
+		watchUnreadCounts(state, channelList)
+	}()
 
 	// Simple chat area to avoid layout issues
 	chatArea := widget.NewEntry()
@@ -434,8 +459,12 @@ func createMainUI(state *GUIState) fyne.CanvasObject {
 		sendBtn.OnTapped()
 	}
 
+	editsBtn := widget.NewButtonWithIcon("", theme.HistoryIcon(), func() {
+		showMessageEditsDialog(state)
+	})
+
 	// Simple message input area
-	messageContainer := container.NewBorder(nil, nil, nil, sendBtn, messageInput)
+	messageContainer := container.NewBorder(nil, nil, nil, container.NewHBox(editsBtn, sendBtn), messageInput)
 
 	// Create main chat container with simple layout
 	chatContainer := container.NewBorder(
@@ -554,6 +583,11 @@ func performLogin(state *GUIState, username, password, serverURL string) {
 		return
 	}
 
+	if err := state.client.SetTransportConfig(transportConfig()); err != nil {
+		showErrorDialog(state, "Connection Settings", fmt.Sprintf("Invalid TLS or proxy configuration: %v", err))
+		return
+	}
+
 	// Show beautiful loading dialog
 	progressBar := widget.NewProgressBarInfinite()
 	progressContent := container.NewVBox(
@@ -624,12 +658,13 @@ func performLogin(state *GUIState, username, password, serverURL string) {
 
 		// Login successful - save token and user info
 		if loginResp.AccessToken != "" {
-			viper.Set("token", loginResp.AccessToken)
-			viper.Set("username", loginResp.Username)
-			viper.Set("user_id", loginResp.UserID)
-
 			// Save config
-			saveConfig()
+			saveSession(map[string]interface{}{
+				"token":         loginResp.AccessToken,
+				"refresh_token": loginResp.RefreshToken,
+				"username":      loginResp.Username,
+				"user_id":       loginResp.UserID,
+			})
 
 			// Update state
 			state.user = &User{
@@ -638,6 +673,7 @@ func performLogin(state *GUIState, username, password, serverURL string) {
 				Email:    username + "@example.com", // Will get from user profile later
 				Token:    loginResp.AccessToken,
 			}
+			watchTokenRefresh(state)
 
 			// Get user profile for complete info
 			go loadUserProfile(state)
@@ -993,6 +1029,12 @@ func createMessageWidget(msg *Message) *fyne.Container {
 		contentLabel,
 	)
 
+	// Show delivery state under own messages
+	if receipt := formatReceipt(msg); receipt != "" {
+		receiptLabel := widget.NewLabelWithStyle(receipt, fyne.TextAlignTrailing, fyne.TextStyle{Italic: true})
+		messageBody.Add(receiptLabel)
+	}
+
 	// Create full message container
 	messageContainer := container.NewHBox(
 		avatarWidget,
@@ -1009,6 +1051,66 @@ func createMessageWidget(msg *Message) *fyne.Container {
 	return container.NewPadded(messageContainer)
 }
 
+// formatReceipt describes how far an own message has got: sent, delivered or seen
+func formatReceipt(msg *Message) string {
+	if !msg.IsOwn {
+		return ""
+	}
+
+	const maxNames = 3
+	switch {
+	case len(msg.SeenBy) > maxNames:
+		return fmt.Sprintf("Seen by %s and %d others", strings.Join(msg.SeenBy[:maxNames], ", "), len(msg.SeenBy)-maxNames)
+	case len(msg.SeenBy) > 0:
+		return "Seen by " + strings.Join(msg.SeenBy, ", ")
+	case msg.Delivered:
+		return "✓ Delivered"
+	default:
+		return "Sent"
+	}
+}
+
+// refreshReadReceipts fetches delivery and read receipts for own messages in a channel
+func refreshReadReceipts(state *GUIState, channelID string) {
+	state.mu.RLock()
+	var ids []string
+	for _, msg := range state.messages[channelID] {
+		if msg.IsOwn {
+			ids = append(ids, msg.ID)
+		}
+	}
+	state.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, id := range ids {
+		var messageID int
+		if _, err := fmt.Sscanf(id, "%d", &messageID); err != nil {
+			continue
+		}
+
+		receipts, err := state.client.GetMessageReceipts(ctx, messageID)
+		if err != nil {
+			continue
+		}
+
+		seenBy := make([]string, 0, len(receipts.ReadBy))
+		for _, receipt := range receipts.ReadBy {
+			seenBy = append(seenBy, receipt.Username)
+		}
+
+		state.mu.Lock()
+		for i := range state.messages[channelID] {
+			if msg := &state.messages[channelID][i]; msg.ID == id {
+				msg.Delivered = len(receipts.DeliveredTo) > 0 || len(seenBy) > 0
+				msg.SeenBy = seenBy
+			}
+		}
+		state.mu.Unlock()
+	}
+}
+
 // showEmojiPicker displays an emoji picker dialog
 func showEmojiPicker(state *GUIState, messageInput *widget.Entry) {
 	// Define emoji categories
@@ -1098,6 +1200,59 @@ Access settings through the gear icon to customize:
 	helpDialog.Show()
 }
 
+// showMessageEditsDialog asks for a message ID and shows what the message said
+// before each edit or delete
+func showMessageEditsDialog(state *GUIState) {
+	messageID := widget.NewEntry()
+	messageID.SetPlaceHolder("Message ID")
+
+	form := container.NewVBox(
+		widget.NewLabel("Show the edit history of a message"),
+		messageID,
+	)
+
+	dialog.ShowCustomConfirm("Show Edits", "Show", "Cancel", form, func(confirm bool) {
+		if !confirm {
+			return
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSpace(messageID.Text), 10, 64)
+		if err != nil {
+			showErrorDialog(state, "Invalid Message ID", fmt.Sprintf("%q is not a message ID", messageID.Text))
+			return
+		}
+
+		revisions, err := loadMessageEdits(configuredDatabasePath(), id)
+		if err != nil {
+			showErrorDialog(state, "Edit History Unavailable", err.Error())
+			return
+		}
+		if len(revisions) == 0 {
+			dialog.ShowInformation("Show Edits", fmt.Sprintf("Message %d has not been edited.", id), state.window)
+			return
+		}
+
+		history := container.NewVBox()
+		for i, revision := range revisions {
+			header := fmt.Sprintf("%d. %s by %s, %s", i+1, revision.Action, revision.EditorID,
+				formatTimestamp(revision.CreatedAt))
+			content := widget.NewLabel(revision.Content)
+			content.Wrapping = fyne.TextWrapWord
+
+			history.Add(widget.NewLabelWithStyle(header, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			history.Add(content)
+			history.Add(widget.NewSeparator())
+		}
+
+		scrollableHistory := container.NewScroll(history)
+		scrollableHistory.SetMinSize(fyne.NewSize(450, 300))
+
+		historyDialog := dialog.NewCustom(fmt.Sprintf("🕘 Edits to Message %d", id), "Close", scrollableHistory, state.window)
+		historyDialog.Resize(fyne.NewSize(550, 400))
+		historyDialog.Show()
+	}, state.window)
+}
+
 // showFileUploadDialog displays a file picker for uploading files
 func showFileUploadDialog(state *GUIState) {
 	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -1124,10 +1279,11 @@ func showFileUploadDialog(state *GUIState) {
 			widget.NewLabel("Ready to upload this file?"),
 		)
 
+		filePath := reader.URI().Path()
+
 		dialog.ShowCustomConfirm("Upload File", "Upload", "Cancel", confirmContent, func(upload bool) {
 			if upload {
-				// TODO: Implement actual file upload
-				dialog.ShowInformation("Upload", fmt.Sprintf("File '%s' uploaded successfully!", fileName), state.window)
+				uploadFileWithProgress(state, filePath)
 			}
 		}, state.window)
 
@@ -1145,6 +1301,52 @@ func showFileUploadDialog(state *GUIState) {
 	fileDialog.Show()
 }
 
+// uploadFileWithProgress uploads a file in resumable chunks, showing progress in a dialog
+func uploadFileWithProgress(state *GUIState, filePath string) {
+	if state.user == nil || state.user.Token == "" {
+		showNotification(state, "Error", "Please login first")
+		return
+	}
+
+	progressBar := widget.NewProgressBar()
+	statusLabel := widget.NewLabel("Preparing upload...")
+	progressDialog := dialog.NewCustomWithoutButtons("Uploading", container.NewVBox(
+		widget.NewLabel(filepath.Base(filePath)),
+		progressBar,
+		statusLabel,
+	), state.window)
+	progressDialog.Show()
+
+	go func() {
+		state.client.SetToken(state.user.Token)
+
+		opts := client.UploadOptionsFromConfig(nil)
+		opts.Resume = true
+		if resumeDir, err := uploadResumeDir(); err == nil {
+			opts.ResumeDir = resumeDir
+		}
+		opts.OnProgress = func(progress files.UploadProgress) {
+			fyne.Do(func() {
+				progressBar.SetValue(progress.Percentage / 100)
+				statusLabel.SetText(fmt.Sprintf("%.1f of %.1f MB (%.0f KB/s)",
+					float64(progress.BytesUploaded)/1024/1024,
+					float64(progress.TotalBytes)/1024/1024,
+					float64(progress.Speed)/1024))
+			})
+		}
+
+		file, err := state.client.UploadFileChunked(context.Background(), filePath, opts)
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if err != nil {
+				showErrorDialog(state, "Upload Failed", fmt.Sprintf("Failed to upload file: %v", err))
+				return
+			}
+			dialog.ShowInformation("Upload", fmt.Sprintf("File '%s' uploaded successfully!", file.Filename), state.window)
+		})
+	}()
+}
+
 // createStatusBar creates a status bar with connection info and notifications
 func createStatusBar(state *GUIState) *fyne.Container {
 	// Connection status
@@ -1205,9 +1407,18 @@ func showNotification(state *GUIState, title, message string) {
 	}()
 }
 
-// saveConfig saves the current configuration to file
-func saveConfig() error {
-	return viper.WriteConfig()
+// watchTokenRefresh keeps the session and saved config in sync when the client
+// renews an expired access token in the background
+func watchTokenRefresh(state *GUIState) {
+	state.client.OnTokenRefresh(func(resp *client.LoginResponse) {
+		state.mu.Lock()
+		if state.user != nil {
+			state.user.Token = resp.AccessToken
+		}
+		state.mu.Unlock()
+
+		saveRefreshedTokens(resp)
+	})
 }
 
 // show2FADialog displays the 2FA authentication dialog
@@ -1265,11 +1476,12 @@ func perform2FALogin(state *GUIState, username, password, method, code string) {
 
 		// Handle successful 2FA login
 		if loginResp.AccessToken != "" {
-			viper.Set("token", loginResp.AccessToken)
-			viper.Set("username", loginResp.User.Username)
-			viper.Set("user_id", loginResp.User.ID)
-
-			saveConfig()
+			saveSession(map[string]interface{}{
+				"token":         loginResp.AccessToken,
+				"refresh_token": loginResp.RefreshToken,
+				"username":      loginResp.User.Username,
+				"user_id":       loginResp.User.ID,
+			})
 
 			state.user = &User{
 				ID:       fmt.Sprintf("%d", loginResp.User.ID),
@@ -1277,6 +1489,7 @@ func perform2FALogin(state *GUIState, username, password, method, code string) {
 				Email:    loginResp.User.Email,
 				Token:    loginResp.AccessToken,
 			}
+			watchTokenRefresh(state)
 
 			// Load user data and switch to main UI
 			go loadUserProfile(state)
@@ -1341,6 +1554,81 @@ func loadUserGroups(state *GUIState) {
 	}
 }
 
+// loadSidebarRooms fetches the rooms listed in the sidebar
+func loadSidebarRooms(state *GUIState, channelList *widget.List) {
+	state.mu.RLock()
+	loggedIn := state.user != nil
+	state.mu.RUnlock()
+	if !loggedIn {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	state.client.SetToken(state.user.Token)
+	rooms, err := client.Paginate(ctx, state.client.RoomPages(), client.PaginateOptions{}).All()
+	if err != nil {
+		showNotification(state, "Error", fmt.Sprintf("Failed to load rooms: %v", err))
+		return
+	}
+
+	state.mu.Lock()
+	state.rooms = rooms
+	state.mu.Unlock()
+	channelList.Refresh()
+}
+
+// channelLabel names a room in the sidebar, with its unread count if any. Counts
+// are keyed by room ID, as in "chat rooms".
+func channelLabel(state *GUIState, room client.Room) string {
+	state.mu.RLock()
+	badge := unreadBadge(state.unread[strconv.Itoa(room.ID)])
+	state.mu.RUnlock()
+
+	if badge == "" {
+		return "# " + room.Name
+	}
+	return fmt.Sprintf("# %s  •%s", room.Name, badge)
+}
+
+// watchUnreadCounts reloads unread counts from the local database and redraws
+// the channel list until the user logs out. The database is opened once, as
+// soon as it exists.
+func watchUnreadCounts(state *GUIState, channelList *widget.List) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	var db *database.Database
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	for {
+		if db == nil {
+			db = openUnreadDatabase(configuredDatabasePath())
+		}
+		var counts map[string]*database.UnreadCount
+		if db != nil {
+			counts = unreadCounts(db)
+		}
+
+		state.mu.Lock()
+		loggedIn := state.user != nil
+		state.unread = counts
+		state.mu.Unlock()
+
+		if !loggedIn {
+			return
+		}
+		channelList.Refresh()
+
+		<-ticker.C
+	}
+}
+
 // sendMessage sends a message to the specified channel
 func sendMessage(state *GUIState, content, channelID string) {
 	if state.user == nil || state.user.Token == "" {
@@ -1400,7 +1688,8 @@ func sendMessage(state *GUIState, content, channelID string) {
 func refreshChatDisplay(state *GUIState, channelID string) {
 	// This function would update the chat area with new messages
 	// Implementation depends on how the chat area is structured
-	// For now, this is a placeholder
+	// For now, only the read receipts of own messages are brought up to date
+	refreshReadReceipts(state, channelID)
 }
 
 // checkExistingSession checks if there's a valid existing session
@@ -1414,8 +1703,9 @@ func checkExistingSession(state *GUIState) bool {
 		return false
 	}
 
-	// Set token and test if it's still valid
+	// Set token and test if it's still valid; an expired token is refreshed transparently
 	state.client.SetToken(token)
+	state.client.SetRefreshToken(viper.GetString("refresh_token"))
 
 	// Try to get current user info to validate token
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -1435,6 +1725,10 @@ func checkExistingSession(state *GUIState) bool {
 		Email:    userResp.Email,
 		Token:    token,
 	}
+	if current, _ := state.client.Tokens(); current != token {
+		state.user.Token = current
+	}
+	watchTokenRefresh(state)
 
 	// Load user data
 	go loadUserGroups(state)
@@ -1444,11 +1738,12 @@ func checkExistingSession(state *GUIState) bool {
 
 // clearStoredSession clears all stored session data
 func clearStoredSession() {
-	viper.Set("token", "")
-	viper.Set("username", "")
-	viper.Set("user_id", "")
-	viper.Set("refresh_token", "")
-	saveConfig()
+	saveSession(map[string]interface{}{
+		"token":         "",
+		"refresh_token": "",
+		"username":      "",
+		"user_id":       "",
+	})
 }
 
 // logout performs logout and clears session
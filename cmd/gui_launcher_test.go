@@ -236,3 +236,22 @@ func BenchmarkMessageSearch(b *testing.B) {
 		}
 	}
 }
+
+func TestFormatReceipt(t *testing.T) {
+	tests := []struct {
+		msg      Message
+		expected string
+	}{
+		{Message{IsOwn: false, SeenBy: []string{"bob"}}, ""},
+		{Message{IsOwn: true}, "Sent"},
+		{Message{IsOwn: true, Delivered: true}, "✓ Delivered"},
+		{Message{IsOwn: true, Delivered: true, SeenBy: []string{"bob", "carol"}}, "Seen by bob, carol"},
+		{Message{IsOwn: true, SeenBy: []string{"a", "b", "c", "d", "e"}}, "Seen by a, b, c and 2 others"},
+	}
+
+	for _, test := range tests {
+		if got := formatReceipt(&test.msg); got != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, got)
+		}
+	}
+}
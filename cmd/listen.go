@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"plexichat-client/internal/realtime"
+	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/logging"
+)
+
+// backfillPageSize is the number of missed messages fetched per REST request
+const backfillPageSize = 100
+
+// listenFrameTypes are the WebSocket frames printed by chat listen
+var listenFrameTypes = []string{
+	"message", "message_edit", "message_delete", "reaction", "user_joined", "user_left", "typing",
+}
+
+// listenEvent is a state change or a frame, delivered to runListen in arrival order
+type listenEvent struct {
+	state *realtime.StateChange
+	frame *realtime.WebSocketMessage
+}
+
+// frameForwarder is a realtime.MessageHandler that hands frames to runListen
+type frameForwarder struct {
+	messageType string
+	events      chan<- listenEvent
+}
+
+func (f *frameForwarder) Handle(ctx context.Context, message *realtime.WebSocketMessage) error {
+	select {
+	case f.events <- listenEvent{frame: message}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *frameForwarder) GetMessageType() string { return f.messageType }
+
+func (f *frameForwarder) GetPriority() int { return 0 }
+
+// clientAuthenticator gives WebSocket handshakes the API client's current credentials,
+// so a reconnect after a token refresh uses the new token
+type clientAuthenticator struct {
+	client *client.Client
+}
+
+func (a *clientAuthenticator) Authenticate(ctx context.Context, conn *websocket.Conn) error {
+	return nil // Credentials travel in the handshake headers
+}
+
+func (a *clientAuthenticator) GetAuthHeaders() http.Header {
+	header := http.Header{}
+	if token, _ := a.client.Tokens(); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if a.client.APIKey != "" {
+		header.Set("X-API-Key", a.client.APIKey)
+	}
+	return header
+}
+
+func (a *clientAuthenticator) RefreshAuth(ctx context.Context) error {
+	_, err := a.client.RefreshAccessToken(ctx)
+	return err
+}
+
+func (a *clientAuthenticator) IsAuthenticated() bool {
+	token, _ := a.client.Tokens()
+	return token != "" || a.client.APIKey != ""
+}
+
+// messageCursor remembers the newest message shown so a reconnect can resume after it
+type messageCursor struct {
+	lastID   int
+	lastTime time.Time
+}
+
+// advance records a message and reports whether it has not been shown yet.
+// Messages without an ID cannot be deduplicated and are always shown.
+func (mc *messageCursor) advance(id int, timestamp time.Time) bool {
+	if id == 0 {
+		return true
+	}
+	if id <= mc.lastID {
+		return false
+	}
+
+	mc.lastID = id
+	if timestamp.After(mc.lastTime) {
+		mc.lastTime = timestamp
+	}
+	return true
+}
+
+// websocketURL converts the API base URL into a WebSocket URL for endpoint
+func websocketURL(baseURL, endpoint string) string {
+	wsURL := strings.Replace(baseURL, "http://", "ws://", 1)
+	wsURL = strings.Replace(wsURL, "https://", "wss://", 1)
+	return wsURL + endpoint
+}
+
+// fallbackTransports returns the SSE and long-poll endpoints carrying the same
+// stream as a WebSocket endpoint, for proxies that block WebSocket upgrades
+func fallbackTransports(baseURL, endpoint string) []realtime.FallbackTransport {
+	path := strings.TrimPrefix(endpoint, "/ws")
+	return []realtime.FallbackTransport{
+		{Kind: realtime.TransportSSE, URL: baseURL + "/sse" + path},
+		{Kind: realtime.TransportLongPoll, URL: baseURL + "/poll" + path},
+	}
+}
+
+func runListen(cmd *cobra.Command, args []string) error {
+	token := viper.GetString("token")
+	if token == "" {
+		return fmt.Errorf("not logged in. Use 'plexichat-client auth login' to authenticate")
+	}
+
+	roomID, _ := cmd.Flags().GetInt("room")
+	listenAll, _ := cmd.Flags().GetBool("all")
+
+	c, err := newAuthenticatedClient(token)
+	if err != nil {
+		return err
+	}
+
+	// Determine WebSocket endpoint
+	endpoint := "/ws/chat"
+	if listenAll {
+		roomID = 0
+	} else {
+		endpoint = fmt.Sprintf("/ws/chat/room/%d", roomID)
+	}
+
+	// Set up signal handling for graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	wsClient := realtime.NewWebSocketClient(realtime.WebSocketConfig{
+		URL:                   websocketURL(c.BaseURL, endpoint),
+		Headers:               map[string]string{"User-Agent": c.UserAgent},
+		ReconnectEnabled:      true,
+		ReconnectInterval:     time.Second,
+		ReconnectBackoff:      2,
+		ReconnectJitter:       0.5,
+		MaxReconnectInterval:  time.Minute,
+		MaxReconnectAttempts:  -1, // Listeners run as daemons; keep trying until stopped
+		AuthenticationEnabled: true,
+		Transport:             transportConfig(),
+		Fallbacks:             fallbackTransports(c.BaseURL, endpoint),
+	}, nil)
+	wsClient.SetAuthenticator(&clientAuthenticator{client: c})
+
+	logger := logging.NewLogger(logging.ERROR, nil, false)
+	if viper.GetBool("verbose") {
+		logger = logging.NewLogger(logging.DEBUG, os.Stderr, false)
+	}
+	wsClient.SetLogger(logger)
+
+	// Frames and state changes share one channel so a reconnect's backfill is
+	// printed before any live frame from the new connection
+	events := make(chan listenEvent, 256)
+	for _, frameType := range listenFrameTypes {
+		wsClient.RegisterMessageHandler(&frameForwarder{messageType: frameType, events: events})
+	}
+	wsClient.OnStateChange(func(change realtime.StateChange) {
+		select {
+		case events <- listenEvent{state: &change}:
+		case <-ctx.Done():
+		}
+	})
+
+	// Messages sent after startup are backfilled even if none arrive before a drop
+	cursor := &messageCursor{lastTime: time.Now()}
+
+	if err := wsClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+	defer wsClient.Disconnect()
+
+	connected := false
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nDisconnecting...")
+			return nil
+
+		case event := <-events:
+			if event.frame != nil {
+				printListenFrame(event.frame, cursor, listenAll)
+				continue
+			}
+
+			change := event.state
+			printConnectionState(*change, connected, listenAll, roomID)
+			if change.State != realtime.StateConnected {
+				continue
+			}
+			if transport := wsClient.Transport(); transport != "" && transport != realtime.TransportWebSocket {
+				color.Yellow("WebSocket unavailable; receiving over %s", transport)
+			}
+			if connected {
+				backfillMissedMessages(ctx, c, roomID, cursor, listenAll)
+			}
+			connected = true
+		}
+	}
+}
+
+// printConnectionState shows connection changes; connecting and transient errors
+// are folded into the reconnecting line
+func printConnectionState(change realtime.StateChange, reconnect, listenAll bool, roomID int) {
+	switch change.State {
+	case realtime.StateConnected:
+		if reconnect {
+			color.Green("✓ Reconnected")
+			return
+		}
+		color.Green("✓ Connected to chat!")
+		if listenAll {
+			fmt.Println("Listening to all rooms... (Press Ctrl+C to exit)")
+		} else {
+			fmt.Printf("Listening to room %d... (Press Ctrl+C to exit)\n", roomID)
+		}
+		fmt.Println(strings.Repeat("-", 50))
+
+	case realtime.StateReconnecting:
+		if change.Attempt == 0 {
+			color.Yellow("⚠ Connection lost: %v", change.Err)
+			return
+		}
+		if change.Err != nil {
+			color.Yellow("⟳ Reconnecting in %s (attempt %d, last error: %v)", change.Delay.Round(100*time.Millisecond), change.Attempt, change.Err)
+		} else {
+			color.Yellow("⟳ Reconnecting in %s (attempt %d)", change.Delay.Round(100*time.Millisecond), change.Attempt)
+		}
+	}
+}
+
+// backfillMissedMessages prints messages sent while the listener was disconnected
+func backfillMissedMessages(ctx context.Context, c *client.Client, roomID int, cursor *messageCursor, listenAll bool) {
+	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	missed := 0
+	for {
+		page, err := c.GetMessagesSince(fetchCtx, roomID, cursor.lastID, cursor.lastTime, backfillPageSize)
+		if err != nil {
+			color.Red("Failed to fetch missed messages: %v", err)
+			return
+		}
+
+		progressed := false
+		for _, msg := range page.Messages {
+			if cursor.advance(msg.ID, msg.Timestamp) {
+				printChatMessage(msg, listenAll)
+				missed++
+				progressed = true
+			}
+		}
+
+		if !page.HasNext || !progressed {
+			break
+		}
+	}
+
+	if missed > 0 {
+		color.Green("✓ Caught up on %d missed message(s)", missed)
+	}
+}
+
+// printChatMessage displays a single chat message
+func printChatMessage(msg client.Message, listenAll bool) {
+	roomInfo := ""
+	if listenAll {
+		roomName := msg.RoomName
+		if roomName == "" {
+			roomName = "Direct Message"
+		}
+		roomInfo = fmt.Sprintf("[%s] ", roomName)
+	}
+
+	username := msg.Username
+	if username == "" {
+		username = "Unknown"
+	}
+
+	color.Cyan("[%s] %s%s: %s", msg.Timestamp.Format("15:04:05"), roomInfo, username, msg.Content)
+}
+
+// printListenFrame displays a live WebSocket frame
+func printListenFrame(frame *realtime.WebSocketMessage, cursor *messageCursor, listenAll bool) {
+	data, _ := json.Marshal(frame.Data)
+
+	switch frame.Type {
+	case "message":
+		var msg client.Message
+		json.Unmarshal(data, &msg)
+		if cursor.advance(msg.ID, msg.Timestamp) {
+			printChatMessage(msg, listenAll)
+		}
+
+	case "message_edit":
+		var msg client.Message
+		json.Unmarshal(data, &msg)
+
+		color.Magenta("✎ Message %d edited by %s: %s", msg.ID, msg.Username, msg.Content)
+
+	case "message_delete":
+		var event client.MessageDeletedEvent
+		json.Unmarshal(data, &event)
+
+		color.Red("✗ Message %d was deleted", event.ID)
+
+	case "reaction":
+		var event client.ReactionEvent
+		json.Unmarshal(data, &event)
+
+		if event.Action == "remove" {
+			color.Blue("%s removed %s from message %d (%d)", event.Username, event.Emoji, event.MessageID, event.Count)
+		} else {
+			color.Blue("%s reacted %s to message %d (%d)", event.Username, event.Emoji, event.MessageID, event.Count)
+		}
+
+	case "user_joined":
+		color.Yellow("→ User joined the room")
+	case "user_left":
+		color.Yellow("← User left the room")
+	case "typing":
+		color.Blue("💬 Someone is typing...")
+	}
+}
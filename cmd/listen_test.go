@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"plexichat-client/internal/realtime"
+	"plexichat-client/pkg/client"
+)
+
+func TestMessageCursor_Advance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cursor := &messageCursor{lastTime: start}
+
+	tests := []struct {
+		id       int
+		expected bool
+	}{
+		{5, true},
+		{5, false}, // Duplicate
+		{3, false}, // Older than the cursor
+		{0, true},  // No ID, always shown
+		{6, true},
+	}
+
+	for _, test := range tests {
+		if got := cursor.advance(test.id, start.Add(time.Duration(test.id)*time.Minute)); got != test.expected {
+			t.Errorf("Expected advance(%d) = %v, got %v", test.id, test.expected, got)
+		}
+	}
+
+	if cursor.lastID != 6 {
+		t.Errorf("Expected last ID 6, got %d", cursor.lastID)
+	}
+	if !cursor.lastTime.Equal(start.Add(6 * time.Minute)) {
+		t.Errorf("Expected last time to follow the newest message, got %v", cursor.lastTime)
+	}
+}
+
+func TestBackfillMissedMessages(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		afterID, _ := strconv.Atoi(r.URL.Query().Get("after_id"))
+
+		// Messages 11-13 were missed; the server pages them two at a time
+		var page client.MessageListResponse
+		for id := afterID + 1; id <= 13 && len(page.Messages) < 2; id++ {
+			page.Messages = append(page.Messages, client.Message{ID: id, Content: "missed"})
+		}
+		page.HasNext = afterID+len(page.Messages) < 13
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	c.SetRetryConfig(0, 0) // No retries for test
+	cursor := &messageCursor{lastID: 10, lastTime: time.Unix(1700000000, 0)}
+
+	backfillMissedMessages(context.Background(), c, 4, cursor, false)
+
+	if cursor.lastID != 13 {
+		t.Errorf("Expected cursor to advance to 13, got %d", cursor.lastID)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 page requests, got %d: %v", len(queries), queries)
+	}
+
+	first, _ := url.ParseQuery(queries[0])
+	if first.Get("after_id") != "10" || first.Get("room_id") != "4" || first.Get("since") != "1700000000" {
+		t.Errorf("Expected the first request to resume after message 10 in room 4, got %s", queries[0])
+	}
+}
+
+func TestWebsocketURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"http://localhost:8000", "ws://localhost:8000/ws/chat"},
+		{"https://chat.example.com", "wss://chat.example.com/ws/chat"},
+	}
+
+	for _, test := range tests {
+		if got := websocketURL(test.baseURL, "/ws/chat"); got != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, got)
+		}
+	}
+}
+
+func TestFallbackTransports(t *testing.T) {
+	fallbacks := fallbackTransports("https://chat.example.com", "/ws/chat/room/4")
+	if len(fallbacks) != 2 {
+		t.Fatalf("Expected 2 fallbacks, got %d", len(fallbacks))
+	}
+	if fallbacks[0].Kind != realtime.TransportSSE || fallbacks[0].URL != "https://chat.example.com/sse/chat/room/4" {
+		t.Errorf("Expected SSE first, got %+v", fallbacks[0])
+	}
+	if fallbacks[1].Kind != realtime.TransportLongPoll || fallbacks[1].URL != "https://chat.example.com/poll/chat/room/4" {
+		t.Errorf("Expected long polling second, got %+v", fallbacks[1])
+	}
+}
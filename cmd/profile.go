@@ -0,0 +1,502 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"plexichat-client/internal/config"
+	"plexichat-client/pkg/security"
+)
+
+var configProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage server profiles",
+	Long: `Manage named server profiles. Each profile has its own server URL,
+credentials, TLS settings and cache directory. Select one for a single
+command with --profile, or make it the default with 'config profile use'.`,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or update a profile",
+	Long:  "Create a profile for the server given by --url, or update an existing one",
+	Example: `  plexichat-client config profile add staging --url https://staging.example.com
+  plexichat-client config profile add prod --url https://chat.example.com --ca-cert ca.pem --use`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileAdd,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the default profile",
+	Long:  "Make a profile the default for every command",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileUse,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Long:  "List all configured server profiles",
+	RunE:  runProfileList,
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile",
+	Long:  "Delete a profile and its stored credentials",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileRemove,
+}
+
+func init() {
+	configCmd.AddCommand(configProfileCmd)
+	configProfileCmd.AddCommand(profileAddCmd)
+	configProfileCmd.AddCommand(profileUseCmd)
+	configProfileCmd.AddCommand(profileListCmd)
+	configProfileCmd.AddCommand(profileRemoveCmd)
+
+	// Add flags (the server URL and API key come from the global --url and --api-key flags)
+	profileAddCmd.Flags().String("token", "", "Access token to store in the profile")
+	profileAddCmd.Flags().String("ca-cert", "", "PEM bundle of CAs trusted for this server")
+	profileAddCmd.Flags().String("client-cert", "", "Client certificate for mutual TLS")
+	profileAddCmd.Flags().String("client-key", "", "Client private key for mutual TLS")
+	profileAddCmd.Flags().Bool("insecure", false, "Skip TLS certificate verification")
+	profileAddCmd.Flags().String("server-name", "", "Name to verify the server certificate against")
+	profileAddCmd.Flags().StringSlice("pin", nil, "Pin the server's public key (sha256/<base64>); repeatable")
+	profileAddCmd.Flags().String("proxy", "", "HTTP or SOCKS5 proxy URL")
+	profileAddCmd.Flags().String("cache-dir", "", "Cache directory (default: <config dir>/cache/<name>)")
+	profileAddCmd.Flags().Bool("use", false, "Make this the default profile")
+}
+
+func runProfileAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := config.ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+
+	profile := &config.Profile{Name: name}
+	existing := store.Exists(name)
+	if existing {
+		if profile, err = store.Load(name); err != nil {
+			return err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("url") {
+		profile.URL = strings.TrimSuffix(baseURL, "/")
+	}
+	if profile.URL == "" {
+		return fmt.Errorf("--url is required for a new profile")
+	}
+	if flags.Changed("api-key") {
+		profile.APIKey = apiKey
+	}
+	if flags.Changed("token") {
+		profile.Token, _ = flags.GetString("token")
+	}
+	if flags.Changed("ca-cert") {
+		profile.TLS.CACert, _ = flags.GetString("ca-cert")
+	}
+	if flags.Changed("client-cert") {
+		profile.TLS.ClientCert, _ = flags.GetString("client-cert")
+	}
+	if flags.Changed("client-key") {
+		profile.TLS.ClientKey, _ = flags.GetString("client-key")
+	}
+	if flags.Changed("insecure") {
+		profile.TLS.InsecureSkipVerify, _ = flags.GetBool("insecure")
+	}
+	if flags.Changed("server-name") {
+		profile.TLS.ServerName, _ = flags.GetString("server-name")
+	}
+	if flags.Changed("pin") {
+		profile.TLS.Pins, _ = flags.GetStringSlice("pin")
+	}
+	if flags.Changed("proxy") {
+		profile.Proxy.URL, _ = flags.GetString("proxy")
+	}
+	if flags.Changed("cache-dir") {
+		profile.CacheDir, _ = flags.GetString("cache-dir")
+	}
+	if profile.CacheDir == "" {
+		dir, err := configDir()
+		if err != nil {
+			return err
+		}
+		profile.CacheDir = filepath.Join(dir, "cache", name)
+	}
+
+	// Catch unreadable certificates or malformed pins now rather than on first use
+	if _, err := profileTransportConfig(profile).TLSConfig(); err != nil {
+		return err
+	}
+	if _, err := profileTransportConfig(profile).Proxy(); err != nil {
+		return err
+	}
+
+	if err := store.Save(profile); err != nil {
+		return err
+	}
+
+	if existing {
+		color.Green("✓ Profile '%s' updated", name)
+	} else {
+		color.Green("✓ Profile '%s' added", name)
+	}
+	fmt.Printf("Server: %s\n", profile.URL)
+
+	if use, _ := flags.GetBool("use"); use {
+		return runProfileUse(cmd, args)
+	}
+
+	fmt.Printf("Use it with --profile %s or 'config profile use %s', then log in with 'auth login'\n", name, name)
+	return nil
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+
+	profile, err := store.Load(name)
+	if err != nil {
+		return err
+	}
+
+	if err := updateMainConfig(map[string]interface{}{"profile": name}); err != nil {
+		return err
+	}
+
+	color.Green("✓ Now using profile '%s' (%s)", name, profile.URL)
+	return nil
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+
+	names, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No profiles configured. Use 'config profile add <name> --url <server>' to create one.")
+		return nil
+	}
+
+	active := viper.GetString("profile")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Active", "Name", "Server", "User", "TLS")
+
+	for _, name := range names {
+		profile, err := store.Load(name)
+		if err != nil {
+			color.Yellow("Warning: %v", err)
+			continue
+		}
+
+		marker := ""
+		if name == active {
+			marker = "*"
+		}
+
+		user := profile.Username
+		if user == "" && profile.Token == "" && profile.APIKey == "" {
+			user = "(not logged in)"
+		}
+
+		table.Append([]string{marker, name, profile.URL, user, describeProfileTLS(profile.TLS)})
+	}
+
+	table.Render()
+	return nil
+}
+
+func runProfileRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Remove(name); err != nil {
+		return err
+	}
+
+	// Forget the default if it pointed at the removed profile
+	main, err := readMainConfig()
+	if err != nil {
+		return err
+	}
+	if main.GetString("profile") == name {
+		if err := updateMainConfig(map[string]interface{}{"profile": ""}); err != nil {
+			return err
+		}
+		color.Yellow("Profile '%s' was the default; falling back to the main configuration", name)
+	}
+
+	color.Green("✓ Profile '%s' removed", name)
+	return nil
+}
+
+func describeProfileTLS(tls config.ProfileTLS) string {
+	var parts []string
+	if tls.CACert != "" {
+		parts = append(parts, "custom CA")
+	}
+	if tls.ClientCert != "" {
+		parts = append(parts, "mTLS")
+	}
+	if len(tls.Pins) > 0 {
+		parts = append(parts, "pinned")
+	}
+	if tls.InsecureSkipVerify {
+		parts = append(parts, "insecure")
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// profileTransportConfig returns the TLS and proxy settings a profile connects with
+func profileTransportConfig(profile *config.Profile) *security.TransportConfig {
+	return &security.TransportConfig{
+		CACertFile:         profile.TLS.CACert,
+		ClientCertFile:     profile.TLS.ClientCert,
+		ClientKeyFile:      profile.TLS.ClientKey,
+		InsecureSkipVerify: profile.TLS.InsecureSkipVerify,
+		ServerName:         profile.TLS.ServerName,
+		PinnedKeys:         profile.TLS.Pins,
+		ProxyURL:           proxyWithCredentials(profile.Proxy.URL, profile.Proxy.Username, profile.Proxy.Password),
+	}
+}
+
+// configDir returns the directory holding the main config file
+func configDir() (string, error) {
+	configFile, err := mainConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(configFile), nil
+}
+
+// mainConfigFile returns the config file in use, or the default location when none exists yet
+func mainConfigFile() (string, error) {
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
+		return configFile, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".plexichat-app", "config.yaml"), nil
+}
+
+// readMainConfig loads the main config file on its own, without flag or profile overrides
+func readMainConfig() (*viper.Viper, error) {
+	configFile, err := mainConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return v, nil
+}
+
+// updateMainConfig writes only the given keys to the main config file, so values
+// applied from a profile never leak into it
+func updateMainConfig(values map[string]interface{}) error {
+	v, err := readMainConfig()
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		v.Set(key, value)
+		viper.Set(key, value)
+	}
+
+	configFile := v.ConfigFileUsed()
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := v.WriteConfigAs(configFile); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	return nil
+}
+
+func profileStore() (*config.ProfileStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return config.NewProfileStore(filepath.Join(dir, config.ProfilesDirName)), nil
+}
+
+// profileFields maps the config keys a profile owns to the matching profile field;
+// everything else comes from the main config
+var profileFields = map[string]func(p *config.Profile) interface{}{
+	"url":                      func(p *config.Profile) interface{} { return &p.URL },
+	"api-key":                  func(p *config.Profile) interface{} { return &p.APIKey },
+	"token":                    func(p *config.Profile) interface{} { return &p.Token },
+	"refresh_token":            func(p *config.Profile) interface{} { return &p.RefreshToken },
+	"username":                 func(p *config.Profile) interface{} { return &p.Username },
+	"user_id":                  func(p *config.Profile) interface{} { return &p.UserID },
+	"cache_dir":                func(p *config.Profile) interface{} { return &p.CacheDir },
+	"tls.ca_cert":              func(p *config.Profile) interface{} { return &p.TLS.CACert },
+	"tls.client_cert":          func(p *config.Profile) interface{} { return &p.TLS.ClientCert },
+	"tls.client_key":           func(p *config.Profile) interface{} { return &p.TLS.ClientKey },
+	"tls.insecure_skip_verify": func(p *config.Profile) interface{} { return &p.TLS.InsecureSkipVerify },
+	"tls.server_name":          func(p *config.Profile) interface{} { return &p.TLS.ServerName },
+	"tls.pins":                 func(p *config.Profile) interface{} { return &p.TLS.Pins },
+	"proxy.url":                func(p *config.Profile) interface{} { return &p.Proxy.URL },
+	"proxy.username":           func(p *config.Profile) interface{} { return &p.Proxy.Username },
+	"proxy.password":           func(p *config.Profile) interface{} { return &p.Proxy.Password },
+}
+
+func isProfileKey(key string) bool {
+	_, ok := profileFields[key]
+	return ok
+}
+
+// applyActiveProfile overlays the selected profile on top of the main configuration.
+// Explicit --url and --api-key flags still take precedence. A default profile whose
+// file has gone missing only produces a warning, so 'config profile' commands can
+// still repair the setup; a missing profile named with --profile is an error.
+func applyActiveProfile() error {
+	name := viper.GetString("profile")
+	if name == "" {
+		return nil
+	}
+
+	store, err := profileStore()
+	if err != nil {
+		return err
+	}
+
+	explicit := rootCmd.PersistentFlags().Changed("profile")
+	if !explicit && !store.Exists(name) {
+		fmt.Fprintf(os.Stderr, "Warning: default profile '%s' not found; using the main configuration. "+
+			"Run 'config profile use <name>' to pick another.\n", name)
+		viper.Set("profile", "")
+		return nil
+	}
+
+	profile, err := store.Load(name)
+	if err != nil {
+		return err
+	}
+
+	for key, field := range profileFields {
+		if flag := rootCmd.PersistentFlags().Lookup(key); flag != nil && flag.Changed {
+			continue
+		}
+		switch value := field(profile).(type) {
+		case *string:
+			viper.Set(key, *value)
+		case *bool:
+			viper.Set(key, *value)
+		case *[]string:
+			viper.Set(key, *value)
+		}
+	}
+
+	return nil
+}
+
+// updateActiveProfile copies the given profile-owned keys from viper into the active
+// profile and saves it. Other keys keep their stored values, so one-off flags such
+// as --url never overwrite the profile.
+func updateActiveProfile(name string, keys []string) (string, error) {
+	store, err := profileStore()
+	if err != nil {
+		return "", err
+	}
+
+	profile, err := store.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range keys {
+		field, ok := profileFields[key]
+		if !ok {
+			continue
+		}
+		switch value := field(profile).(type) {
+		case *string:
+			*value = viper.GetString(key)
+		case *bool:
+			*value = viper.GetBool(key)
+		case *[]string:
+			*value = viper.GetStringSlice(key)
+		}
+	}
+
+	if err := store.Save(profile); err != nil {
+		return "", err
+	}
+	return store.Path(name), nil
+}
+
+// saveSession stores login state in the active profile, or in the main config file
+// when no profile is selected, and returns the file written
+func saveSession(values map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		viper.Set(key, value)
+		keys = append(keys, key)
+	}
+
+	if name := viper.GetString("profile"); name != "" {
+		return updateActiveProfile(name, keys)
+	}
+
+	configFile, err := mainConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return configFile, viper.WriteConfigAs(configFile)
+}
+
+// cacheDir returns the directory for on-disk state such as upload resume tokens
+func cacheDir() (string, error) {
+	if dir := viper.GetString("cache_dir"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".plexichat-app"), nil
+}
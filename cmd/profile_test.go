@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"plexichat-client/internal/config"
+)
+
+// withProfileConfig points viper at a temporary main config and profile store
+func withProfileConfig(t *testing.T) *config.ProfileStore {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configFile, []byte("url: http://main.example.com\n"), 0600)
+
+	viper.Reset()
+	viper.SetConfigFile(configFile)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	flags := rootCmd.PersistentFlags()
+	t.Cleanup(func() {
+		viper.Reset()
+		for _, name := range []string{"profile", "url", "api-key"} {
+			flag := flags.Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+
+	return config.NewProfileStore(filepath.Join(dir, config.ProfilesDirName))
+}
+
+func TestApplyActiveProfile_ExplicitFlagsWin(t *testing.T) {
+	store := withProfileConfig(t)
+	store.Save(&config.Profile{
+		Name:   "prod",
+		URL:    "https://prod.example.com",
+		APIKey: "prod-key",
+		Token:  "prod-token",
+	})
+
+	flags := rootCmd.PersistentFlags()
+	flags.Set("url", "http://override.example.com")
+	flags.Set("api-key", "override-key")
+	viper.BindPFlag("url", flags.Lookup("url"))
+	viper.BindPFlag("api-key", flags.Lookup("api-key"))
+	viper.Set("profile", "prod")
+
+	if err := applyActiveProfile(); err != nil {
+		t.Fatalf("applyActiveProfile failed: %v", err)
+	}
+
+	if url := viper.GetString("url"); url != "http://override.example.com" {
+		t.Errorf("Expected --url to win over the profile, got %s", url)
+	}
+	if key := viper.GetString("api-key"); key != "override-key" {
+		t.Errorf("Expected --api-key to win over the profile, got %s", key)
+	}
+	if token := viper.GetString("token"); token != "prod-token" {
+		t.Errorf("Expected the profile token to be applied, got %s", token)
+	}
+}
+
+func TestApplyActiveProfile_MissingDefault(t *testing.T) {
+	withProfileConfig(t)
+	viper.Set("profile", "deleted")
+
+	if err := applyActiveProfile(); err != nil {
+		t.Fatalf("Expected a missing default profile to fall back, got %v", err)
+	}
+	if name := viper.GetString("profile"); name != "" {
+		t.Errorf("Expected the missing profile to be cleared, got %s", name)
+	}
+	if url := viper.GetString("url"); url != "http://main.example.com" {
+		t.Errorf("Expected the main config URL, got %s", url)
+	}
+
+	rootCmd.PersistentFlags().Set("profile", "deleted")
+	viper.Set("profile", "deleted")
+	if err := applyActiveProfile(); err == nil {
+		t.Error("Expected a missing --profile to be an error")
+	}
+}
+
+func TestSaveSession_KeepsOneOffFlagsOutOfProfile(t *testing.T) {
+	store := withProfileConfig(t)
+	store.Save(&config.Profile{Name: "prod", URL: "https://prod.example.com"})
+
+	viper.Set("profile", "prod")
+	if err := applyActiveProfile(); err != nil {
+		t.Fatalf("applyActiveProfile failed: %v", err)
+	}
+
+	// A one-off --url for this run must not be persisted with the new tokens
+	viper.Set("url", "http://other.example.com")
+	if _, err := saveSession(map[string]interface{}{"token": "fresh", "refresh_token": "r2"}); err != nil {
+		t.Fatalf("saveSession failed: %v", err)
+	}
+
+	profile, err := store.Load("prod")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if profile.URL != "https://prod.example.com" {
+		t.Errorf("Expected the saved URL to be kept, got %s", profile.URL)
+	}
+	if profile.Token != "fresh" || profile.RefreshToken != "r2" {
+		t.Errorf("Expected the session tokens to be saved, got %s/%s", profile.Token, profile.RefreshToken)
+	}
+}
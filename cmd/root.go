@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,13 +12,15 @@ import (
 	"github.com/spf13/viper"
 
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/security"
 )
 
 var (
-	cfgFile string
-	baseURL string
-	apiKey  string
-	verbose bool
+	cfgFile     string
+	profileName string
+	baseURL     string
+	apiKey      string
+	verbose     bool
 )
 
 // Version information
@@ -65,11 +68,13 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.plexichat-app/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "server profile to use (see 'config profile')")
 	rootCmd.PersistentFlags().StringVar(&baseURL, "url", "http://localhost:8000", "PlexiChat server URL")
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "API key for authentication")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 
 	// Bind flags to viper
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
 	viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("url"))
 	viper.BindPFlag("api-key", rootCmd.PersistentFlags().Lookup("api-key"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -112,6 +117,46 @@ func initConfig() {
 	viper.SetDefault("timeout", "30s")
 	viper.SetDefault("retries", 3)
 	viper.SetDefault("concurrent_requests", 10)
+
+	// Overlay the selected server profile, if any
+	cobra.CheckErr(applyActiveProfile())
+}
+
+// transportConfig collects the TLS and proxy settings shared by every outbound connection
+func transportConfig() *security.TransportConfig {
+	return &security.TransportConfig{
+		CACertFile:         viper.GetString("tls.ca_cert"),
+		ClientCertFile:     viper.GetString("tls.client_cert"),
+		ClientKeyFile:      viper.GetString("tls.client_key"),
+		InsecureSkipVerify: viper.GetBool("tls.insecure_skip_verify"),
+		ServerName:         viper.GetString("tls.server_name"),
+		PinnedKeys:         viper.GetStringSlice("tls.pins"),
+		ProxyURL:           proxyWithCredentials(viper.GetString("proxy.url"), viper.GetString("proxy.username"), viper.GetString("proxy.password")),
+	}
+}
+
+// proxyWithCredentials adds credentials kept apart from the proxy URL to it
+func proxyWithCredentials(rawURL, username, password string) string {
+	if rawURL == "" || username == "" {
+		return rawURL
+	}
+
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		// Left as is so the transport reports the malformed URL
+		return rawURL
+	}
+	proxyURL.User = url.UserPassword(username, password)
+	return proxyURL.String()
+}
+
+// newAPIClient returns an unauthenticated client for the configured server
+func newAPIClient() (*client.Client, error) {
+	c := client.NewClient(viper.GetString("url"))
+	if err := c.SetTransportConfig(transportConfig()); err != nil {
+		return nil, fmt.Errorf("failed to configure connection: %w", err)
+	}
+	return c, nil
 }
 
 // SetVersionInfo sets version information from main
@@ -176,7 +221,11 @@ var healthCmd = &cobra.Command{
 	Short: "Check server health",
 	Long:  "Check the health status of the PlexiChat server",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		c := client.NewClient(viper.GetString("url"))
+		c, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
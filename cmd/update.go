@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/security"
 	"plexichat-client/pkg/updater"
 
 	"github.com/spf13/cobra"
@@ -50,7 +51,10 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	updater := updater.NewUpdater()
+	updater, err := newUpdater()
+	if err != nil {
+		return err
+	}
 
 	// Check for updates
 	logging.Info("Checking for updates...")
@@ -125,7 +129,12 @@ func CheckForUpdatesBackground() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		updater := updater.NewUpdater()
+		updater, err := newUpdater()
+		if err != nil {
+			logging.Debug("Background update check failed: %v", err)
+			return
+		}
+
 		updateInfo, err := updater.CheckForUpdates(ctx)
 		if err != nil {
 			logging.Debug("Background update check failed: %v", err)
@@ -144,3 +153,18 @@ func CheckForUpdatesBackground() {
 func GetCurrentVersion() string {
 	return updater.CurrentVersion
 }
+
+// newUpdater returns an updater that uses the configured proxy and CA bundle.
+// Client certificates and pins are specific to the PlexiChat server, so they are
+// not sent to the release host.
+func newUpdater() (*updater.Updater, error) {
+	server := transportConfig()
+	u := updater.NewUpdater()
+	if err := u.SetTransportConfig(&security.TransportConfig{
+		CACertFile: server.CACertFile,
+		ProxyURL:   server.ProxyURL,
+	}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -677,7 +677,7 @@ func (cm *AdvancedConfigManager) loadProfiles(ctx context.Context) error {
 	// Look for profile-specific configuration files
 	for _, source := range cm.sources {
 		if fileSource, ok := source.(*FileConfigSource); ok {
-			profilesDir := filepath.Dir(fileSource.path) + "/profiles"
+			profilesDir := filepath.Join(filepath.Dir(fileSource.path), ProfilesDirName)
 			if _, err := os.Stat(profilesDir); os.IsNotExist(err) {
 				continue
 			}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfilesDirName is the directory, next to the main config file, that holds one file per profile
+const ProfilesDirName = "profiles"
+
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_\-.]{0,63}$`)
+
+// Profile is a named server connection with its own credentials, TLS settings and cache
+type Profile struct {
+	Name         string       `yaml:"-"`
+	URL          string       `yaml:"url"`
+	Token        string       `yaml:"token,omitempty"`
+	RefreshToken string       `yaml:"refresh_token,omitempty"`
+	APIKey       string       `yaml:"api_key,omitempty"`
+	Username     string       `yaml:"username,omitempty"`
+	UserID       string       `yaml:"user_id,omitempty"`
+	CacheDir     string       `yaml:"cache_dir,omitempty"`
+	TLS          ProfileTLS   `yaml:"tls,omitempty"`
+	Proxy        ProfileProxy `yaml:"proxy,omitempty"`
+}
+
+// ProfileTLS holds the TLS settings of a profile; paths point at PEM files
+type ProfileTLS struct {
+	CACert             string   `yaml:"ca_cert,omitempty"`
+	ClientCert         string   `yaml:"client_cert,omitempty"`
+	ClientKey          string   `yaml:"client_key,omitempty"`
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify,omitempty"`
+	ServerName         string   `yaml:"server_name,omitempty"`
+	Pins               []string `yaml:"pins,omitempty"`
+}
+
+// ProfileProxy holds the proxy a profile connects through; empty uses the environment
+type ProfileProxy struct {
+	URL      string `yaml:"url,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// ProfileStore keeps profiles as individual YAML files in a directory, the same
+// layout AdvancedConfigManager.loadProfiles reads
+type ProfileStore struct {
+	dir string
+}
+
+// NewProfileStore returns a store rooted at dir
+func NewProfileStore(dir string) *ProfileStore {
+	return &ProfileStore{dir: dir}
+}
+
+// ValidateProfileName checks that a profile name is usable as a file name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' or '.'", name)
+	}
+	return nil
+}
+
+// Path returns the file that stores the named profile
+func (s *ProfileStore) Path(name string) string {
+	return filepath.Join(s.dir, name+".yaml")
+}
+
+// Exists reports whether the named profile has been saved
+func (s *ProfileStore) Exists(name string) bool {
+	_, err := os.Stat(s.Path(name))
+	return err == nil
+}
+
+// List returns the names of all saved profiles in alphabetical order
+func (s *ProfileStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads the named profile
+func (s *ProfileStore) Load(name string) (*Profile, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.Path(name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("profile '%s' not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile '%s': %w", name, err)
+	}
+
+	var profile Profile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile '%s': %w", name, err)
+	}
+	profile.Name = name
+
+	return &profile, nil
+}
+
+// Save writes the profile, replacing any previous version. The file holds
+// credentials, so it is only readable by the owner.
+func (s *ProfileStore) Save(profile *Profile) error {
+	if err := ValidateProfileName(profile.Name); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	tmp := s.Path(profile.Name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	if err := os.Rename(tmp, s.Path(profile.Name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the named profile
+func (s *ProfileStore) Remove(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.Path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("profile '%s' not found", name)
+		}
+		return fmt.Errorf("failed to remove profile '%s': %w", name, err)
+	}
+
+	return nil
+}
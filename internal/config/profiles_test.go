@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{name: "simple", profile: "prod", wantErr: false},
+		{name: "longest allowed", profile: strings.Repeat("a", 64), wantErr: false},
+		{name: "with separators", profile: "eu-west_2.staging", wantErr: false},
+		{name: "digits first", profile: "2fa", wantErr: false},
+		{name: "empty", profile: "", wantErr: true},
+		{name: "leading dot", profile: ".hidden", wantErr: true},
+		{name: "path traversal", profile: "../config", wantErr: true},
+		{name: "slash", profile: "team/prod", wantErr: true},
+		{name: "space", profile: "my profile", wantErr: true},
+		{name: "too long", profile: strings.Repeat("a", 65), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProfileName(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProfileName(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileStore_SaveLoad(t *testing.T) {
+	store := NewProfileStore(t.TempDir())
+
+	profile := &Profile{
+		Name:         "prod",
+		URL:          "https://chat.example.com",
+		Token:        "access",
+		RefreshToken: "refresh",
+		Username:     "alice",
+		TLS:          ProfileTLS{CACert: "/etc/ca.pem", Pins: []string{"sha256/abc="}},
+		Proxy:        ProfileProxy{URL: "socks5://proxy:1080"},
+	}
+	if err := store.Save(profile); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(store.Path("prod"))
+	if err != nil {
+		t.Fatalf("Expected profile file to exist: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("Expected profile file mode 0600, got %o", mode)
+	}
+
+	loaded, err := store.Load("prod")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Name != "prod" || loaded.URL != profile.URL || loaded.Token != "access" || loaded.RefreshToken != "refresh" {
+		t.Errorf("Loaded profile does not match: %+v", loaded)
+	}
+	if loaded.TLS.CACert != "/etc/ca.pem" || len(loaded.TLS.Pins) != 1 || loaded.Proxy.URL != "socks5://proxy:1080" {
+		t.Errorf("Loaded TLS and proxy settings do not match: %+v", loaded)
+	}
+
+	if _, err := store.Load("missing"); err == nil {
+		t.Error("Expected loading a missing profile to fail")
+	}
+	if err := store.Save(&Profile{Name: "../escape"}); err == nil {
+		t.Error("Expected an invalid profile name to be rejected")
+	}
+}
+
+func TestProfileStore_ListRemove(t *testing.T) {
+	dir := t.TempDir()
+	store := NewProfileStore(dir)
+
+	names, err := store.List()
+	if err != nil || len(names) != 0 {
+		t.Fatalf("Expected no profiles in an empty store, got %v (%v)", names, err)
+	}
+
+	for _, name := range []string{"staging", "dev", "prod"} {
+		if err := store.Save(&Profile{Name: name, URL: "https://" + name + ".example.com"}); err != nil {
+			t.Fatalf("Save %s failed: %v", name, err)
+		}
+	}
+	// Unrelated files in the directory are ignored
+	os.WriteFile(dir+"/notes.txt", []byte("hi"), 0600)
+
+	names, err = store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(names) != 3 || names[0] != "dev" || names[1] != "prod" || names[2] != "staging" {
+		t.Errorf("Expected [dev prod staging], got %v", names)
+	}
+
+	if err := store.Remove("dev"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if store.Exists("dev") {
+		t.Error("Expected removed profile to be gone")
+	}
+	if err := store.Remove("dev"); err == nil {
+		t.Error("Expected removing a missing profile to fail")
+	}
+
+	names, _ = store.List()
+	if len(names) != 2 {
+		t.Errorf("Expected 2 profiles after removal, got %v", names)
+	}
+}
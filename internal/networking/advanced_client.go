@@ -17,6 +17,7 @@ import (
 
 	"plexichat-client/internal/interfaces"
 	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/security"
 )
 
 // AdvancedHTTPClient implements a sophisticated HTTP client with enterprise features
@@ -37,6 +38,7 @@ type AdvancedHTTPClient struct {
 	rateLimiter     RateLimiter
 	authProvider    AuthProvider
 	middleware      []Middleware
+	configErr       error // Invalid transport settings; every request fails with it
 }
 
 // ConnectionPool manages HTTP connections with advanced pooling
@@ -188,6 +190,17 @@ func NewAdvancedHTTPClient(config ClientConfig) *AdvancedHTTPClient {
 			InsecureSkipVerify: config.InsecureSkipVerify,
 			MinVersion:         tls.VersionTLS12,
 		},
+		Proxy: http.ProxyFromEnvironment,
+	}
+
+	// Custom CAs, client certificates, pins and proxy
+	var configErr error
+	if config.Transport != nil {
+		transportConfig := *config.Transport
+		transportConfig.InsecureSkipVerify = transportConfig.InsecureSkipVerify || config.InsecureSkipVerify
+		if err := transportConfig.Apply(transport); err != nil {
+			configErr = fmt.Errorf("invalid transport configuration: %w", err)
+		}
 	}
 
 	pool.transport = transport
@@ -212,6 +225,7 @@ func NewAdvancedHTTPClient(config ClientConfig) *AdvancedHTTPClient {
 		responseFilters: make([]ResponseFilter, 0),
 		middleware:      make([]Middleware, 0),
 		logger:          logging.GetLogger("http-client"),
+		configErr:       configErr,
 	}
 
 	// Set circuit breaker if provided
@@ -250,6 +264,7 @@ type ClientConfig struct {
 	ExpectContinueTimeout time.Duration             `json:"expect_continue_timeout"`
 	DialTimeout           time.Duration             `json:"dial_timeout"`
 	InsecureSkipVerify    bool                      `json:"insecure_skip_verify"`
+	Transport             *security.TransportConfig `json:"transport,omitempty"`
 	DefaultHeaders        map[string]string         `json:"default_headers"`
 	RetryPolicy           interfaces.RetryPolicy    `json:"retry_policy"`
 	CircuitBreaker        interfaces.CircuitBreaker `json:"-"`
@@ -356,6 +371,10 @@ func (c *AdvancedHTTPClient) AddMiddleware(middleware Middleware) {
 
 // doRequest performs the actual HTTP request with all features
 func (c *AdvancedHTTPClient) doRequest(ctx context.Context, method, urlPath string, body interface{}, headers map[string]string) (*interfaces.HTTPResponse, error) {
+	if c.configErr != nil {
+		return nil, c.configErr
+	}
+
 	start := time.Now()
 
 	// Increment total requests
@@ -0,0 +1,70 @@
+package networking
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"plexichat-client/pkg/security"
+)
+
+// writeServerCA writes the certificate of an httptest TLS server to a PEM file
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+	return path
+}
+
+func TestAdvancedHTTPClient_TransportConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	// The system roots do not trust the test server
+	client := NewAdvancedHTTPClient(ClientConfig{BaseURL: server.URL})
+	if _, err := client.Get(context.Background(), "/api/v1/ping", nil); err == nil {
+		t.Fatal("Expected untrusted certificate to be rejected")
+	}
+
+	client = NewAdvancedHTTPClient(ClientConfig{
+		BaseURL:   server.URL,
+		Transport: &security.TransportConfig{CACertFile: writeServerCA(t, server)},
+	})
+	resp, err := client.Get(context.Background(), "/api/v1/ping", nil)
+	if err != nil {
+		t.Fatalf("Request with custom CA failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	pinned := NewAdvancedHTTPClient(ClientConfig{
+		BaseURL: server.URL,
+		Transport: &security.TransportConfig{
+			InsecureSkipVerify: true,
+			PinnedKeys:         []string{security.PublicKeyPin(server.Certificate())},
+		},
+	})
+	if _, err := pinned.Get(context.Background(), "/api/v1/ping", nil); err != nil {
+		t.Errorf("Expected pinned key to be accepted: %v", err)
+	}
+}
+
+func TestAdvancedHTTPClient_InvalidTransportConfig(t *testing.T) {
+	client := NewAdvancedHTTPClient(ClientConfig{
+		BaseURL:   "https://plexichat.internal",
+		Transport: &security.TransportConfig{ProxyURL: "ftp://proxy"},
+	})
+
+	if _, err := client.Get(context.Background(), "/", nil); err == nil {
+		t.Error("Expected unsupported proxy scheme to fail every request")
+	}
+}
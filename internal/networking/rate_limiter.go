@@ -80,6 +80,18 @@ func (rl *TokenBucketRateLimiter) GetMetrics() RateLimiterMetrics {
 	return rl.metrics
 }
 
+// RetryAfter returns how long until the next request would be allowed
+func (rl *TokenBucketRateLimiter) RetryAfter() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill()
+	if rl.tokens >= 1.0 || rl.rate <= 0 {
+		return 0
+	}
+	return time.Duration((1.0 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
 // refill refills the token bucket
 func (rl *TokenBucketRateLimiter) refill() {
 	now := time.Now()
@@ -171,6 +183,19 @@ func (rl *SlidingWindowRateLimiter) GetMetrics() RateLimiterMetrics {
 	return rl.metrics
 }
 
+// RetryAfter returns how long until the next request would be allowed
+func (rl *SlidingWindowRateLimiter) RetryAfter() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.cleanOldRequests(now)
+	if len(rl.requests) < rl.limit || len(rl.requests) == 0 {
+		return 0
+	}
+	return rl.requests[len(rl.requests)-rl.limit].Add(rl.window).Sub(now)
+}
+
 // cleanOldRequests removes requests outside the sliding window
 func (rl *SlidingWindowRateLimiter) cleanOldRequests(now time.Time) {
 	cutoff := now.Add(-rl.window)
@@ -376,6 +401,23 @@ func (rl *CompositeRateLimiter) GetMetrics() RateLimiterMetrics {
 	return rl.metrics
 }
 
+// RetryAfter returns how long until every limiter would allow a request, for the
+// limiters that can tell
+func (rl *CompositeRateLimiter) RetryAfter() time.Duration {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	var longest time.Duration
+	for _, limiter := range rl.limiters {
+		if timed, ok := limiter.(interface{ RetryAfter() time.Duration }); ok {
+			if wait := timed.RetryAfter(); wait > longest {
+				longest = wait
+			}
+		}
+	}
+	return longest
+}
+
 // AddLimiter adds a rate limiter to the composite
 func (rl *CompositeRateLimiter) AddLimiter(limiter RateLimiter) {
 	rl.mu.Lock()
@@ -0,0 +1,35 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	ctx := context.Background()
+
+	bucket := NewTokenBucketRateLimiter(10, 1)
+	window := NewSlidingWindowRateLimiter(1, time.Minute)
+	composite := NewCompositeRateLimiter(bucket, window)
+
+	if wait := composite.RetryAfter(); wait != 0 {
+		t.Errorf("Expected no wait before any request, got %v", wait)
+	}
+	if !composite.Allow(ctx) {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if composite.Allow(ctx) {
+		t.Fatal("Expected the second request to be blocked")
+	}
+
+	if wait := bucket.RetryAfter(); wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("Expected the bucket to refill within 100ms, got %v", wait)
+	}
+	if wait := window.RetryAfter(); wait <= 59*time.Second || wait > time.Minute {
+		t.Errorf("Expected the window to reopen in about a minute, got %v", wait)
+	}
+	if wait := composite.RetryAfter(); wait <= 59*time.Second {
+		t.Errorf("Expected the composite to wait for its slowest limiter, got %v", wait)
+	}
+}
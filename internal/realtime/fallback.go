@@ -0,0 +1,333 @@
+package realtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"plexichat-client/pkg/wire"
+)
+
+// TransportKind names the transport a client receives messages over
+type TransportKind string
+
+const (
+	TransportWebSocket TransportKind = "websocket"
+	TransportSSE       TransportKind = "sse"
+	TransportLongPoll  TransportKind = "longpoll"
+)
+
+// longPollRequestTimeout bounds one poll; the server answers within 25s
+const longPollRequestTimeout = time.Minute
+
+// FallbackTransport is an HTTP endpoint carrying the same message stream as the
+// WebSocket, for networks whose proxies block WebSocket upgrades
+type FallbackTransport struct {
+	Kind TransportKind `json:"kind"` // TransportSSE or TransportLongPoll
+	URL  string        `json:"url"`
+}
+
+// longPollResponse is the body the server answers each poll with
+type longPollResponse struct {
+	Session  string            `json:"session"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+// Transport returns the transport the client is connected over, or "" when it is not connected
+func (ws *WebSocketClient) Transport() TransportKind {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.transport
+}
+
+// connectFallback tries the fallback transports in order after the WebSocket
+// handshake failed; callers hold mu. Fallbacks only receive: the authenticator's
+// headers are sent with each request, but connect hooks and Authenticate, which
+// need a WebSocket, are not run.
+func (ws *WebSocketClient) connectFallback(ctx context.Context) error {
+	httpClient, err := ws.fallbackHTTPClient()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, fallback := range ws.config.Fallbacks {
+		stop := make(chan struct{})
+		streamCtx, cancel := context.WithCancel(ctx)
+
+		switch fallback.Kind {
+		case TransportSSE:
+			var body io.ReadCloser
+			if body, err = ws.openSSE(streamCtx, httpClient, fallback.URL); err == nil {
+				go ws.sseLoop(streamCtx, body, stop)
+			}
+		case TransportLongPoll:
+			var session string
+			if session, err = ws.openLongPoll(streamCtx, httpClient, fallback.URL); err == nil {
+				go ws.longPollLoop(streamCtx, httpClient, fallback.URL, session, stop)
+			}
+		default:
+			err = fmt.Errorf("unknown transport %q", fallback.Kind)
+		}
+		if err != nil {
+			cancel()
+			ws.logger.Error("Fallback transport failed", "transport", fallback.Kind, "url", fallback.URL, "error", err)
+			lastErr = err
+			continue
+		}
+
+		// Stopping the client cancels the stream's requests
+		go func() {
+			<-stop
+			cancel()
+		}()
+
+		ws.stopCh = stop
+		ws.transport = fallback.Kind
+		atomic.StoreInt32(&ws.reconnectAttempts, 0)
+		ws.metrics.LastConnected = time.Now()
+		atomic.AddInt64(&ws.metrics.ConnectionsTotal, 1)
+		atomic.AddInt64(&ws.metrics.ConnectionsActive, 1)
+		ws.setState(StateChange{State: StateConnected})
+
+		if ws.eventBus != nil {
+			ws.eventBus.Publish(ctx, &WebSocketEvent{
+				Type:      "websocket.connected",
+				Timestamp: time.Now(),
+				Data: map[string]interface{}{
+					"url":       fallback.URL,
+					"transport": string(fallback.Kind),
+				},
+			})
+		}
+
+		ws.logger.Info("Connected over fallback transport", "transport", fallback.Kind, "url", fallback.URL)
+		return nil
+	}
+	return lastErr
+}
+
+// fallbackHTTPClient returns an HTTP client with the configured TLS and proxy settings
+func (ws *WebSocketClient) fallbackHTTPClient() (*http.Client, error) {
+	if ws.config.Transport == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		return &http.Client{Transport: transport}, nil
+	}
+
+	transport, err := ws.config.Transport.NewTransport()
+	if err != nil {
+		return nil, fmt.Errorf("invalid transport configuration: %w", err)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// fallbackGet sends an authenticated GET, refreshing expired credentials once like
+// the WebSocket handshake does
+func (ws *WebSocketClient) fallbackGet(ctx context.Context, httpClient *http.Client, rawURL string, headers http.Header) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = ws.handshakeHeaders()
+		for key, values := range headers {
+			req.Header[key] = values
+		}
+		return httpClient.Do(req)
+	}
+
+	resp, err := send()
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && ws.config.AuthenticationEnabled && ws.authenticator != nil {
+		if refreshErr := ws.authenticator.RefreshAuth(ctx); refreshErr == nil {
+			resp.Body.Close()
+			resp, err = send()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// openSSE opens an event stream, resuming after the last event seen on any transport
+func (ws *WebSocketClient) openSSE(ctx context.Context, httpClient *http.Client, rawURL string) (io.ReadCloser, error) {
+	headers := http.Header{"Accept": {"text/event-stream"}}
+	if ws.lastEventID != "" {
+		headers.Set("Last-Event-ID", ws.lastEventID)
+	}
+
+	// The server sends headers at once, so a slow answer means a stuck proxy
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(ws.config.HandshakeTimeout, cancel)
+	resp, err := ws.fallbackGet(ctx, httpClient, rawURL, headers)
+	if !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("event stream handshake timed out")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event stream: %w", err)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %q", contentType)
+	}
+	return resp.Body, nil
+}
+
+// sseLoop reads events until the stream ends, handling each as a message frame
+func (ws *WebSocketClient) sseLoop(ctx context.Context, body io.ReadCloser, stop chan struct{}) {
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var data bytes.Buffer
+	var id string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line ends an event
+			if data.Len() > 0 {
+				ws.receiveFallback(ctx, data.Bytes(), id)
+			}
+			data.Reset()
+			id = ""
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "id":
+			id = value
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	ws.connectionLost(ctx, nil, stop, fmt.Errorf("event stream closed: %w", err))
+}
+
+// openLongPoll opens a long-poll session and returns its ID
+func (ws *WebSocketClient) openLongPoll(ctx context.Context, httpClient *http.Client, rawURL string) (string, error) {
+	query := url.Values{}
+	if ws.lastEventID != "" {
+		query.Set("since", ws.lastEventID)
+	}
+	pollURL, err := withQuery(rawURL, query)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := ws.poll(ctx, httpClient, pollURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to open long-poll session: %w", err)
+	}
+	if response.Session == "" {
+		return "", fmt.Errorf("server did not return a long-poll session")
+	}
+	return response.Session, nil
+}
+
+// longPollLoop polls a session until it fails or the client stops
+func (ws *WebSocketClient) longPollLoop(ctx context.Context, httpClient *http.Client, rawURL, session string, stop chan struct{}) {
+	pollURL, err := withQuery(rawURL, url.Values{"session": {session}})
+	for err == nil {
+		var response *longPollResponse
+		if response, err = ws.poll(ctx, httpClient, pollURL); err != nil {
+			break
+		}
+
+		for _, raw := range response.Messages {
+			ws.receiveFallback(ctx, raw, resumeMarker(raw))
+		}
+	}
+
+	ws.connectionLost(ctx, nil, stop, fmt.Errorf("long-poll session ended: %w", err))
+}
+
+// poll sends one long-poll request
+func (ws *WebSocketClient) poll(ctx context.Context, httpClient *http.Client, pollURL string) (*longPollResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, longPollRequestTimeout)
+	defer cancel()
+
+	resp, err := ws.fallbackGet(ctx, httpClient, pollURL, http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response longPollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode poll response: %w", err)
+	}
+	return &response, nil
+}
+
+// resumeMarker returns the stored ID of a chat frame, as SSE sends it in the event
+// ID, or "" for frames that do not move the resume point
+func resumeMarker(raw []byte) string {
+	var frame struct {
+		Type string `json:"type"`
+		Seq  int64  `json:"seq"`
+	}
+	if err := json.Unmarshal(raw, &frame); err != nil || frame.Type != "chat" || frame.Seq <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(frame.Seq, 10)
+}
+
+// receiveFallback handles a message from a fallback transport and remembers its
+// resume marker, if it has one, so the next connection can resume after it
+func (ws *WebSocketClient) receiveFallback(ctx context.Context, data []byte, id string) {
+	atomic.AddInt64(&ws.metrics.MessagesReceived, 1)
+	atomic.AddInt64(&ws.metrics.BytesReceived, int64(len(data)))
+
+	if id != "" {
+		ws.mu.Lock()
+		ws.lastEventID = id
+		ws.mu.Unlock()
+	}
+
+	if err := ws.handleMessage(ctx, wire.JSON, data); err != nil {
+		ws.logger.Error("Failed to handle message", "error", err)
+	}
+}
+
+// withQuery returns rawURL with query's values added
+func withQuery(rawURL string, query url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid fallback URL: %w", err)
+	}
+
+	values := u.Query()
+	for key, value := range query {
+		values[key] = value
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
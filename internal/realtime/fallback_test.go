@@ -0,0 +1,148 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"plexichat-client/pkg/websocket"
+)
+
+// channelHandler forwards messages of one type to a channel
+type channelHandler struct {
+	messageType string
+	messages    chan *WebSocketMessage
+}
+
+func (h *channelHandler) Handle(ctx context.Context, message *WebSocketMessage) error {
+	h.messages <- message
+	return nil
+}
+
+func (h *channelHandler) GetMessageType() string { return h.messageType }
+func (h *channelHandler) GetPriority() int       { return 0 }
+
+// newBlockedHubServer serves a hub behind a "proxy" that rejects WebSocket
+// upgrades and, if blockSSE is set, event streams too
+func newBlockedHubServer(t *testing.T, hub *websocket.Hub, blockSSE bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Upgrade blocked", http.StatusForbidden)
+	})
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		if blockSSE {
+			http.Error(w, "Streaming blocked", http.StatusForbidden)
+			return
+		}
+		hub.HandleSSE(w, r, "1", "alice")
+	})
+	mux.HandleFunc("/poll", func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleLongPoll(w, r, "1", "alice")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebSocketClient_Fallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		blockSSE bool
+		expected TransportKind
+	}{
+		{"sse", false, TransportSSE},
+		{"longpoll", true, TransportLongPoll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := websocket.NewHub()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go hub.Run(ctx)
+
+			server := newBlockedHubServer(t, hub, tt.blockSSE)
+			client := NewWebSocketClient(WebSocketConfig{
+				URL:     "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+				Headers: map[string]string{"Authorization": "Bearer test-token-123"},
+				Fallbacks: []FallbackTransport{
+					{Kind: TransportSSE, URL: server.URL + "/sse"},
+					{Kind: TransportLongPoll, URL: server.URL + "/poll"},
+				},
+			}, nil)
+
+			chats := &channelHandler{messageType: "chat", messages: make(chan *WebSocketMessage, 8)}
+			client.RegisterMessageHandler(chats)
+			receipts := &channelHandler{messageType: "read", messages: make(chan *WebSocketMessage, 8)}
+			client.RegisterMessageHandler(receipts)
+
+			if err := client.Connect(ctx); err != nil {
+				t.Fatalf("Expected to connect over a fallback, got %v", err)
+			}
+			defer client.Disconnect()
+
+			if transport := client.Transport(); transport != tt.expected {
+				t.Fatalf("Expected transport %s, got %s", tt.expected, transport)
+			}
+			if err := client.SendMessage(ctx, &WebSocketMessage{Type: "chat", Data: "hi"}); err == nil {
+				t.Error("Expected sending over a fallback to fail")
+			}
+
+			hub.SendToUser("1", websocket.Message{Type: websocket.MessageTypeChat, Data: "hello", MessageID: "ack-1", Seq: 7})
+			select {
+			case message := <-chats.messages:
+				if message.Data != "hello" {
+					t.Errorf("Expected hello, got %v", message.Data)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the chat message over the fallback")
+			}
+
+			// A receipt for another message must not move the resume point
+			hub.SendToUser("1", websocket.Message{Type: websocket.MessageTypeRead, ChannelID: "general", MessageID: "9"})
+			select {
+			case <-receipts.messages:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the receipt over the fallback")
+			}
+			client.mu.RLock()
+			lastEventID := client.lastEventID
+			client.mu.RUnlock()
+			if lastEventID != "7" {
+				t.Errorf("Expected to resume after message 7, got %q", lastEventID)
+			}
+
+			client.Disconnect()
+			if transport := client.Transport(); transport != "" {
+				t.Errorf("Expected no transport after Disconnect, got %s", transport)
+			}
+			if got := client.GetMetrics().ConnectionsActive; got != 0 {
+				t.Errorf("Expected 0 active connections, got %d", got)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_FallbackUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Blocked", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:       "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		Fallbacks: []FallbackTransport{{Kind: TransportSSE, URL: server.URL + "/sse"}},
+	}, nil)
+
+	if err := client.Connect(context.Background()); err == nil {
+		client.Disconnect()
+		t.Fatal("Expected connect to fail when every transport is blocked")
+	}
+	if state := client.GetConnectionState(); state != StateError {
+		t.Errorf("Expected StateError, got %v", state)
+	}
+}
@@ -0,0 +1,51 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"plexichat-client/pkg/websocket"
+)
+
+// newHubClient connects a client to a running hub as user 1
+func newHubClient(t *testing.T, ctx context.Context, hub *websocket.Hub, config WebSocketConfig) *WebSocketClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleWebSocket(w, r, "1", "alice")
+	}))
+	t.Cleanup(server.Close)
+
+	config.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	config.Headers = map[string]string{"Authorization": "Bearer test-token-123"}
+	client := NewWebSocketClient(config, nil)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Expected connect to succeed, got %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect() })
+	return client
+}
+
+func TestWebSocketClient_AckedByHub(t *testing.T) {
+	hub := websocket.NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	// A long ack timeout, so only the hub's ack can settle the message
+	client := newHubClient(t, ctx, hub, WebSocketConfig{AckTimeout: time.Minute})
+
+	if err := client.SendWithAck(ctx, &WebSocketMessage{Type: "chat", Data: "hello"}); err != nil {
+		t.Fatalf("Expected send to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.PendingAcks()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the hub's ack to settle the pending message")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -3,16 +3,20 @@ package realtime
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"plexichat-client/internal/interfaces"
 	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/security"
+	"plexichat-client/pkg/wire"
 
 	"github.com/gorilla/websocket"
 )
@@ -39,10 +43,17 @@ type WebSocketClient struct {
 	authenticator     WebSocketAuthenticator
 	middleware        []WebSocketMiddleware
 	hooks             map[string][]WebSocketHook
+	hooksMu           sync.RWMutex // Guards hooks; separate from mu, which Connect holds while running them
 	stopCh            chan struct{}
 	pingTicker        *time.Ticker
 	reconnectTimer    *time.Timer
 	started           bool
+	stateHandlers     []StateChangeHandler
+	pendingAcks       map[string]*pendingAck
+	pendingMu         sync.Mutex
+	nextMessageID     int64
+	transport         TransportKind // Transport in use; empty while not connected
+	lastEventID       string        // Last message ID a fallback transport received
 }
 
 // WebSocketConfig contains WebSocket client configuration
@@ -56,17 +67,28 @@ type WebSocketConfig struct {
 	ReconnectInterval     time.Duration     `json:"reconnect_interval"`
 	MaxReconnectAttempts  int               `json:"max_reconnect_attempts"`
 	ReconnectBackoff      float64           `json:"reconnect_backoff"`
+	ReconnectJitter       float64           `json:"reconnect_jitter"` // Fraction of each delay randomized; negative disables
 	MaxReconnectInterval  time.Duration     `json:"max_reconnect_interval"`
 	HandshakeTimeout      time.Duration     `json:"handshake_timeout"`
 	ReadBufferSize        int               `json:"read_buffer_size"`
 	WriteBufferSize       int               `json:"write_buffer_size"`
 	MessageQueueSize      int               `json:"message_queue_size"`
-	CompressionEnabled    bool              `json:"compression_enabled"`
+	CompressionEnabled    bool              `json:"compression_enabled"` // Negotiates permessage-deflate and runs the MessageCompressor, if set
+	BinaryEncoding        bool              `json:"binary_encoding"`     // Offers the MessagePack subprotocol; JSON is used if the server declines
 	EncryptionEnabled     bool              `json:"encryption_enabled"`
 	AuthenticationEnabled bool              `json:"authentication_enabled"`
 	RateLimitEnabled      bool              `json:"rate_limit_enabled"`
 	MetricsEnabled        bool              `json:"metrics_enabled"`
 	DebugEnabled          bool              `json:"debug_enabled"`
+	AckTimeout            time.Duration     `json:"ack_timeout"`     // How long SendWithAck waits before resending
+	MaxAckRetries         int               `json:"max_ack_retries"` // Resends before an unacked message is given up; negative retries forever
+
+	// Transport applies custom CAs, client certificates, pins and a proxy to the handshake
+	Transport *security.TransportConfig `json:"transport,omitempty"`
+
+	// Fallbacks are tried in order when the WebSocket handshake fails. They only
+	// receive messages; SendMessage needs a WebSocket.
+	Fallbacks []FallbackTransport `json:"fallbacks,omitempty"`
 }
 
 // ConnectionState represents WebSocket connection states
@@ -82,6 +104,41 @@ const (
 	StateError
 )
 
+// String returns a human-readable connection state
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosing:
+		return "closing"
+	case StateClosed:
+		return "closed"
+	case StateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange describes a connection state transition. Attempt and Delay are set
+// while reconnecting; Err carries the failure that caused the transition.
+type StateChange struct {
+	State   ConnectionState
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+// StateChangeHandler is called on every state transition. It runs while the client
+// lock is held, so it must not call back into the client.
+type StateChangeHandler func(change StateChange)
+
 // MessageHandler handles incoming WebSocket messages
 type MessageHandler interface {
 	Handle(ctx context.Context, message *WebSocketMessage) error
@@ -93,6 +150,7 @@ type MessageHandler interface {
 type WebSocketMessage struct {
 	Type       string                 `json:"type"`
 	ID         string                 `json:"id,omitempty"`
+	MessageID  string                 `json:"message_id,omitempty"` // Set by SendWithAck; the server acks with it
 	Data       interface{}            `json:"data"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	Timestamp  time.Time              `json:"timestamp"`
@@ -100,6 +158,13 @@ type WebSocketMessage struct {
 	Encrypted  bool                   `json:"encrypted,omitempty"`
 }
 
+// pendingAck is a message sent with SendWithAck that the server has not acknowledged yet
+type pendingAck struct {
+	message  *WebSocketMessage
+	sentAt   time.Time
+	attempts int
+}
+
 // MessageQueue manages outgoing message queue
 type MessageQueue struct {
 	mu       sync.RWMutex
@@ -175,6 +240,10 @@ type WebSocketMetrics struct {
 
 // NewWebSocketClient creates a new WebSocket client
 func NewWebSocketClient(config WebSocketConfig, eventBus interfaces.EventBus) *WebSocketClient {
+	if config.MessageQueueSize <= 0 {
+		config.MessageQueueSize = 1000
+	}
+
 	client := &WebSocketClient{
 		url:             config.URL,
 		headers:         make(http.Header),
@@ -188,6 +257,7 @@ func NewWebSocketClient(config WebSocketConfig, eventBus interfaces.EventBus) *W
 		middleware:      make([]WebSocketMiddleware, 0),
 		hooks:           make(map[string][]WebSocketHook),
 		stopCh:          make(chan struct{}),
+		pendingAcks:     make(map[string]*pendingAck),
 	}
 
 	// Set default values
@@ -201,17 +271,26 @@ func NewWebSocketClient(config WebSocketConfig, eventBus interfaces.EventBus) *W
 		client.config.ReconnectInterval = 5 * time.Second
 	}
 	if client.config.MaxReconnectAttempts == 0 {
-		client.config.MaxReconnectAttempts = 10
+		client.config.MaxReconnectAttempts = 10 // Negative retries forever
 	}
 	if client.config.ReconnectBackoff == 0 {
 		client.config.ReconnectBackoff = 1.5
 	}
+	if client.config.ReconnectJitter == 0 {
+		client.config.ReconnectJitter = 0.5
+	}
 	if client.config.MaxReconnectInterval == 0 {
 		client.config.MaxReconnectInterval = 5 * time.Minute
 	}
 	if client.config.HandshakeTimeout == 0 {
 		client.config.HandshakeTimeout = 10 * time.Second
 	}
+	if client.config.AckTimeout == 0 {
+		client.config.AckTimeout = 10 * time.Second
+	}
+	if client.config.MaxAckRetries == 0 {
+		client.config.MaxAckRetries = 3
+	}
 
 	// Set headers
 	for key, value := range config.Headers {
@@ -231,38 +310,59 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 	}
 
 	ws.logger.Info("Connecting to WebSocket", "url", ws.url)
-	ws.connectionState = StateConnecting
+	ws.setState(StateChange{State: StateConnecting})
 
 	// Parse URL
 	u, err := url.Parse(ws.url)
 	if err != nil {
-		ws.connectionState = StateError
+		ws.setState(StateChange{State: StateError, Err: err})
 		return fmt.Errorf("invalid WebSocket URL: %w", err)
 	}
 
 	// Create dialer
 	dialer := websocket.Dialer{
-		HandshakeTimeout: ws.config.HandshakeTimeout,
-		ReadBufferSize:   ws.config.ReadBufferSize,
-		WriteBufferSize:  ws.config.WriteBufferSize,
-		Subprotocols:     ws.config.Subprotocols,
+		HandshakeTimeout:  ws.config.HandshakeTimeout,
+		ReadBufferSize:    ws.config.ReadBufferSize,
+		WriteBufferSize:   ws.config.WriteBufferSize,
+		Subprotocols:      ws.config.Subprotocols,
+		EnableCompression: ws.config.CompressionEnabled,
+		Proxy:             http.ProxyFromEnvironment,
+	}
+	if ws.config.BinaryEncoding {
+		dialer.Subprotocols = append(append([]string{}, wire.Subprotocols...), ws.config.Subprotocols...)
 	}
 
-	// Add authentication headers if enabled
-	headers := ws.headers
-	if ws.config.AuthenticationEnabled && ws.authenticator != nil {
-		authHeaders := ws.authenticator.GetAuthHeaders()
-		for key, values := range authHeaders {
-			for _, value := range values {
-				headers.Add(key, value)
-			}
+	if ws.config.Transport != nil {
+		tlsConfig, err := ws.config.Transport.TLSConfig()
+		if err != nil {
+			ws.setState(StateChange{State: StateError, Err: err})
+			return fmt.Errorf("invalid TLS configuration: %w", err)
 		}
+		proxy, err := ws.config.Transport.Proxy()
+		if err != nil {
+			ws.setState(StateChange{State: StateError, Err: err})
+			return fmt.Errorf("invalid proxy configuration: %w", err)
+		}
+		dialer.TLSClientConfig = tlsConfig
+		dialer.Proxy = proxy
 	}
 
 	// Establish connection
-	conn, resp, err := dialer.DialContext(ctx, u.String(), headers)
+	conn, resp, err := dialer.DialContext(ctx, u.String(), ws.handshakeHeaders())
+	if err != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized && ws.config.AuthenticationEnabled && ws.authenticator != nil {
+		// Credentials expired while we were away; refresh them and try once more
+		if refreshErr := ws.authenticator.RefreshAuth(ctx); refreshErr == nil {
+			conn, resp, err = dialer.DialContext(ctx, u.String(), ws.handshakeHeaders())
+		}
+	}
+	if err != nil && len(ws.config.Fallbacks) > 0 {
+		ws.logger.Error("WebSocket handshake failed, trying fallbacks", "error", err)
+		if fallbackErr := ws.connectFallback(ctx); fallbackErr == nil {
+			return nil
+		}
+	}
 	if err != nil {
-		ws.connectionState = StateError
+		ws.setState(StateChange{State: StateError, Err: err})
 		ws.logger.Error("Failed to connect to WebSocket", "error", err)
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -271,9 +371,11 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 		resp.Body.Close()
 	}
 
+	stop := make(chan struct{})
 	ws.conn = conn
-	ws.connectionState = StateConnected
-	ws.reconnectAttempts = 0
+	ws.stopCh = stop
+	ws.transport = TransportWebSocket
+	atomic.StoreInt32(&ws.reconnectAttempts, 0)
 	ws.metrics.LastConnected = time.Now()
 	atomic.AddInt64(&ws.metrics.ConnectionsTotal, 1)
 	atomic.AddInt64(&ws.metrics.ConnectionsActive, 1)
@@ -294,10 +396,19 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 		ws.logger.Error("Connection hook failed", "error", err)
 	}
 
+	// A pong proves the connection is alive, so it extends the read deadline
+	readTimeout := ws.config.PingInterval + ws.config.PongTimeout
+	conn.SetPongHandler(func(string) error {
+		ws.lastPong = time.Now()
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	ws.setState(StateChange{State: StateConnected})
+
 	// Start background routines
-	go ws.readLoop(ctx)
-	go ws.writeLoop(ctx)
-	go ws.pingLoop(ctx)
+	go ws.readLoop(ctx, conn, stop)
+	go ws.writeLoop(ctx, stop)
+	go ws.pingLoop(ctx, conn, stop)
 
 	// Publish connection event
 	if ws.eventBus != nil {
@@ -315,6 +426,53 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 	return nil
 }
 
+// handshakeHeaders returns the configured headers plus the authenticator's current ones
+func (ws *WebSocketClient) handshakeHeaders() http.Header {
+	headers := ws.headers.Clone()
+	if ws.config.AuthenticationEnabled && ws.authenticator != nil {
+		for key, values := range ws.authenticator.GetAuthHeaders() {
+			headers[key] = values
+		}
+	}
+	return headers
+}
+
+// setState records a state transition and notifies handlers; callers hold mu
+func (ws *WebSocketClient) setState(change StateChange) {
+	ws.connectionState = change.State
+	for _, handler := range ws.stateHandlers {
+		handler(change)
+	}
+}
+
+// OnStateChange registers a handler for connection state transitions
+func (ws *WebSocketClient) OnStateChange(handler StateChangeHandler) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.stateHandlers = append(ws.stateHandlers, handler)
+}
+
+// SetAuthenticator sets the authenticator used for handshake headers and token refresh
+func (ws *WebSocketClient) SetAuthenticator(authenticator WebSocketAuthenticator) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.authenticator = authenticator
+}
+
+// SetCompressor sets the compressor applied to payloads when compression is enabled
+func (ws *WebSocketClient) SetCompressor(compressor MessageCompressor) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.compressor = compressor
+}
+
+// SetLogger replaces the client's logger
+func (ws *WebSocketClient) SetLogger(logger interfaces.Logger) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.logger = logger
+}
+
 // Disconnect closes the WebSocket connection
 func (ws *WebSocketClient) Disconnect() error {
 	ws.mu.Lock()
@@ -330,7 +488,7 @@ func (ws *WebSocketClient) disconnect() error {
 	}
 
 	ws.logger.Info("Disconnecting WebSocket")
-	ws.connectionState = StateClosing
+	ws.setState(StateChange{State: StateClosing})
 
 	// Stop background routines
 	if ws.stopCh != nil {
@@ -346,16 +504,21 @@ func (ws *WebSocketClient) disconnect() error {
 		ws.reconnectTimer.Stop()
 	}
 
-	// Close connection
+	// Close connection; stopping a fallback transport already cancelled its requests
 	if ws.conn != nil {
-		ws.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		// WriteControl is safe alongside a write in progress on writeLoop
+		ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
 		ws.conn.Close()
 		ws.conn = nil
 	}
+	if ws.transport != "" {
+		ws.transport = ""
+		ws.metrics.LastDisconnected = time.Now()
+		atomic.AddInt64(&ws.metrics.ConnectionsActive, -1)
+	}
 
-	ws.connectionState = StateDisconnected
-	ws.metrics.LastDisconnected = time.Now()
-	atomic.AddInt64(&ws.metrics.ConnectionsActive, -1)
+	ws.setState(StateChange{State: StateDisconnected})
 
 	// Execute disconnection hooks
 	if err := ws.executeHooks("disconnect", func(hook WebSocketHook) error {
@@ -382,9 +545,12 @@ func (ws *WebSocketClient) disconnect() error {
 
 // SendMessage sends a message through the WebSocket
 func (ws *WebSocketClient) SendMessage(ctx context.Context, message *WebSocketMessage) error {
-	if ws.connectionState != StateConnected {
+	if !ws.IsConnected() {
 		return fmt.Errorf("not connected")
 	}
+	if transport := ws.Transport(); transport != TransportWebSocket {
+		return fmt.Errorf("cannot send over the %s fallback transport", transport)
+	}
 
 	// Set timestamp if not provided
 	if message.Timestamp.IsZero() {
@@ -415,6 +581,97 @@ func (ws *WebSocketClient) SendMessage(ctx context.Context, message *WebSocketMe
 	return ws.messageQueue.Enqueue(queuedMessage)
 }
 
+// SendWithAck sends a message and keeps it until the server answers with an
+// "ack" frame carrying its MessageID. Messages without one are given one. A
+// message that could not be sent stays pending and goes out with the next retry.
+func (ws *WebSocketClient) SendWithAck(ctx context.Context, message *WebSocketMessage) error {
+	if message.MessageID == "" {
+		message.MessageID = fmt.Sprintf("msg_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&ws.nextMessageID, 1))
+	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	ws.pendingMu.Lock()
+	ws.pendingAcks[message.MessageID] = &pendingAck{message: message, sentAt: time.Now()}
+	ws.pendingMu.Unlock()
+
+	return ws.SendMessage(ctx, message)
+}
+
+// PendingAcks returns the messages still waiting for an acknowledgement, oldest first
+func (ws *WebSocketClient) PendingAcks() []*WebSocketMessage {
+	ws.pendingMu.Lock()
+	defer ws.pendingMu.Unlock()
+
+	pending := make([]*pendingAck, 0, len(ws.pendingAcks))
+	for _, entry := range ws.pendingAcks {
+		pending = append(pending, entry)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].message.Timestamp.Before(pending[j].message.Timestamp)
+	})
+
+	messages := make([]*WebSocketMessage, len(pending))
+	for i, entry := range pending {
+		messages[i] = entry.message
+	}
+	return messages
+}
+
+// RetryUnacked resends pending messages that have waited longer than AckTimeout
+// and returns how many were resent. Messages that used up MaxAckRetries are
+// dropped and reported as failed.
+func (ws *WebSocketClient) RetryUnacked(ctx context.Context) (int, error) {
+	return ws.resendPending(ctx, ws.config.AckTimeout)
+}
+
+// resendPending resends pending messages last sent at least minAge ago
+func (ws *WebSocketClient) resendPending(ctx context.Context, minAge time.Duration) (int, error) {
+	now := time.Now()
+	var due []*WebSocketMessage
+
+	ws.pendingMu.Lock()
+	for id, entry := range ws.pendingAcks {
+		if now.Sub(entry.sentAt) < minAge {
+			continue
+		}
+		if ws.config.MaxAckRetries >= 0 && entry.attempts >= ws.config.MaxAckRetries {
+			delete(ws.pendingAcks, id)
+			atomic.AddInt64(&ws.metrics.MessagesFailed, 1)
+			ws.logger.Error("Message was never acknowledged", "id", id, "attempts", entry.attempts+1)
+			continue
+		}
+		entry.attempts++
+		entry.sentAt = now
+		due = append(due, entry.message)
+	}
+	ws.pendingMu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Timestamp.Before(due[j].Timestamp) })
+
+	for i, message := range due {
+		if err := ws.SendMessage(ctx, message); err != nil {
+			return i, fmt.Errorf("failed to resend message %s: %w", message.ID, err)
+		}
+	}
+	return len(due), nil
+}
+
+// acknowledge settles a pending message named by an "ack" frame
+func (ws *WebSocketClient) acknowledge(message *WebSocketMessage) {
+	id := message.MessageID
+	if data, ok := message.Data.(map[string]interface{}); ok {
+		if messageID, ok := data["message_id"].(string); ok && messageID != "" {
+			id = messageID
+		}
+	}
+
+	ws.pendingMu.Lock()
+	delete(ws.pendingAcks, id)
+	ws.pendingMu.Unlock()
+}
+
 // RegisterMessageHandler registers a message handler
 func (ws *WebSocketClient) RegisterMessageHandler(handler MessageHandler) {
 	ws.mu.Lock()
@@ -444,8 +701,8 @@ func (ws *WebSocketClient) AddMiddleware(middleware WebSocketMiddleware) {
 
 // AddHook adds a WebSocket hook
 func (ws *WebSocketClient) AddHook(eventType string, hook WebSocketHook) {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
+	ws.hooksMu.Lock()
+	defer ws.hooksMu.Unlock()
 
 	if ws.hooks[eventType] == nil {
 		ws.hooks[eventType] = make([]WebSocketHook, 0)
@@ -475,35 +732,33 @@ func (ws *WebSocketClient) IsConnected() bool {
 }
 
 // readLoop handles incoming messages
-func (ws *WebSocketClient) readLoop(ctx context.Context) {
+func (ws *WebSocketClient) readLoop(ctx context.Context, conn *websocket.Conn, stop chan struct{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			ws.logger.Error("Read loop panic", "panic", r)
 		}
 	}()
 
+	readTimeout := ws.config.PingInterval + ws.config.PongTimeout
+	negotiated := wire.ForSubprotocol(conn.Subprotocol())
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ws.stopCh:
+		case <-stop:
 			return
 		default:
 		}
 
-		if ws.conn == nil {
-			return
-		}
+		// Set read deadline; pongs from pingLoop keep extending it
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
 
-		// Set read deadline
-		ws.conn.SetReadDeadline(time.Now().Add(ws.config.PongTimeout))
-
-		messageType, data, err := ws.conn.ReadMessage()
+		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				ws.logger.Error("WebSocket read error", "error", err)
 			}
-			ws.handleConnectionError(ctx, err)
+			ws.connectionLost(ctx, conn, stop, err)
 			return
 		}
 
@@ -513,11 +768,9 @@ func (ws *WebSocketClient) readLoop(ctx context.Context) {
 		// Handle different message types
 		switch messageType {
 		case websocket.TextMessage, websocket.BinaryMessage:
-			if err := ws.handleMessage(ctx, data); err != nil {
+			if err := ws.handleMessage(ctx, wire.ForFrame(negotiated, messageType), data); err != nil {
 				ws.logger.Error("Failed to handle message", "error", err)
 			}
-		case websocket.PongMessage:
-			ws.lastPong = time.Now()
 		case websocket.CloseMessage:
 			ws.logger.Info("Received close message")
 			ws.disconnect()
@@ -527,7 +780,7 @@ func (ws *WebSocketClient) readLoop(ctx context.Context) {
 }
 
 // writeLoop handles outgoing messages
-func (ws *WebSocketClient) writeLoop(ctx context.Context) {
+func (ws *WebSocketClient) writeLoop(ctx context.Context, stop chan struct{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			ws.logger.Error("Write loop panic", "panic", r)
@@ -537,43 +790,50 @@ func (ws *WebSocketClient) writeLoop(ctx context.Context) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	ackTicker := time.NewTicker(ws.config.AckTimeout)
+	defer ackTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ws.stopCh:
+		case <-stop:
 			return
 		case <-ticker.C:
 			if err := ws.processMessageQueue(ctx); err != nil {
 				ws.logger.Error("Failed to process message queue", "error", err)
 			}
+		case <-ackTicker.C:
+			if _, err := ws.RetryUnacked(ctx); err != nil {
+				ws.logger.Error("Failed to resend unacknowledged messages", "error", err)
+			}
 		}
 	}
 }
 
 // pingLoop sends periodic ping messages
-func (ws *WebSocketClient) pingLoop(ctx context.Context) {
-	ws.pingTicker = time.NewTicker(ws.config.PingInterval)
-	defer ws.pingTicker.Stop()
+func (ws *WebSocketClient) pingLoop(ctx context.Context, conn *websocket.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(ws.config.PingInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ws.stopCh:
+		case <-stop:
 			return
-		case <-ws.pingTicker.C:
-			if err := ws.sendPing(); err != nil {
+		case <-ticker.C:
+			if err := ws.sendPing(conn); err != nil {
 				ws.logger.Error("Failed to send ping", "error", err)
-				ws.handleConnectionError(ctx, err)
+				ws.connectionLost(ctx, conn, stop, err)
 				return
 			}
 		}
 	}
 }
 
-// handleMessage processes incoming messages
-func (ws *WebSocketClient) handleMessage(ctx context.Context, data []byte) error {
+// handleMessage processes an incoming frame, decoding it with codec
+func (ws *WebSocketClient) handleMessage(ctx context.Context, codec wire.Codec, data []byte) error {
 	// Decompress if needed
 	if ws.config.CompressionEnabled && ws.compressor != nil && ws.compressor.IsEnabled() {
 		decompressed, err := ws.compressor.Decompress(data)
@@ -594,7 +854,7 @@ func (ws *WebSocketClient) handleMessage(ctx context.Context, data []byte) error
 
 	// Parse message
 	var message WebSocketMessage
-	if err := json.Unmarshal(data, &message); err != nil {
+	if err := codec.Unmarshal(data, &message); err != nil {
 		return fmt.Errorf("failed to parse message: %w", err)
 	}
 
@@ -610,6 +870,10 @@ func (ws *WebSocketClient) handleMessage(ctx context.Context, data []byte) error
 		ws.logger.Error("Message hook failed", "error", err)
 	}
 
+	if message.Type == "ack" {
+		ws.acknowledge(&message)
+	}
+
 	// Find and execute handler
 	ws.mu.RLock()
 	handler, exists := ws.messageHandlers[message.Type]
@@ -641,7 +905,11 @@ func (ws *WebSocketClient) handleMessage(ctx context.Context, data []byte) error
 
 // processMessageQueue processes the outgoing message queue
 func (ws *WebSocketClient) processMessageQueue(ctx context.Context) error {
-	if ws.conn == nil {
+	ws.mu.RLock()
+	conn := ws.conn
+	ws.mu.RUnlock()
+
+	if conn == nil {
 		return fmt.Errorf("not connected")
 	}
 
@@ -650,8 +918,10 @@ func (ws *WebSocketClient) processMessageQueue(ctx context.Context) error {
 		return nil
 	}
 
-	// Serialize message
-	data, err := json.Marshal(message.Message)
+	// Serialize message with the encoding negotiated for this connection
+	codec := wire.ForSubprotocol(conn.Subprotocol())
+	frameType := codec.FrameType()
+	data, err := codec.Marshal(message.Message)
 	if err != nil {
 		atomic.AddInt64(&ws.metrics.MessagesFailed, 1)
 		if message.Callback != nil {
@@ -671,6 +941,7 @@ func (ws *WebSocketClient) processMessageQueue(ctx context.Context) error {
 			return fmt.Errorf("encryption failed: %w", err)
 		}
 		data = encrypted
+		frameType = websocket.BinaryMessage
 		message.Message.Encrypted = true
 	}
 
@@ -685,11 +956,12 @@ func (ws *WebSocketClient) processMessageQueue(ctx context.Context) error {
 			return fmt.Errorf("compression failed: %w", err)
 		}
 		data = compressed
+		frameType = websocket.BinaryMessage
 		message.Message.Compressed = true
 	}
 
 	// Send message
-	if err := ws.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if err := conn.WriteMessage(frameType, data); err != nil {
 		atomic.AddInt64(&ws.metrics.MessagesFailed, 1)
 
 		// Retry if configured
@@ -714,13 +986,43 @@ func (ws *WebSocketClient) processMessageQueue(ctx context.Context) error {
 }
 
 // sendPing sends a ping message
-func (ws *WebSocketClient) sendPing() error {
-	if ws.conn == nil {
-		return fmt.Errorf("not connected")
+func (ws *WebSocketClient) sendPing(conn *websocket.Conn) error {
+	ws.lastPing = time.Now()
+	return conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(ws.config.PongTimeout))
+}
+
+// connectionLost tears down a connection that failed underneath us and, if
+// enabled, starts reconnecting. conn is nil for a fallback transport. It is a
+// no-op once Disconnect has run.
+func (ws *WebSocketClient) connectionLost(ctx context.Context, conn *websocket.Conn, stop chan struct{}, err error) {
+	ws.mu.Lock()
+	select {
+	case <-stop:
+		ws.mu.Unlock()
+		return
+	default:
 	}
 
-	ws.lastPing = time.Now()
-	return ws.conn.WriteMessage(websocket.PingMessage, []byte{})
+	close(stop)
+	if conn != nil {
+		conn.Close()
+	}
+	if ws.conn == conn && ws.transport != "" { // A fallback transport has no conn
+		ws.conn = nil
+		ws.transport = ""
+		ws.stopCh = make(chan struct{})
+		ws.metrics.LastDisconnected = time.Now()
+		atomic.AddInt64(&ws.metrics.ConnectionsActive, -1)
+	}
+
+	if ws.config.ReconnectEnabled {
+		ws.setState(StateChange{State: StateReconnecting, Err: err})
+	} else {
+		ws.setState(StateChange{State: StateDisconnected, Err: err})
+	}
+	ws.mu.Unlock()
+
+	ws.handleConnectionError(ctx, err)
 }
 
 // handleConnectionError handles connection errors
@@ -752,51 +1054,79 @@ func (ws *WebSocketClient) handleConnectionError(ctx context.Context, err error)
 	}
 }
 
-// attemptReconnect attempts to reconnect to the WebSocket
+// attemptReconnect retries Connect with jittered exponential backoff until it
+// succeeds, the attempt limit is reached, ctx ends or Disconnect is called
 func (ws *WebSocketClient) attemptReconnect(ctx context.Context) {
-	ws.mu.Lock()
-	if ws.connectionState == StateReconnecting {
-		ws.mu.Unlock()
-		return
-	}
-	ws.connectionState = StateReconnecting
-	ws.mu.Unlock()
+	var lastErr error
+	for {
+		attempts := atomic.AddInt32(&ws.reconnectAttempts, 1)
+		if ws.config.MaxReconnectAttempts >= 0 && int(attempts) > ws.config.MaxReconnectAttempts {
+			ws.logger.Error("Max reconnect attempts reached", "attempts", attempts)
+			ws.mu.Lock()
+			ws.setState(StateChange{State: StateError, Err: lastErr})
+			ws.mu.Unlock()
+			return
+		}
+
+		delay := ws.reconnectDelay(int(attempts))
 
-	attempts := atomic.AddInt32(&ws.reconnectAttempts, 1)
-	if int(attempts) > ws.config.MaxReconnectAttempts {
-		ws.logger.Error("Max reconnect attempts reached", "attempts", attempts)
+		// Stop if Disconnect ran since the connection was lost
 		ws.mu.Lock()
-		ws.connectionState = StateError
+		if ws.connectionState != StateReconnecting && ws.connectionState != StateError {
+			ws.mu.Unlock()
+			return
+		}
+		ws.setState(StateChange{State: StateReconnecting, Attempt: int(attempts), Delay: delay, Err: lastErr})
 		ws.mu.Unlock()
-		return
-	}
 
-	// Calculate backoff delay
-	delay := time.Duration(float64(ws.config.ReconnectInterval) *
-		float64(attempts) * ws.config.ReconnectBackoff)
-	if delay > ws.config.MaxReconnectInterval {
-		delay = ws.config.MaxReconnectInterval
-	}
+		ws.logger.Info("Attempting to reconnect", "attempt", attempts, "delay", delay)
+		atomic.AddInt64(&ws.metrics.ReconnectAttempts, 1)
 
-	ws.logger.Info("Attempting to reconnect", "attempt", attempts, "delay", delay)
-	atomic.AddInt64(&ws.metrics.ReconnectAttempts, 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
 
-	ws.reconnectTimer = time.NewTimer(delay)
-	defer ws.reconnectTimer.Stop()
+		ws.mu.RLock()
+		cancelled := ws.connectionState != StateReconnecting
+		ws.mu.RUnlock()
+		if cancelled {
+			return
+		}
 
-	select {
-	case <-ws.reconnectTimer.C:
-		if err := ws.Connect(ctx); err != nil {
-			ws.logger.Error("Reconnect failed", "error", err)
-			go ws.attemptReconnect(ctx)
-		} else {
+		lastErr = ws.Connect(ctx)
+		if lastErr == nil {
 			ws.logger.Info("Reconnected successfully")
+
+			// Anything unacknowledged may have been lost with the old connection
+			if _, err := ws.resendPending(ctx, 0); err != nil {
+				ws.logger.Error("Failed to resend unacknowledged messages", "error", err)
+			}
+			return
 		}
-	case <-ctx.Done():
-		return
+		ws.logger.Error("Reconnect failed", "error", lastErr)
 	}
 }
 
+// reconnectDelay returns the backoff before the given attempt, capped at
+// MaxReconnectInterval and randomly shortened by up to ReconnectJitter so that
+// many clients do not reconnect in lockstep after a server restart
+func (ws *WebSocketClient) reconnectDelay(attempt int) time.Duration {
+	delay := float64(ws.config.ReconnectInterval) * math.Pow(ws.config.ReconnectBackoff, float64(attempt-1))
+	if max := float64(ws.config.MaxReconnectInterval); delay > max {
+		delay = max
+	}
+
+	if jitter := ws.config.ReconnectJitter; jitter > 0 {
+		delay -= delay * math.Min(jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
 // Helper functions and types
 
 // WebSocketEvent represents a WebSocket event
@@ -902,9 +1232,9 @@ func (mq *MessageQueue) sortByPriority() {
 
 // executeHooks executes hooks for a specific event type
 func (ws *WebSocketClient) executeHooks(eventType string, executor func(WebSocketHook) error) error {
-	ws.mu.RLock()
+	ws.hooksMu.RLock()
 	hooks := ws.hooks[eventType]
-	ws.mu.RUnlock()
+	ws.hooksMu.RUnlock()
 
 	for _, hook := range hooks {
 		if err := executor(hook); err != nil {
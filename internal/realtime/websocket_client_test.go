@@ -0,0 +1,325 @@
+package realtime
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"plexichat-client/pkg/security"
+	"plexichat-client/pkg/wire"
+)
+
+func newTLSWebSocketServer(t *testing.T) (*httptest.Server, string) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+
+	return server, caFile
+}
+
+func TestWebSocketClient_TransportConfig(t *testing.T) {
+	server, caFile := newTLSWebSocketServer(t)
+	defer server.Close()
+
+	wsURL := "wss" + strings.TrimPrefix(server.URL, "https") + "/ws"
+
+	// The system roots do not trust the test server
+	untrusted := NewWebSocketClient(WebSocketConfig{URL: wsURL}, nil)
+	if err := untrusted.Connect(context.Background()); err == nil {
+		untrusted.Disconnect()
+		t.Fatal("Expected untrusted certificate to be rejected")
+	}
+
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:       wsURL,
+		Transport: &security.TransportConfig{CACertFile: caFile},
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect with custom CA failed: %v", err)
+	}
+	if !client.IsConnected() {
+		t.Error("Expected client to be connected")
+	}
+	client.Disconnect()
+}
+
+func TestWebSocketClient_InvalidTransportConfig(t *testing.T) {
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:       "wss://plexichat.internal/ws",
+		Transport: &security.TransportConfig{PinnedKeys: []string{"not-a-pin"}},
+	}, nil)
+
+	if err := client.Connect(context.Background()); err == nil {
+		t.Fatal("Expected malformed pin to be rejected")
+	}
+	if state := client.GetConnectionState(); state != StateError {
+		t.Errorf("Expected StateError, got %v", state)
+	}
+}
+
+func TestWebSocketClient_ReconnectDelay(t *testing.T) {
+	client := NewWebSocketClient(WebSocketConfig{
+		ReconnectInterval:    time.Second,
+		ReconnectBackoff:     2,
+		ReconnectJitter:      0.5,
+		MaxReconnectInterval: 5 * time.Second,
+	}, nil)
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second}, // Capped
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			delay := client.reconnectDelay(test.attempt)
+			if delay > test.expected || delay < test.expected/2 {
+				t.Errorf("Expected attempt %d delay within [%v, %v], got %v", test.attempt, test.expected/2, test.expected, delay)
+			}
+		}
+	}
+}
+
+func TestWebSocketClient_ReconnectsAfterServerDrop(t *testing.T) {
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// The first connection is dropped as if the server restarted
+		if connections.Add(1) == 1 {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, ""))
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:                  "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		ReconnectEnabled:     true,
+		ReconnectInterval:    10 * time.Millisecond,
+		MaxReconnectAttempts: -1,
+	}, nil)
+
+	states := make(chan StateChange, 32)
+	client.OnStateChange(func(change StateChange) {
+		states <- change
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Expected initial connect to succeed, got %v", err)
+	}
+
+	var sawReconnecting bool
+	connected := 0
+	timeout := time.After(5 * time.Second)
+	for connected < 2 {
+		select {
+		case change := <-states:
+			switch change.State {
+			case StateReconnecting:
+				sawReconnecting = true
+			case StateConnected:
+				connected++
+			}
+		case <-timeout:
+			t.Fatalf("Expected the client to reconnect, got state %v", client.GetConnectionState())
+		}
+	}
+
+	if !sawReconnecting {
+		t.Error("Expected a reconnecting state before the second connect")
+	}
+	if got := client.GetMetrics().ConnectionsActive; got != 1 {
+		t.Errorf("Expected 1 active connection, got %d", got)
+	}
+
+	client.Disconnect()
+	if state := client.GetConnectionState(); state != StateDisconnected {
+		t.Errorf("Expected StateDisconnected after Disconnect, got %v", state)
+	}
+}
+
+func TestWebSocketClient_RetriesUnackedMessages(t *testing.T) {
+	deliveries := make(chan string, 8)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// The first delivery is "lost"; only the resend is acknowledged
+		seen := map[string]bool{}
+		for {
+			var message WebSocketMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			deliveries <- message.MessageID
+			if seen[message.MessageID] {
+				conn.WriteJSON(map[string]interface{}{
+					"type": "ack",
+					"data": map[string]string{"message_id": message.MessageID},
+				})
+			}
+			seen[message.MessageID] = true
+		}
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:        "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		AckTimeout: 50 * time.Millisecond,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Expected connect to succeed, got %v", err)
+	}
+	defer client.Disconnect()
+
+	message := &WebSocketMessage{Type: "chat", Data: "hello"}
+	if err := client.SendWithAck(ctx, message); err != nil {
+		t.Fatalf("Expected send to succeed, got %v", err)
+	}
+	if message.MessageID == "" {
+		t.Fatal("Expected SendWithAck to assign a message ID")
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-deliveries:
+			if id != message.MessageID {
+				t.Errorf("Expected delivery of %s, got %s", message.MessageID, id)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected delivery %d of the unacked message", i+1)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.PendingAcks()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the ack to settle the pending message")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketClient_BinaryEncoding(t *testing.T) {
+	frames := make(chan int, 8)
+	upgrader := websocket.Upgrader{Subprotocols: wire.Subprotocols, EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		codec := wire.ForSubprotocol(conn.Subprotocol())
+		for {
+			frameType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			frames <- frameType
+
+			var message WebSocketMessage
+			if err := wire.ForFrame(codec, frameType).Unmarshal(data, &message); err != nil {
+				return
+			}
+			ack, _ := codec.Marshal(map[string]interface{}{
+				"type": "ack",
+				"data": map[string]string{"message_id": message.MessageID},
+			})
+			conn.WriteMessage(codec.FrameType(), ack)
+		}
+	}))
+	defer server.Close()
+
+	client := NewWebSocketClient(WebSocketConfig{
+		URL:                "ws" + strings.TrimPrefix(server.URL, "http") + "/ws",
+		CompressionEnabled: true,
+		BinaryEncoding:     true,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Expected connect to succeed, got %v", err)
+	}
+	defer client.Disconnect()
+
+	if err := client.SendWithAck(ctx, &WebSocketMessage{Type: "chat", Data: "hello"}); err != nil {
+		t.Fatalf("Expected send to succeed, got %v", err)
+	}
+
+	select {
+	case frameType := <-frames:
+		if frameType != websocket.BinaryMessage {
+			t.Errorf("Expected a binary frame, got %d", frameType)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the message to reach the server")
+	}
+
+	// The msgpack ack must decode for the pending message to settle
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.PendingAcks()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the binary ack to settle the pending message")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
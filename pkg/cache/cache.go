@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"plexichat-client/pkg/client"
@@ -11,9 +14,16 @@ import (
 
 // CacheEntry represents a cached item with expiration
 type CacheEntry struct {
-	Data      interface{} `json:"data"`
-	ExpiresAt time.Time   `json:"expires_at"`
-	CreatedAt time.Time   `json:"created_at"`
+	Data         interface{} `json:"data"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	CreatedAt    time.Time   `json:"created_at"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+}
+
+// HasValidators reports whether the entry can be revalidated with a conditional request
+func (e *CacheEntry) HasValidators() bool {
+	return e.ETag != "" || e.LastModified != ""
 }
 
 // IsExpired checks if the cache entry has expired
@@ -96,8 +106,26 @@ func (c *Cache) Get(cacheType, identifier string) (interface{}, bool) {
 	return entry.Data, true
 }
 
+// GetEntry retrieves an entry even if it has expired, so it can be revalidated
+func (c *Cache) GetEntry(cacheType, identifier string) (CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[c.generateKey(cacheType, identifier)]
+	if !exists {
+		return CacheEntry{}, false
+	}
+	return *entry, true
+}
+
 // Set stores an item in cache
 func (c *Cache) Set(cacheType, identifier string, data interface{}) {
+	c.SetWithValidators(cacheType, identifier, data, "", "")
+}
+
+// SetWithValidators stores an item along with the ETag and Last-Modified values
+// the server returned for it
+func (c *Cache) SetWithValidators(cacheType, identifier string, data interface{}, etag, lastModified string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -110,15 +138,29 @@ func (c *Cache) Set(cacheType, identifier string, data interface{}) {
 	ttl := c.getTTL(cacheType)
 
 	entry := &CacheEntry{
-		Data:      data,
-		ExpiresAt: time.Now().Add(ttl),
-		CreatedAt: time.Now(),
+		Data:         data,
+		ExpiresAt:    time.Now().Add(ttl),
+		CreatedAt:    time.Now(),
+		ETag:         etag,
+		LastModified: lastModified,
 	}
 
 	c.entries[key] = entry
 	c.logger.Debug("Cache set: %s (TTL: %v)", key, ttl)
 }
 
+// Touch renews the TTL of an entry the server confirmed is unchanged
+func (c *Cache) Touch(cacheType, identifier string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := c.generateKey(cacheType, identifier)
+	if entry, exists := c.entries[key]; exists {
+		entry.ExpiresAt = time.Now().Add(c.getTTL(cacheType))
+		c.logger.Debug("Cache revalidated: %s", key)
+	}
+}
+
 // Delete removes an item from cache
 func (c *Cache) Delete(cacheType, identifier string) {
 	c.mu.Lock()
@@ -178,14 +220,15 @@ func (c *Cache) evictOldest() {
 	}
 }
 
-// Cleanup removes expired entries
+// Cleanup removes expired entries. Entries with validators are kept so they can be
+// revalidated; the size limit still evicts them.
 func (c *Cache) Cleanup() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	var expiredKeys []string
 	for key, entry := range c.entries {
-		if entry.IsExpired() {
+		if entry.IsExpired() && !entry.HasValidators() {
 			expiredKeys = append(expiredKeys, key)
 		}
 	}
@@ -248,11 +291,57 @@ func (c *Cache) Stats() map[string]interface{} {
 	return stats
 }
 
+// flight is a request in progress that identical callers wait on
+type flight struct {
+	done chan struct{}
+	data interface{}
+	err  error
+}
+
+// flightGroup coalesces concurrent calls with the same key into one
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// do runs fn once per key at a time; callers arriving while it runs share its
+// result and get shared set to true
+func (g *flightGroup) do(key string, fn func() (interface{}, error)) (data interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+		return f.data, f.err, true
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	f.data, f.err = fn()
+	close(f.done)
+
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+
+	return f.data, f.err, false
+}
+
 // CachedClient wraps the API client with caching
 type CachedClient struct {
 	*client.Client
 	cache   *Cache
 	enabled bool
+	flights flightGroup
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	revalidations atomic.Int64
+	coalesced     atomic.Int64
 }
 
 // NewCachedClient creates a new cached client
@@ -273,54 +362,118 @@ func NewCachedClient(apiClient *client.Client, config *CacheConfig) *CachedClien
 	}
 }
 
-// GetUser gets user with caching
-func (c *CachedClient) GetUser(userID string) (*client.UserResponse, error) {
-	if c.enabled {
-		if cached, found := c.cache.Get("users", userID); found {
-			if user, ok := cached.(*client.UserResponse); ok {
-				return user, nil
-			}
+// fetchCached serves an endpoint from cache. A stale entry is revalidated with
+// If-None-Match/If-Modified-Since and a 304 reuses it; concurrent identical
+// fetches share one request.
+func fetchCached[T any](c *CachedClient, ctx context.Context, cacheType, key, endpoint string) (*T, error) {
+	if entry, found := c.cache.GetEntry(cacheType, key); found && !entry.IsExpired() {
+		if data, ok := entry.Data.(*T); ok {
+			c.hits.Add(1)
+			return data, nil
 		}
 	}
 
-	// Fetch from API
-	user, err := c.Client.GetUser(nil, userID)
+	data, err, shared := c.flights.do(cacheType+":"+key, func() (interface{}, error) {
+		return revalidate[T](c, ctx, cacheType, key, endpoint)
+	})
+	if shared {
+		c.coalesced.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data.(*T), nil
+}
+
+// revalidate performs the (conditional) request behind fetchCached
+func revalidate[T any](c *CachedClient, ctx context.Context, cacheType, key, endpoint string) (*T, error) {
+	header := http.Header{}
+	stale, found := c.cache.GetEntry(cacheType, key)
+	staleData, usable := stale.Data.(*T)
+	if found && usable {
+		if stale.ETag != "" {
+			header.Set("If-None-Match", stale.ETag)
+		}
+		if stale.LastModified != "" {
+			header.Set("If-Modified-Since", stale.LastModified)
+		}
+	}
+
+	resp, err := c.Client.GetWithHeaders(ctx, endpoint, header)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the result
-	if c.enabled && user != nil {
-		c.cache.Set("users", userID, user)
+	if resp.StatusCode == http.StatusNotModified && found && usable {
+		resp.Body.Close()
+		c.cache.Touch(cacheType, key)
+		c.hits.Add(1)
+		c.revalidations.Add(1)
+		return staleData, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+
+	var data T
+	if err := c.Client.ParseResponse(resp, &data); err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	c.misses.Add(1)
+	c.cache.SetWithValidators(cacheType, key, &data, etag, lastModified)
+	return &data, nil
+}
+
+// GetUser gets user with caching
+func (c *CachedClient) GetUser(userID string) (*client.UserResponse, error) {
+	if !c.enabled {
+		return c.Client.GetUser(context.Background(), userID)
+	}
+
+	return fetchCached[client.UserResponse](c, context.Background(), "users", userID,
+		fmt.Sprintf("/api/v1/users/%s", userID))
 }
 
 // GetMessages gets messages with caching
 func (c *CachedClient) GetMessages(otherUserID string, limit, page int) (*client.MessageListResponse, error) {
+	if !c.enabled {
+		return c.Client.GetMessages(context.Background(), otherUserID, limit, page)
+	}
+
 	cacheKey := fmt.Sprintf("%s:%d:%d", otherUserID, limit, page)
+	return fetchCached[client.MessageListResponse](c, context.Background(), "messages", cacheKey,
+		fmt.Sprintf("/api/v1/messages/conversation/%s?limit=%d&page=%d", otherUserID, limit, page))
+}
 
-	if c.enabled {
-		if cached, found := c.cache.Get("messages", cacheKey); found {
-			if messages, ok := cached.(*client.MessageListResponse); ok {
-				return messages, nil
+// MessagePages returns Client.MessagePages with each page cached like GetMessages,
+// so conversation walks reuse pages fetched within the messages TTL
+func (c *CachedClient) MessagePages(otherUserID string) client.PageFetcher[client.Message] {
+	fetch := c.Client.MessagePages(otherUserID)
+	return func(ctx context.Context, req client.PageRequest) (*client.Page[client.Message], error) {
+		cacheKey := fmt.Sprintf("pages:%s:%d:%d:%s", otherUserID, req.Limit, req.Page, req.Cursor)
+
+		if c.enabled {
+			if cached, found := c.cache.Get("messages", cacheKey); found {
+				if page, ok := cached.(*client.Page[client.Message]); ok {
+					c.hits.Add(1)
+					return page, nil
+				}
 			}
 		}
-	}
 
-	// Fetch from API
-	messages, err := c.Client.GetMessages(nil, otherUserID, limit, page)
-	if err != nil {
-		return nil, err
-	}
+		page, err := fetch(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 
-	// Cache the result
-	if c.enabled && messages != nil {
-		c.cache.Set("messages", cacheKey, messages)
-	}
+		if c.enabled && page != nil {
+			c.misses.Add(1)
+			c.cache.Set("messages", cacheKey, page)
+		}
 
-	return messages, nil
+		return page, nil
+	}
 }
 
 // InvalidateUser removes user from cache
@@ -338,7 +491,7 @@ func (c *CachedClient) InvalidateMessages(otherUserID string) {
 	}
 }
 
-// GetCacheStats returns cache statistics
+// GetCacheStats returns cache statistics, including hit, miss and revalidation counts
 func (c *CachedClient) GetCacheStats() map[string]interface{} {
 	if !c.enabled {
 		return map[string]interface{}{"enabled": false}
@@ -346,5 +499,9 @@ func (c *CachedClient) GetCacheStats() map[string]interface{} {
 
 	stats := c.cache.Stats()
 	stats["enabled"] = true
+	stats["hits"] = c.hits.Load()
+	stats["misses"] = c.misses.Load()
+	stats["revalidations"] = c.revalidations.Load()
+	stats["coalesced"] = c.coalesced.Load()
 	return stats
 }
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"plexichat-client/pkg/client"
+)
+
+func newTestCachedClient(t *testing.T, handler http.HandlerFunc, ttl time.Duration) *CachedClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	apiClient := client.NewClient(server.URL)
+	apiClient.SetRetryConfig(0, 0) // No retries for test
+	apiClient.SetAdvancedRetryConfig(client.RetryConfig{})
+
+	config := DefaultCacheConfig()
+	config.TypeSpecificTTL["users"] = ttl
+	config.TypeSpecificTTL["messages"] = ttl
+	return NewCachedClient(apiClient, config)
+}
+
+func TestCachedClient_GetUserRevalidatesWithETag(t *testing.T) {
+	var requests, conditional atomic.Int32
+	cc := newTestCachedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(client.UserResponse{ID: "42", Username: "alice"})
+	}, time.Millisecond)
+
+	first, err := cc.GetUser("42")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond) // Let the entry go stale
+
+	second, err := cc.GetUser("42")
+	if err != nil {
+		t.Fatalf("Expected no error on revalidation, got %v", err)
+	}
+	if second.Username != "alice" || second != first {
+		t.Errorf("Expected the cached user to be reused after a 304, got %+v", second)
+	}
+	if requests.Load() != 2 || conditional.Load() != 1 {
+		t.Errorf("Expected 2 requests with 1 conditional, got %d and %d", requests.Load(), conditional.Load())
+	}
+
+	stats := cc.GetCacheStats()
+	if stats["hits"] != int64(1) || stats["misses"] != int64(1) || stats["revalidations"] != int64(1) {
+		t.Errorf("Expected 1 hit, 1 miss and 1 revalidation, got %v", stats)
+	}
+}
+
+func TestCachedClient_GetMessagesUsesLastModified(t *testing.T) {
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	var sawIfModifiedSince atomic.Bool
+	cc := newTestCachedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			sawIfModifiedSince.Store(true)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		json.NewEncoder(w).Encode(client.MessageListResponse{})
+	}, time.Millisecond)
+
+	if _, err := cc.GetMessages("7", 50, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := cc.GetMessages("7", 50, 1); err != nil {
+		t.Fatalf("Expected no error on revalidation, got %v", err)
+	}
+
+	if !sawIfModifiedSince.Load() {
+		t.Error("Expected If-Modified-Since to be sent for a stale entry")
+	}
+}
+
+func TestCachedClient_FreshEntryIsHit(t *testing.T) {
+	var requests atomic.Int32
+	cc := newTestCachedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(client.UserResponse{ID: "1"})
+	}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cc.GetUser("1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", requests.Load())
+	}
+	if stats := cc.GetCacheStats(); stats["hits"] != int64(2) || stats["misses"] != int64(1) {
+		t.Errorf("Expected 2 hits and 1 miss, got %v", stats)
+	}
+}
+
+func TestCachedClient_CoalescesConcurrentRequests(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	cc := newTestCachedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		json.NewEncoder(w).Encode(client.UserResponse{ID: "1", Username: "bob"})
+	}, time.Minute)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cc.GetUser("1")
+			errs <- err
+		}()
+	}
+
+	// Wait until the first request reaches the server and the rest are queued behind it
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected concurrent calls to share 1 request, got %d", requests.Load())
+	}
+	if coalesced := cc.GetCacheStats()["coalesced"]; coalesced != int64(callers-1) {
+		t.Errorf("Expected %d coalesced calls, got %v", callers-1, coalesced)
+	}
+}
+
+func TestCache_CleanupKeepsRevalidatableEntries(t *testing.T) {
+	cache := NewCache(&CacheConfig{MaxSize: 10, TypeSpecificTTL: map[string]time.Duration{"users": time.Millisecond}})
+	cache.Set("users", "plain", "data")
+	cache.SetWithValidators("users", "tagged", "data", `"etag"`, "")
+
+	time.Sleep(5 * time.Millisecond)
+	cache.Cleanup()
+
+	if _, found := cache.GetEntry("users", "plain"); found {
+		t.Error("Expected expired entry without validators to be removed")
+	}
+	if _, found := cache.GetEntry("users", "tagged"); !found {
+		t.Error("Expected expired entry with an ETag to be kept for revalidation")
+	}
+}
@@ -12,11 +12,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"plexichat-client/pkg/errors"
 	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/messaging"
 	"plexichat-client/pkg/security"
+	"plexichat-client/pkg/wire"
 
 	"github.com/gorilla/websocket"
 )
@@ -62,17 +65,37 @@ func (c *Client) calculateRetryDelay(attempt int) time.Duration {
 	return delay
 }
 
+// refreshEndpoint exchanges a refresh token for a new access token
+const refreshEndpoint = "/api/v1/auth/refresh"
+
+// TokenRefreshFunc is called after the client has obtained a new access token
+type TokenRefreshFunc func(resp *LoginResponse)
+
 // Client represents the PlexiChat API client with 2FA/MFA support.
 type Client struct {
-	BaseURL     string
-	HTTPClient  *http.Client
-	APIKey      string
-	Token       string
-	UserAgent   string
-	MaxRetries  int
-	RetryDelay  time.Duration
-	RetryConfig RetryConfig
-	Debug       bool
+	BaseURL      string
+	HTTPClient   *http.Client
+	APIKey       string
+	Token        string
+	RefreshToken string
+	UserAgent    string
+	MaxRetries   int
+	RetryDelay   time.Duration
+	RetryConfig  RetryConfig
+	Debug        bool
+
+	authMu         sync.RWMutex // Guards Token and RefreshToken
+	refreshMu      sync.Mutex   // Serializes token refreshes
+	onTokenRefresh TokenRefreshFunc
+	wsDialer       *websocket.Dialer
+	wsBinary       bool // Offer the MessagePack subprotocol in ConnectWebSocket
+}
+
+// defaultWebSocketDialer is websocket.DefaultDialer with permessage-deflate offered
+var defaultWebSocketDialer = &websocket.Dialer{
+	Proxy:             http.ProxyFromEnvironment,
+	HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+	EnableCompression: true,
 }
 
 // NewClient creates a new PlexiChat API client
@@ -97,9 +120,42 @@ func (c *Client) SetAPIKey(apiKey string) {
 
 // SetToken sets the JWT token for authentication
 func (c *Client) SetToken(token string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
 	c.Token = token
 }
 
+// SetRefreshToken sets the refresh token used to renew an expired access token
+func (c *Client) SetRefreshToken(refreshToken string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.RefreshToken = refreshToken
+}
+
+// OnTokenRefresh registers a callback invoked whenever the access token is refreshed
+func (c *Client) OnTokenRefresh(fn TokenRefreshFunc) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.onTokenRefresh = fn
+}
+
+// Tokens returns the current access and refresh tokens
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
+	return c.Token, c.RefreshToken
+}
+
+// setAuthHeaders adds the bearer token or API key to outgoing request headers
+func (c *Client) setAuthHeaders(header http.Header) {
+	token, _ := c.Tokens()
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else if c.APIKey != "" {
+		header.Set("X-API-Key", c.APIKey)
+	}
+}
+
 // SetDebug enables or disables debug logging
 func (c *Client) SetDebug(debug bool) {
 	c.Debug = debug
@@ -121,8 +177,57 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.HTTPClient.Timeout = timeout
 }
 
+// SetTransportConfig applies custom CAs, client certificates, certificate pins
+// and a proxy to both REST requests and WebSocket connections
+func (c *Client) SetTransportConfig(config *security.TransportConfig) error {
+	transport, err := config.NewTransport()
+	if err != nil {
+		return fmt.Errorf("invalid TLS or proxy configuration: %w", err)
+	}
+
+	c.HTTPClient.Transport = transport
+	c.wsDialer = &websocket.Dialer{
+		Proxy:             transport.Proxy,
+		TLSClientConfig:   transport.TLSClientConfig,
+		HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+		EnableCompression: true,
+	}
+	return nil
+}
+
+// SetWebSocketDialer overrides the dialer used by ConnectWebSocket
+func (c *Client) SetWebSocketDialer(dialer *websocket.Dialer) {
+	c.wsDialer = dialer
+}
+
+// WebSocketDialer returns the dialer used by ConnectWebSocket
+func (c *Client) WebSocketDialer() *websocket.Dialer {
+	if c.wsDialer != nil {
+		return c.wsDialer
+	}
+	return defaultWebSocketDialer
+}
+
+// SetBinaryWebSocket makes ConnectWebSocket offer the MessagePack subprotocol.
+// Read and write frames with ReadWebSocketMessage and WriteWebSocketMessage, which
+// follow whichever encoding the server picks.
+func (c *Client) SetBinaryWebSocket(enabled bool) {
+	c.wsBinary = enabled
+}
+
 // Request makes an HTTP request to the PlexiChat API with retry logic
 func (c *Client) Request(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
+	return c.request(ctx, method, endpoint, body, nil)
+}
+
+// GetWithHeaders makes a GET request with extra headers such as If-None-Match.
+// The response is returned as-is, so callers must handle 304 Not Modified.
+func (c *Client) GetWithHeaders(ctx context.Context, endpoint string, header http.Header) (*http.Response, error) {
+	return c.request(ctx, "GET", endpoint, nil, header)
+}
+
+// request validates and sends a request, renewing an expired token once
+func (c *Client) request(ctx context.Context, method, endpoint string, body interface{}, header http.Header) (*http.Response, error) {
 	// Security validation
 	if !security.IsValidHTTPMethod(method) {
 		return nil, errors.NewValidationError("INVALID_METHOD", "Invalid HTTP method").WithContext("method", method)
@@ -152,6 +257,24 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 		}
 	}
 
+	resp, err := c.send(ctx, method, endpoint, reqBodyBytes, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired access token is renewed once and the request replayed
+	if resp.StatusCode == http.StatusUnauthorized && endpoint != refreshEndpoint {
+		if c.refreshAfterUnauthorized(ctx, resp) {
+			resp.Body.Close()
+			return c.send(ctx, method, endpoint, reqBodyBytes, header)
+		}
+	}
+
+	return resp, nil
+}
+
+// send performs the HTTP request, retrying network failures and server errors
+func (c *Client) send(ctx context.Context, method, endpoint string, reqBodyBytes []byte, header http.Header) (*http.Response, error) {
 	url := c.BaseURL + endpoint
 
 	var lastErr error
@@ -175,13 +298,12 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 		// Set headers
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("User-Agent", c.UserAgent)
+		for key, values := range header {
+			req.Header[key] = values
+		}
 
 		// Set authentication
-		if c.Token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.Token)
-		} else if c.APIKey != "" {
-			req.Header.Set("X-API-Key", c.APIKey)
-		}
+		c.setAuthHeaders(req.Header)
 
 		if c.Debug {
 			logging.Debug("%s %s (attempt %d/%d)", method, url, attempt+1, c.MaxRetries+1)
@@ -219,6 +341,114 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 	return nil, lastErr
 }
 
+// refreshAfterUnauthorized renews the access token after a 401 and reports whether
+// the request should be replayed. Concurrent callers share a single refresh: whoever
+// arrives after the token has already changed simply retries with the new one.
+func (c *Client) refreshAfterUnauthorized(ctx context.Context, resp *http.Response) bool {
+	if _, refreshToken := c.Tokens(); refreshToken == "" {
+		return false
+	}
+
+	var staleToken string
+	if resp.Request != nil {
+		staleToken = strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")
+	}
+
+	c.refreshMu.Lock()
+	if token, _ := c.Tokens(); token != "" && token != staleToken {
+		c.refreshMu.Unlock()
+		return true
+	}
+	loginResp, err := c.refreshLocked(ctx)
+	c.refreshMu.Unlock()
+
+	if err != nil {
+		if c.Debug {
+			logging.Debug("Token refresh failed: %v", err)
+		}
+		return false
+	}
+
+	c.notifyTokenRefresh(loginResp)
+	return true
+}
+
+// doWithRefresh sends a request built outside Request, such as an upload chunk or a
+// download range, renewing an expired access token once and replaying the request
+func (c *Client) doWithRefresh(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if !c.refreshAfterUnauthorized(req.Context(), resp) {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request: %w", err)
+		}
+		retry.Body = body
+	}
+	c.setAuthHeaders(retry.Header)
+
+	return c.HTTPClient.Do(retry)
+}
+
+// refreshLocked exchanges the refresh token for a new access token; refreshMu must be held
+func (c *Client) refreshLocked(ctx context.Context) (*LoginResponse, error) {
+	_, refreshToken := c.Tokens()
+	if refreshToken == "" {
+		return nil, errors.NewValidationError("NO_REFRESH_TOKEN", "No refresh token available")
+	}
+
+	body, err := json.Marshal(&RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refresh request: %w", err)
+	}
+
+	resp, err := c.send(ctx, "POST", refreshEndpoint, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var loginResp LoginResponse
+	if err := c.ParseResponse(resp, &loginResp); err != nil {
+		return nil, err
+	}
+	if loginResp.AccessToken == "" {
+		return nil, fmt.Errorf("refresh response did not include an access token")
+	}
+
+	c.authMu.Lock()
+	c.Token = loginResp.AccessToken
+	if loginResp.RefreshToken != "" {
+		c.RefreshToken = loginResp.RefreshToken // Server rotated the refresh token
+	} else {
+		loginResp.RefreshToken = c.RefreshToken
+	}
+	c.authMu.Unlock()
+
+	if c.Debug {
+		logging.Debug("Access token refreshed")
+	}
+
+	return &loginResp, nil
+}
+
+func (c *Client) notifyTokenRefresh(resp *LoginResponse) {
+	c.authMu.RLock()
+	fn := c.onTokenRefresh
+	c.authMu.RUnlock()
+
+	if fn != nil {
+		fn(resp)
+	}
+}
+
 // Get makes a GET request
 func (c *Client) Get(ctx context.Context, endpoint string) (*http.Response, error) {
 	return c.Request(ctx, "GET", endpoint, nil)
@@ -278,13 +508,9 @@ func (c *Client) UploadFile(ctx context.Context, endpoint, filePath string) (*ht
 	req.Header.Set("User-Agent", c.UserAgent)
 
 	// Set authentication
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	} else if c.APIKey != "" {
-		req.Header.Set("X-API-Key", c.APIKey)
-	}
+	c.setAuthHeaders(req.Header)
 
-	return c.HTTPClient.Do(req)
+	return c.doWithRefresh(req)
 }
 
 // ConnectWebSocket establishes a WebSocket connection
@@ -303,16 +529,19 @@ func (c *Client) ConnectWebSocket(ctx context.Context, endpoint string) (*websoc
 	// Set up headers
 	headers := http.Header{}
 	headers.Set("User-Agent", c.UserAgent)
-
-	if c.Token != "" {
-		headers.Set("Authorization", "Bearer "+c.Token)
-	} else if c.APIKey != "" {
-		headers.Set("X-API-Key", c.APIKey)
+	c.setAuthHeaders(headers)
+	if c.wsBinary {
+		headers.Set("Sec-WebSocket-Protocol", strings.Join(wire.Subprotocols, ", "))
 	}
 
 	// Create WebSocket connection
-	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.DialContext(ctx, u.String(), headers)
+	dialer := c.WebSocketDialer()
+	conn, resp, err := dialer.DialContext(ctx, u.String(), headers)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized && c.refreshAfterUnauthorized(ctx, resp) {
+		// The handshake was rejected with an expired token; retry once with the new one
+		c.setAuthHeaders(headers)
+		conn, _, err = dialer.DialContext(ctx, u.String(), headers)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
@@ -320,6 +549,32 @@ func (c *Client) ConnectWebSocket(ctx context.Context, endpoint string) (*websoc
 	return conn, nil
 }
 
+// ReadWebSocketMessage reads one frame from a connection made by ConnectWebSocket,
+// decoding it with the negotiated subprotocol, or as JSON if it is a text frame
+func ReadWebSocketMessage(conn *websocket.Conn) (*WebSocketMessage, error) {
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	var message WebSocketMessage
+	if err := wire.ForFrame(wire.ForSubprotocol(conn.Subprotocol()), frameType).Unmarshal(data, &message); err != nil {
+		return nil, fmt.Errorf("failed to decode WebSocket message: %w", err)
+	}
+	return &message, nil
+}
+
+// WriteWebSocketMessage writes a message to a connection made by ConnectWebSocket
+// in the negotiated encoding
+func WriteWebSocketMessage(conn *websocket.Conn, message *WebSocketMessage) error {
+	codec := wire.ForSubprotocol(conn.Subprotocol())
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode WebSocket message: %w", err)
+	}
+	return conn.WriteMessage(codec.FrameType(), data)
+}
+
 // ParseResponse parses an HTTP response into a struct
 func (c *Client) ParseResponse(resp *http.Response, v interface{}) error {
 	defer resp.Body.Close()
@@ -440,10 +695,27 @@ func (c *Client) Login(ctx context.Context, username, password string) (*LoginRe
 	// Set token for future requests if login was successful
 	if loginResp.AccessToken != "" {
 		c.SetToken(loginResp.AccessToken)
+		c.SetRefreshToken(loginResp.RefreshToken)
 	}
 	return &loginResp, nil
 }
 
+// RefreshAccessToken exchanges the stored refresh token for a new access token.
+// Requests that receive a 401 do this automatically; the OnTokenRefresh callback
+// is invoked in both cases.
+func (c *Client) RefreshAccessToken(ctx context.Context) (*LoginResponse, error) {
+	c.refreshMu.Lock()
+	loginResp, err := c.refreshLocked(ctx)
+	c.refreshMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.notifyTokenRefresh(loginResp)
+	return loginResp, nil
+}
+
 // LoginWith2FA authenticates with username, password and 2FA code
 func (c *Client) LoginWith2FA(ctx context.Context, username, password, method, code, challengeResponse string) (*TwoFALoginResponse, error) {
 	loginReq := &TwoFALoginRequest{
@@ -468,6 +740,7 @@ func (c *Client) LoginWith2FA(ctx context.Context, username, password, method, c
 	// Set token for future requests if 2FA was successful
 	if loginResp.AccessToken != "" {
 		c.SetToken(loginResp.AccessToken)
+		c.SetRefreshToken(loginResp.RefreshToken)
 	}
 	return &loginResp, nil
 }
@@ -652,6 +925,128 @@ func (c *Client) SendMessage(ctx context.Context, content string, recipientID st
 	return &message, err
 }
 
+// EditMessage replaces the content of a message sent by the current user
+func (c *Client) EditMessage(ctx context.Context, messageID int, content string) (*Message, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.NewValidationError("EMPTY_CONTENT", "Message content cannot be empty")
+	}
+
+	resp, err := c.Put(ctx, fmt.Sprintf("/api/v1/messages/%d", messageID), &EditMessageRequest{Content: content})
+	if err != nil {
+		return nil, err
+	}
+
+	var message Message
+	err = c.ParseResponse(resp, &message)
+	return &message, err
+}
+
+// DeleteMessage deletes a message
+func (c *Client) DeleteMessage(ctx context.Context, messageID int) error {
+	resp, err := c.Delete(ctx, fmt.Sprintf("/api/v1/messages/%d", messageID))
+	if err != nil {
+		return err
+	}
+
+	return c.ParseResponse(resp, nil)
+}
+
+// ReplyToMessage posts a reply in the thread of the given parent message
+func (c *Client) ReplyToMessage(ctx context.Context, parentID int, content string) (*Message, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.NewValidationError("EMPTY_CONTENT", "Message content cannot be empty")
+	}
+
+	replyReq := &ReplyMessageRequest{
+		Content:     content,
+		MessageType: "text",
+	}
+
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/messages/%d/replies", parentID), replyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var message Message
+	err = c.ParseResponse(resp, &message)
+	return &message, err
+}
+
+// GetThread retrieves the thread information for a message
+func (c *Client) GetThread(ctx context.Context, messageID int) (*messaging.ThreadInfo, error) {
+	resp, err := c.Get(ctx, fmt.Sprintf("/api/v1/messages/%d/thread", messageID))
+	if err != nil {
+		return nil, err
+	}
+
+	var thread messaging.ThreadInfo
+	err = c.ParseResponse(resp, &thread)
+	return &thread, err
+}
+
+// AddReaction adds an emoji reaction to a message and returns the updated reaction
+func (c *Client) AddReaction(ctx context.Context, messageID int, emoji string) (*messaging.Reaction, error) {
+	if emoji == "" {
+		return nil, errors.NewValidationError("EMPTY_EMOJI", "Reaction emoji cannot be empty")
+	}
+
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/messages/%d/reactions", messageID), &ReactionRequest{Emoji: emoji})
+	if err != nil {
+		return nil, err
+	}
+
+	var reaction messaging.Reaction
+	err = c.ParseResponse(resp, &reaction)
+	return &reaction, err
+}
+
+// RemoveReaction removes the current user's emoji reaction from a message
+func (c *Client) RemoveReaction(ctx context.Context, messageID int, emoji string) error {
+	if emoji == "" {
+		return errors.NewValidationError("EMPTY_EMOJI", "Reaction emoji cannot be empty")
+	}
+
+	// The emoji travels in the body since it cannot be expressed in a valid endpoint path
+	resp, err := c.Request(ctx, "DELETE", fmt.Sprintf("/api/v1/messages/%d/reactions", messageID), &ReactionRequest{Emoji: emoji})
+	if err != nil {
+		return err
+	}
+
+	return c.ParseResponse(resp, nil)
+}
+
+// MarkDelivered reports that a message reached this client
+func (c *Client) MarkDelivered(ctx context.Context, messageID int) error {
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/messages/%d/delivered", messageID), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.ParseResponse(resp, nil)
+}
+
+// MarkRead moves the current user's read position in a room up to messageID
+func (c *Client) MarkRead(ctx context.Context, roomID, messageID int) error {
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/rooms/%d/read", roomID), &ReadMarkerRequest{MessageID: messageID})
+	if err != nil {
+		return err
+	}
+
+	return c.ParseResponse(resp, nil)
+}
+
+// GetMessageReceipts retrieves who a message has been delivered to and read by
+func (c *Client) GetMessageReceipts(ctx context.Context, messageID int) (*MessageReceipts, error) {
+	resp, err := c.Get(ctx, fmt.Sprintf("/api/v1/messages/%d/receipts", messageID))
+	if err != nil {
+		return nil, err
+	}
+
+	var receipts MessageReceipts
+	err = c.ParseResponse(resp, &receipts)
+	return &receipts, err
+}
+
 // GetMessages retrieves messages with pagination
 func (c *Client) GetMessages(ctx context.Context, otherUserID string, limit, page int) (*MessageListResponse, error) {
 	endpoint := fmt.Sprintf("/api/v1/messages/conversation/%s?limit=%d&page=%d", otherUserID, limit, page)
@@ -665,6 +1060,27 @@ func (c *Client) GetMessages(ctx context.Context, otherUserID string, limit, pag
 	return &listResp, err
 }
 
+// GetMessagesSince retrieves messages newer than afterID and since, oldest first.
+// A roomID of 0 covers every room the user can read.
+func (c *Client) GetMessagesSince(ctx context.Context, roomID, afterID int, since time.Time, limit int) (*MessageListResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/messages?after_id=%d&limit=%d&order=asc", afterID, limit)
+	if roomID > 0 {
+		endpoint += fmt.Sprintf("&room_id=%d", roomID)
+	}
+	if !since.IsZero() {
+		endpoint += fmt.Sprintf("&since=%d", since.Unix()) // Unix seconds keep the endpoint within IsValidEndpoint
+	}
+
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var listResp MessageListResponse
+	err = c.ParseResponse(resp, &listResp)
+	return &listResp, err
+}
+
 // GetRooms retrieves available chat rooms
 func (c *Client) GetRooms(ctx context.Context, limit, page int) (*RoomListResponse, error) {
 	endpoint := fmt.Sprintf("/api/v1/rooms?limit=%d&page=%d", limit, page)
@@ -678,6 +1094,109 @@ func (c *Client) GetRooms(ctx context.Context, limit, page int) (*RoomListRespon
 	return &listResp, err
 }
 
+// GetRoom gets information about a specific room
+func (c *Client) GetRoom(ctx context.Context, roomID int) (*Room, error) {
+	resp, err := c.Get(ctx, fmt.Sprintf("/api/v1/rooms/%d", roomID))
+	if err != nil {
+		return nil, err
+	}
+
+	var room Room
+	err = c.ParseResponse(resp, &room)
+	return &room, err
+}
+
+// CreateRoom creates a new chat room
+func (c *Client) CreateRoom(ctx context.Context, req *CreateRoomRequest) (*Room, error) {
+	if req == nil || strings.TrimSpace(req.Name) == "" {
+		return nil, errors.NewValidationError("INVALID_ROOM_NAME", "Room name is required")
+	}
+
+	resp, err := c.Post(ctx, "/api/v1/rooms", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var room Room
+	err = c.ParseResponse(resp, &room)
+	return &room, err
+}
+
+// UpdateRoom updates a room's name, description or privacy
+func (c *Client) UpdateRoom(ctx context.Context, roomID int, req *UpdateRoomRequest) (*Room, error) {
+	resp, err := c.Put(ctx, fmt.Sprintf("/api/v1/rooms/%d", roomID), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var room Room
+	err = c.ParseResponse(resp, &room)
+	return &room, err
+}
+
+// DeleteRoom deletes a room
+func (c *Client) DeleteRoom(ctx context.Context, roomID int) error {
+	resp, err := c.Delete(ctx, fmt.Sprintf("/api/v1/rooms/%d", roomID))
+	if err != nil {
+		return err
+	}
+
+	return c.ParseResponse(resp, nil)
+}
+
+// JoinRoom joins the current user to a room
+func (c *Client) JoinRoom(ctx context.Context, roomID int) (*RoomActionResponse, error) {
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/rooms/%d/join", roomID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var actionResp RoomActionResponse
+	err = c.ParseResponse(resp, &actionResp)
+	return &actionResp, err
+}
+
+// LeaveRoom removes the current user from a room
+func (c *Client) LeaveRoom(ctx context.Context, roomID int) (*RoomActionResponse, error) {
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/rooms/%d/leave", roomID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var actionResp RoomActionResponse
+	err = c.ParseResponse(resp, &actionResp)
+	return &actionResp, err
+}
+
+// InviteToRoom invites one or more users to a room
+func (c *Client) InviteToRoom(ctx context.Context, roomID int, userIDs []int) (*RoomActionResponse, error) {
+	if len(userIDs) == 0 {
+		return nil, errors.NewValidationError("NO_INVITEES", "At least one user ID is required")
+	}
+
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/rooms/%d/invite", roomID), &InviteRoomRequest{UserIDs: userIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	var actionResp RoomActionResponse
+	err = c.ParseResponse(resp, &actionResp)
+	return &actionResp, err
+}
+
+// GetRoomMembers retrieves the members of a room with pagination
+func (c *Client) GetRoomMembers(ctx context.Context, roomID, limit, page int) (*RoomMemberListResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/rooms/%d/members?limit=%d&page=%d", roomID, limit, page)
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var listResp RoomMemberListResponse
+	err = c.ParseResponse(resp, &listResp)
+	return &listResp, err
+}
+
 // GetFiles retrieves uploaded files
 func (c *Client) GetFiles(ctx context.Context, limit, page int, fileType string) (*FileListResponse, error) {
 	endpoint := fmt.Sprintf("/api/v1/files?limit=%d&page=%d", limit, page)
@@ -2,10 +2,35 @@ package client
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"plexichat-client/pkg/files"
+	"plexichat-client/pkg/security"
+	"plexichat-client/pkg/wire"
+
+	gorillaws "github.com/gorilla/websocket"
 )
 
 func TestNewClient(t *testing.T) {
@@ -419,3 +444,1188 @@ func TestClient_Health(t *testing.T) {
 		t.Errorf("Expected version '1.0.0', got %s", health.Version)
 	}
 }
+
+func TestClient_CreateRoom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/rooms" {
+			t.Errorf("Expected POST /api/v1/rooms, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req CreateRoomRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Name != "general" || !req.IsPrivate || len(req.MemberIDs) != 2 {
+			t.Errorf("Unexpected create request: %+v", req)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7, "name": "general", "is_private": true, "created_by": 1}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	room, err := client.CreateRoom(context.Background(), &CreateRoomRequest{
+		Name:      "general",
+		IsPrivate: true,
+		MemberIDs: []int{2, 3},
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom failed: %v", err)
+	}
+
+	if room.ID != 7 || room.Name != "general" || !room.IsPrivate {
+		t.Errorf("Unexpected room: %+v", room)
+	}
+}
+
+func TestClient_CreateRoom_RequiresName(t *testing.T) {
+	client := NewClient("http://example.com")
+
+	if _, err := client.CreateRoom(context.Background(), &CreateRoomRequest{Name: "  "}); err == nil {
+		t.Error("Expected error for empty room name")
+	}
+}
+
+func TestClient_UpdateRoom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/api/v1/rooms/7" {
+			t.Errorf("Expected PUT /api/v1/rooms/7, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["name"] != "renamed" {
+			t.Errorf("Expected name 'renamed', got %v", body["name"])
+		}
+		if _, ok := body["description"]; ok {
+			t.Error("Expected unset description to be omitted")
+		}
+
+		w.Write([]byte(`{"id": 7, "name": "renamed"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	name := "renamed"
+	room, err := client.UpdateRoom(context.Background(), 7, &UpdateRoomRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateRoom failed: %v", err)
+	}
+
+	if room.Name != "renamed" {
+		t.Errorf("Expected name 'renamed', got %s", room.Name)
+	}
+}
+
+func TestClient_JoinLeaveRoom(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"success": true, "room_id": 7}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	ctx := context.Background()
+
+	joined, err := client.JoinRoom(ctx, 7)
+	if err != nil || !joined.Success {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+
+	left, err := client.LeaveRoom(ctx, 7)
+	if err != nil || !left.Success {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+
+	if len(paths) != 2 || paths[0] != "/api/v1/rooms/7/join" || paths[1] != "/api/v1/rooms/7/leave" {
+		t.Errorf("Unexpected request paths: %v", paths)
+	}
+}
+
+func TestClient_InviteToRoom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rooms/7/invite" {
+			t.Errorf("Expected path /api/v1/rooms/7/invite, got %s", r.URL.Path)
+		}
+
+		var req InviteRoomRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.UserIDs) != 1 || req.UserIDs[0] != 42 {
+			t.Errorf("Unexpected invitees: %v", req.UserIDs)
+		}
+
+		w.Write([]byte(`{"success": true, "room_id": 7}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	if _, err := client.InviteToRoom(context.Background(), 7, []int{42}); err != nil {
+		t.Fatalf("InviteToRoom failed: %v", err)
+	}
+
+	if _, err := client.InviteToRoom(context.Background(), 7, nil); err == nil {
+		t.Error("Expected error when inviting nobody")
+	}
+}
+
+func TestClient_GetRoomMembers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rooms/7/members" {
+			t.Errorf("Expected path /api/v1/rooms/7/members, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("limit") != "25" || r.URL.Query().Get("page") != "2" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+
+		w.Write([]byte(`{"items": [{"user_id": 1, "username": "alice", "role": "owner"}, {"user_id": 2, "username": "bob", "role": "member"}], "total": 27, "page": 2, "total_pages": 2}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	members, err := client.GetRoomMembers(context.Background(), 7, 25, 2)
+	if err != nil {
+		t.Fatalf("GetRoomMembers failed: %v", err)
+	}
+
+	if len(members.Members) != 2 || members.Members[0].Role != "owner" || members.Total != 27 {
+		t.Errorf("Unexpected members response: %+v", members)
+	}
+}
+
+func TestClient_DeleteRoom_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Only the owner can delete this room"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	err := client.DeleteRoom(context.Background(), 7)
+	if err == nil || err.Error() != "API error (status 403): Only the owner can delete this room" {
+		t.Errorf("Expected forbidden error, got %v", err)
+	}
+}
+
+func TestClient_EditMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/api/v1/messages/12" {
+			t.Errorf("Expected PUT /api/v1/messages/12, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req EditMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Content != "fixed typo" {
+			t.Errorf("Expected content 'fixed typo', got %s", req.Content)
+		}
+
+		w.Write([]byte(`{"id": 12, "content": "fixed typo", "edited": true, "edited_at": "2025-01-01T10:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	msg, err := client.EditMessage(context.Background(), 12, "fixed typo")
+	if err != nil {
+		t.Fatalf("EditMessage failed: %v", err)
+	}
+
+	if !msg.Edited || msg.EditedAt == nil || msg.Content != "fixed typo" {
+		t.Errorf("Unexpected edited message: %+v", msg)
+	}
+
+	if _, err := client.EditMessage(context.Background(), 12, " "); err == nil {
+		t.Error("Expected error for empty content")
+	}
+}
+
+func TestClient_DeleteMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/api/v1/messages/12" {
+			t.Errorf("Expected DELETE /api/v1/messages/12, got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	if err := client.DeleteMessage(context.Background(), 12); err != nil {
+		t.Errorf("DeleteMessage failed: %v", err)
+	}
+}
+
+func TestClient_ReplyToMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/messages/12/replies" {
+			t.Errorf("Expected POST /api/v1/messages/12/replies, got %s %s", r.Method, r.URL.Path)
+		}
+
+		w.Write([]byte(`{"id": 13, "content": "agreed", "parent_id": 12, "thread": {"parent_id": 12, "reply_count": 1, "participants": ["alice"]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	msg, err := client.ReplyToMessage(context.Background(), 12, "agreed")
+	if err != nil {
+		t.Fatalf("ReplyToMessage failed: %v", err)
+	}
+
+	if msg.ParentID == nil || *msg.ParentID != 12 {
+		t.Errorf("Expected parent ID 12, got %v", msg.ParentID)
+	}
+	if msg.Thread == nil || msg.Thread.ReplyCount != 1 {
+		t.Errorf("Expected thread info with 1 reply, got %+v", msg.Thread)
+	}
+}
+
+func TestClient_Reactions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/messages/12/reactions" {
+			t.Errorf("Expected path /api/v1/messages/12/reactions, got %s", r.URL.Path)
+		}
+
+		var req ReactionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Emoji != "👍" {
+			t.Errorf("Expected emoji 👍, got %s", req.Emoji)
+		}
+
+		switch r.Method {
+		case "POST":
+			w.Write([]byte(`{"emoji": "👍", "users": ["alice", "bob"], "count": 2}`))
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	ctx := context.Background()
+
+	reaction, err := client.AddReaction(ctx, 12, "👍")
+	if err != nil {
+		t.Fatalf("AddReaction failed: %v", err)
+	}
+	if reaction.Count != 2 || len(reaction.Users) != 2 {
+		t.Errorf("Unexpected reaction: %+v", reaction)
+	}
+
+	if err := client.RemoveReaction(ctx, 12, "👍"); err != nil {
+		t.Errorf("RemoveReaction failed: %v", err)
+	}
+}
+
+func TestClient_Receipts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/messages/12/delivered":
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /api/v1/rooms/3/read":
+			var req ReadMarkerRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.MessageID != 12 {
+				t.Errorf("Expected read marker at 12, got %d", req.MessageID)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /api/v1/messages/12/receipts":
+			w.Write([]byte(`{"message_id": 12, "delivered_to": [{"user_id": 2, "username": "bob"}, {"user_id": 3, "username": "carol"}], "read_by": [{"user_id": 2, "username": "bob"}]}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	ctx := context.Background()
+
+	if err := client.MarkDelivered(ctx, 12); err != nil {
+		t.Errorf("MarkDelivered failed: %v", err)
+	}
+	if err := client.MarkRead(ctx, 3, 12); err != nil {
+		t.Errorf("MarkRead failed: %v", err)
+	}
+
+	receipts, err := client.GetMessageReceipts(ctx, 12)
+	if err != nil {
+		t.Fatalf("GetMessageReceipts failed: %v", err)
+	}
+	if len(receipts.DeliveredTo) != 2 || len(receipts.ReadBy) != 1 || receipts.ReadBy[0].Username != "bob" {
+		t.Errorf("Unexpected receipts: %+v", receipts)
+	}
+}
+
+func TestPaginate_PageNumbers(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+
+		switch page {
+		case "1":
+			w.Write([]byte(`{"items": [{"id": 1}, {"id": 2}], "page": 1, "total_pages": 3, "has_next": true}`))
+		case "2":
+			w.Write([]byte(`{"items": [{"id": 3}, {"id": 4}], "page": 2, "total_pages": 3, "has_next": true}`))
+		default:
+			w.Write([]byte(`{"items": [{"id": 5}], "page": 3, "total_pages": 3, "has_next": false}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	pages := Paginate(context.Background(), client.RoomPages(), PaginateOptions{PageSize: 2})
+	rooms, err := pages.All()
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+
+	if len(rooms) != 5 || rooms[4].ID != 5 {
+		t.Errorf("Expected 5 rooms ending with ID 5, got %+v", rooms)
+	}
+	if fmt.Sprint(requested) != "[1 2 3]" {
+		t.Errorf("Expected pages [1 2 3], got %v", requested)
+	}
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items": [{"id": 1}, {"id": 2}], "next_cursor": "abc"}`))
+		case "abc":
+			w.Write([]byte(`{"items": [{"id": 3}]}`))
+		default:
+			t.Errorf("Unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	pages := Paginate(context.Background(), client.MessagePages("42"), PaginateOptions{PageSize: 2, Prefetch: true})
+	defer pages.Close()
+
+	var ids []int
+	for pages.Next() {
+		ids = append(ids, pages.Item().ID)
+	}
+
+	if err := pages.Err(); err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("Expected IDs [1 2 3], got %v", ids)
+	}
+	if pages.PagesFetched() != 2 {
+		t.Errorf("Expected 2 pages fetched, got %d", pages.PagesFetched())
+	}
+}
+
+func TestPaginate_OffsetAndMaxItems(t *testing.T) {
+	var offsets []string
+	fetch := func(ctx context.Context, req PageRequest) (*Page[int], error) {
+		offsets = append(offsets, fmt.Sprint(req.Offset))
+		return &Page[int]{Items: []int{req.Offset, req.Offset + 1, req.Offset + 2}, HasNext: true}, nil
+	}
+
+	items, err := Paginate(context.Background(), fetch, PaginateOptions{PageSize: 3, MaxItems: 5}).All()
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+
+	if fmt.Sprint(items) != "[0 1 2 3 4]" {
+		t.Errorf("Expected items [0 1 2 3 4], got %v", items)
+	}
+	if fmt.Sprint(offsets) != "[0 3]" {
+		t.Errorf("Expected offsets [0 3], got %v", offsets)
+	}
+}
+
+func TestPaginate_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fetch := func(ctx context.Context, req PageRequest) (*Page[int], error) {
+		if req.Page == 2 {
+			cancel()
+		}
+		return &Page[int]{Items: []int{req.Page}, HasNext: true}, nil
+	}
+
+	pages := Paginate(ctx, fetch, PaginateOptions{})
+	count := 0
+	for pages.Next() {
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("Expected iteration to stop after 2 items, got %d", count)
+	}
+	if pages.Err() != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", pages.Err())
+	}
+}
+
+// chunkServer is an httptest stand-in for the chunked upload protocol
+type chunkServer struct {
+	mu       sync.Mutex
+	chunks   map[int][]byte
+	failFrom int // reject chunks with index >= failFrom (-1 disables)
+	puts     int
+	sessions int
+}
+
+func (cs *chunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/api/v1/files/uploads":
+		cs.sessions++
+		w.Write([]byte(`{"upload_id": "up1", "received_chunks": []}`))
+	case r.Method == "GET" && r.URL.Path == "/api/v1/files/uploads/up1":
+		received := []int{}
+		for index := range cs.chunks {
+			received = append(received, index)
+		}
+		json.NewEncoder(w).Encode(UploadSession{UploadID: "up1", ReceivedChunks: received})
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/v1/files/uploads/up1/chunks/"):
+		cs.puts++
+		index, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/files/uploads/up1/chunks/"))
+		if cs.failFrom >= 0 && index >= cs.failFrom {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "connection reset"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Chunk-Checksum") != "sha256="+hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		cs.chunks[index] = body
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "POST" && r.URL.Path == "/api/v1/files/uploads/up1/complete":
+		var size int
+		for _, chunk := range cs.chunks {
+			size += len(chunk)
+		}
+		fmt.Fprintf(w, `{"id": 9, "filename": "data.bin", "size": %d}`, size)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_UploadFileChunked_Resume(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "data.bin")
+	content := []byte(strings.Repeat("0123456789", 10)) // 100 bytes -> 10 chunks of 10
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := &chunkServer{chunks: make(map[int][]byte), failFrom: 6}
+	server := httptest.NewServer(cs)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	opts := &ChunkedUploadOptions{ChunkSize: 10, Concurrency: 1, ResumeDir: filepath.Join(dir, "resume")}
+
+	_, err := client.UploadFileChunked(context.Background(), filePath, opts)
+	if err == nil {
+		t.Fatal("Expected first upload attempt to fail")
+	}
+	if IsResumableUploadError(err) {
+		t.Errorf("Expected a rejected chunk not to be reported as resumable: %v", err)
+	}
+
+	tokens, _ := filepath.Glob(filepath.Join(dir, "resume", "*.upload.json"))
+	if len(tokens) != 1 {
+		t.Fatalf("Expected a resume token to be saved, found %d", len(tokens))
+	}
+
+	cs.mu.Lock()
+	cs.failFrom = -1
+	cs.puts = 0
+	cs.mu.Unlock()
+
+	var last files.UploadProgress
+	opts.Resume = true
+	opts.OnProgress = func(progress files.UploadProgress) { last = progress }
+
+	file, err := client.UploadFileChunked(context.Background(), filePath, opts)
+	if err != nil {
+		t.Fatalf("Resumed upload failed: %v", err)
+	}
+
+	if file.Size != 100 {
+		t.Errorf("Expected 100 bytes uploaded, got %d", file.Size)
+	}
+	if cs.puts != 4 {
+		t.Errorf("Expected only the 4 missing chunks to be sent, got %d", cs.puts)
+	}
+	if cs.sessions != 1 {
+		t.Errorf("Expected the original session to be reused, got %d sessions", cs.sessions)
+	}
+	if last.BytesUploaded != 100 || last.Percentage != 100 {
+		t.Errorf("Expected final progress of 100 bytes / 100%%, got %+v", last)
+	}
+
+	tokens, _ = filepath.Glob(filepath.Join(dir, "resume", "*.upload.json"))
+	if len(tokens) != 0 {
+		t.Errorf("Expected resume token to be removed after completion")
+	}
+}
+
+func TestClient_UploadFileChunked_ServerErrorIsResumable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data.bin")
+	os.WriteFile(filePath, []byte("0123456789"), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.Write([]byte(`{"upload_id": "up1", "received_chunks": []}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetAdvancedRetryConfig(RetryConfig{})
+
+	_, err := client.UploadFileChunked(context.Background(), filePath, &ChunkedUploadOptions{ChunkSize: 5})
+	if !IsResumableUploadError(err) {
+		t.Errorf("Expected server errors to be resumable, got %v", err)
+	}
+
+	// Interrupted once the session exists, before any chunk is sent
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := &ChunkedUploadOptions{ChunkSize: 5, OnProgress: func(files.UploadProgress) { cancel() }}
+	_, err = client.UploadFileChunked(ctx, filePath, opts)
+	if !IsResumableUploadError(err) {
+		t.Errorf("Expected cancellation to be resumable, got %v", err)
+	}
+}
+
+func TestClient_UploadFileChunked_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "data.bin")
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	os.WriteFile(filePath, content, 0644)
+
+	cs := &chunkServer{chunks: make(map[int][]byte), failFrom: -1}
+	server := httptest.NewServer(cs)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	opts := UploadOptionsFromConfig(&files.FileManagerConfig{ChunkSize: 64, ConcurrentUploads: 4})
+	if _, err := client.UploadFileChunked(context.Background(), filePath, opts); err != nil {
+		t.Fatalf("UploadFileChunked failed: %v", err)
+	}
+
+	var assembled []byte
+	for index := 0; index < len(cs.chunks); index++ {
+		assembled = append(assembled, cs.chunks[index]...)
+	}
+	if string(assembled) != string(content) {
+		t.Errorf("Reassembled upload does not match the original file")
+	}
+}
+
+// newDownloadServer serves file info and a range-capable download endpoint for file 5
+func newDownloadServer(t *testing.T, content []byte, hash string, ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/files/5":
+			fmt.Fprintf(w, `{"id": 5, "filename": "data.bin", "size": %d, "checksum": %q}`, len(content), hash)
+		case "/api/v1/files/5/download":
+			mu.Lock()
+			*ranges = append(*ranges, r.Header.Get("Range"))
+			mu.Unlock()
+			http.ServeContent(w, r, "data.bin", time.Time{}, strings.NewReader(string(content)))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient_DownloadFile_Parallel(t *testing.T) {
+	content := []byte(strings.Repeat("abcdefghij", 100))
+	sum := sha256.Sum256(content)
+
+	var ranges []string
+	server := newDownloadServer(t, content, hex.EncodeToString(sum[:]), &ranges)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	output := filepath.Join(t.TempDir(), "data.bin")
+	var downloaded int64
+	var mu sync.Mutex
+	result, err := client.DownloadFile(context.Background(), 5, output, &DownloadOptions{
+		Segments: 4,
+		OnProgress: func(done, total int64) {
+			mu.Lock()
+			downloaded = done
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(output)
+	if string(data) != string(content) {
+		t.Errorf("Downloaded content does not match")
+	}
+	if !result.Verified {
+		t.Errorf("Expected the download to be verified against its hash")
+	}
+	if len(ranges) != 4 {
+		t.Errorf("Expected 4 range requests, got %v", ranges)
+	}
+	if downloaded != int64(len(content)) {
+		t.Errorf("Expected progress to reach %d, got %d", len(content), downloaded)
+	}
+	if _, err := os.Stat(output + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected .part file to be removed")
+	}
+}
+
+func TestClient_DownloadFile_Resume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 10))
+	sum := sha256.Sum256(content)
+
+	var ranges []string
+	server := newDownloadServer(t, content, hex.EncodeToString(sum[:]), &ranges)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	output := filepath.Join(t.TempDir(), "data.bin")
+	os.WriteFile(output+".part", content[:40], 0644)
+
+	if _, err := client.DownloadFile(context.Background(), 5, output, &DownloadOptions{Resume: true}); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=40-99" {
+		t.Errorf("Expected a single request for bytes=40-99, got %v", ranges)
+	}
+
+	data, _ := os.ReadFile(output)
+	if string(data) != string(content) {
+		t.Errorf("Resumed content does not match")
+	}
+}
+
+func TestClient_DownloadFile_HashMismatch(t *testing.T) {
+	content := []byte("hello world")
+
+	var ranges []string
+	server := newDownloadServer(t, content, strings.Repeat("0", 64), &ranges)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	output := filepath.Join(t.TempDir(), "data.bin")
+	_, err := client.DownloadFile(context.Background(), 5, output, nil)
+	if err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("Expected hash mismatch error, got %v", err)
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Expected corrupt download not to be moved into place")
+	}
+	if _, err := os.Stat(output + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected corrupt .part file to be removed")
+	}
+}
+
+func TestClient_DownloadFile_UnsupportedHash(t *testing.T) {
+	content := []byte("hello world")
+
+	var ranges []string
+	server := newDownloadServer(t, content, "sha1:2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", &ranges)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+
+	file, err := client.GetFileInfo(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "data.bin")
+	result, err := client.DownloadFile(context.Background(), 5, output, &DownloadOptions{File: file})
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if result.Verified {
+		t.Errorf("Expected an unsupported hash not to be reported as verified")
+	}
+	if result.File != file {
+		t.Errorf("Expected the supplied file info to be reused")
+	}
+}
+
+func TestClient_RefreshOn401(t *testing.T) {
+	var refreshes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/auth/refresh":
+			atomic.AddInt32(&refreshes, 1)
+			var req RefreshTokenRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.RefreshToken != "refresh-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			time.Sleep(20 * time.Millisecond) // Give concurrent requests time to pile up
+			w.Write([]byte(`{"access_token": "fresh", "refresh_token": "refresh-2"}`))
+		case "/api/v1/auth/me":
+			if r.Header.Get("Authorization") != "Bearer fresh" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "token expired"}`))
+				return
+			}
+			w.Write([]byte(`{"id": "1", "username": "alice"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetToken("expired")
+	client.SetRefreshToken("refresh-1")
+
+	var callbacks int32
+	client.OnTokenRefresh(func(resp *LoginResponse) {
+		atomic.AddInt32(&callbacks, 1)
+		if resp.AccessToken != "fresh" || resp.RefreshToken != "refresh-2" {
+			t.Errorf("Unexpected refreshed tokens: %+v", resp)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := client.GetCurrentUser(context.Background())
+			if err != nil {
+				t.Errorf("GetCurrentUser failed: %v", err)
+				return
+			}
+			if user.Username != "alice" {
+				t.Errorf("Expected alice, got %s", user.Username)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Errorf("Expected a single refresh, got %d", n)
+	}
+	if n := atomic.LoadInt32(&callbacks); n != 1 {
+		t.Errorf("Expected a single refresh callback, got %d", n)
+	}
+	if token, refresh := client.Tokens(); token != "fresh" || refresh != "refresh-2" {
+		t.Errorf("Expected rotated tokens, got %s/%s", token, refresh)
+	}
+}
+
+func TestClient_RefreshFailureReturnsOriginal401(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "invalid token"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetToken("expired")
+	client.SetRefreshToken("revoked")
+
+	_, err := client.GetCurrentUser(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected 401 error, got %v", err)
+	}
+}
+
+// writeServerCA writes the certificate of an httptest TLS server to a PEM file
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+	return path
+}
+
+// writeClientCert generates a self-signed client certificate and returns its paths
+func writeClientCert(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "plexichat-test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile, cert
+}
+
+func TestClient_TransportConfig_CustomCA(t *testing.T) {
+	upgrader := gorillaws.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	// The system roots do not trust the test server
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetAdvancedRetryConfig(RetryConfig{})
+	if _, err := client.Get(context.Background(), "/api/v1/ping"); err == nil {
+		t.Fatal("Expected untrusted certificate to be rejected")
+	}
+
+	if err := client.SetTransportConfig(&security.TransportConfig{CACertFile: writeServerCA(t, server)}); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "/api/v1/ping")
+	if err != nil {
+		t.Fatalf("Request with custom CA failed: %v", err)
+	}
+	resp.Body.Close()
+
+	conn, err := client.ConnectWebSocket(context.Background(), "/ws")
+	if err != nil {
+		t.Fatalf("WebSocket with custom CA failed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestClient_TransportConfig_Pinning(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetAdvancedRetryConfig(RetryConfig{})
+
+	goodPin := security.PublicKeyPin(server.Certificate())
+	badPin := "sha256/" + strings.Repeat("A", 43) + "="
+
+	config := &security.TransportConfig{InsecureSkipVerify: true, PinnedKeys: []string{badPin}}
+	if err := client.SetTransportConfig(config); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+	if _, err := client.Get(context.Background(), "/"); err == nil {
+		t.Error("Expected pin mismatch to be rejected")
+	}
+
+	config.PinnedKeys = []string{badPin, goodPin}
+	if err := client.SetTransportConfig(config); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+	resp, err := client.Get(context.Background(), "/")
+	if err != nil {
+		t.Fatalf("Expected pinned key to be accepted: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := client.SetTransportConfig(&security.TransportConfig{PinnedKeys: []string{"not-a-pin"}}); err == nil {
+		t.Error("Expected malformed pin to be rejected")
+	}
+}
+
+func TestClient_TransportConfig_MutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCert(t)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user": "` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writeServerCA(t, server)
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetAdvancedRetryConfig(RetryConfig{})
+
+	client.SetTransportConfig(&security.TransportConfig{CACertFile: caFile})
+	if _, err := client.Get(context.Background(), "/"); err == nil {
+		t.Error("Expected request without client certificate to fail")
+	}
+
+	err := client.SetTransportConfig(&security.TransportConfig{
+		CACertFile:     caFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	})
+	if err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "/")
+	if err != nil {
+		t.Fatalf("Request with client certificate failed: %v", err)
+	}
+
+	var body map[string]string
+	if err := client.ParseResponse(resp, &body); err != nil {
+		t.Fatalf("ParseResponse failed: %v", err)
+	}
+	if body["user"] != "plexichat-test-client" {
+		t.Errorf("Expected server to see the client certificate, got %v", body)
+	}
+}
+
+func TestClient_TransportConfig_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer proxy.Close()
+
+	client := NewClient("http://plexichat.internal:8000")
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetAdvancedRetryConfig(RetryConfig{})
+	if err := client.SetTransportConfig(&security.TransportConfig{ProxyURL: proxy.URL}); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "/api/v1/ping")
+	if err != nil {
+		t.Fatalf("Request through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(proxied) != 1 || proxied[0] != "http://plexichat.internal:8000/api/v1/ping" {
+		t.Errorf("Expected request to go through the proxy, got %v", proxied)
+	}
+
+	if err := client.SetTransportConfig(&security.TransportConfig{ProxyURL: "ftp://proxy"}); err == nil {
+		t.Error("Expected unsupported proxy scheme to be rejected")
+	}
+}
+
+func TestClient_RefreshOn401_UploadAndDownload(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 10))
+	sum := sha256.Sum256(content)
+
+	cs := &chunkServer{chunks: make(map[int][]byte), failFrom: -1}
+
+	// The access token expires after the third chunk and again before the download
+	var mu sync.Mutex
+	valid, generation, refreshes := "token-1", 1, 0
+	expire := func() {
+		generation++
+		valid = fmt.Sprintf("token-%d", generation)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.URL.Path == "/api/v1/auth/refresh" {
+			refreshes++
+			fmt.Fprintf(w, `{"access_token": %q, "refresh_token": "refresh"}`, valid)
+			mu.Unlock()
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			mu.Unlock()
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "token expired"}`))
+			return
+		}
+		if r.Method == "PUT" && len(cs.chunks) == 3 && generation == 1 {
+			expire()
+			mu.Unlock()
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Unlock()
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/files/uploads"):
+			cs.ServeHTTP(w, r)
+		case r.URL.Path == "/api/v1/files/5":
+			fmt.Fprintf(w, `{"id": 5, "filename": "data.bin", "size": %d, "checksum": "%x"}`, len(content), sum)
+		case r.URL.Path == "/api/v1/files/5/download":
+			http.ServeContent(w, r, "data.bin", time.Time{}, strings.NewReader(string(content)))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetRetryConfig(0, 0) // No retries for test
+	client.SetToken("token-1")
+	client.SetRefreshToken("refresh")
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "data.bin")
+	os.WriteFile(filePath, content, 0644)
+
+	opts := &ChunkedUploadOptions{ChunkSize: 10, Concurrency: 1, ResumeDir: filepath.Join(dir, "resume")}
+	if _, err := client.UploadFileChunked(context.Background(), filePath, opts); err != nil {
+		t.Fatalf("Chunked upload did not survive token expiry: %v", err)
+	}
+	if len(cs.chunks) != 10 {
+		t.Errorf("Expected 10 chunks uploaded, got %d", len(cs.chunks))
+	}
+
+	// Expire the token once the file info has been fetched, so only the ranges see it
+	file, err := client.GetFileInfo(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	mu.Lock()
+	expire()
+	mu.Unlock()
+
+	output := filepath.Join(dir, "downloaded.bin")
+	if _, err := client.DownloadFile(context.Background(), file.ID, output, &DownloadOptions{Segments: 2, File: file}); err != nil {
+		t.Fatalf("Segmented download did not survive token expiry: %v", err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != string(content) {
+		t.Errorf("Downloaded content does not match")
+	}
+
+	if refreshes != 2 {
+		t.Errorf("Expected one refresh per expiry, got %d", refreshes)
+	}
+}
+
+func TestClient_BinaryWebSocket(t *testing.T) {
+	upgrader := gorillaws.Upgrader{Subprotocols: wire.Subprotocols, EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Echo frames back unchanged
+		for {
+			frameType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(frameType, data)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		binary      bool
+		subprotocol string
+	}{
+		{"json fallback", false, ""},
+		{"msgpack", true, wire.SubprotocolMsgpack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(server.URL)
+			client.SetBinaryWebSocket(tt.binary)
+
+			conn, err := client.ConnectWebSocket(context.Background(), "/ws")
+			if err != nil {
+				t.Fatalf("Expected to connect, got %v", err)
+			}
+			defer conn.Close()
+
+			if conn.Subprotocol() != tt.subprotocol {
+				t.Errorf("Expected subprotocol %q, got %q", tt.subprotocol, conn.Subprotocol())
+			}
+
+			sent := &WebSocketMessage{Type: "chat", Data: "hello", Timestamp: time.Now().UTC(), RoomID: 3}
+			if err := WriteWebSocketMessage(conn, sent); err != nil {
+				t.Fatalf("Expected to write, got %v", err)
+			}
+
+			received, err := ReadWebSocketMessage(conn)
+			if err != nil {
+				t.Fatalf("Expected to read, got %v", err)
+			}
+			if received.Type != "chat" || received.Data != "hello" || received.RoomID != 3 {
+				t.Errorf("Expected the message echoed back, got %+v", received)
+			}
+		})
+	}
+}
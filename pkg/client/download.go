@@ -0,0 +1,457 @@
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"plexichat-client/pkg/logging"
+)
+
+// DownloadOptions configures a ranged, resumable download
+type DownloadOptions struct {
+	Segments   int   // Parallel range requests; 1 downloads sequentially
+	Resume     bool  // Continue from an existing .part file instead of starting over
+	SkipVerify bool  // Do not check the finished file against the server-reported hash
+	File       *File // Metadata already fetched with GetFileInfo; fetched when nil
+	OnProgress func(downloaded, total int64)
+}
+
+// DownloadResult describes a finished download
+type DownloadResult struct {
+	File     *File
+	Verified bool // The data matched the server-reported hash
+}
+
+// downloadSegment is a byte range [Start, End] of which Done bytes have been written
+type downloadSegment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (s *downloadSegment) remaining() int64 {
+	return s.End - s.Start + 1 - s.Done
+}
+
+// downloadState is persisted next to the .part file during parallel downloads
+type downloadState struct {
+	FileID   int               `json:"file_id"`
+	Size     int64             `json:"size"`
+	Hash     string            `json:"hash"`
+	Segments []downloadSegment `json:"segments"`
+}
+
+// DownloadFile downloads a file to outputPath. Data is written to outputPath+".part"
+// and only renamed into place once it matches the server-reported hash, so an
+// interrupted download can be continued with opts.Resume.
+func (c *Client) DownloadFile(ctx context.Context, fileID int, outputPath string, opts *DownloadOptions) (*DownloadResult, error) {
+	if opts == nil {
+		opts = &DownloadOptions{Segments: 1}
+	}
+
+	var err error
+	info := opts.File
+	if info == nil {
+		if info, err = c.GetFileInfo(ctx, fileID); err != nil {
+			return nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+	}
+
+	partPath := outputPath + ".part"
+	statePath := partPath + ".json"
+
+	if !opts.Resume {
+		os.Remove(partPath)
+		os.Remove(statePath)
+	}
+
+	expectedHash := info.Checksum
+	if expectedHash == "" {
+		expectedHash = info.Hash
+	}
+
+	if state, ok := loadDownloadState(statePath, fileID, info.Size, expectedHash); ok {
+		err = c.downloadSegments(ctx, fileID, partPath, statePath, state, opts.OnProgress)
+	} else if opts.Segments > 1 && info.Size > 0 {
+		state := newDownloadState(fileID, info.Size, expectedHash, opts.Segments)
+		err = c.downloadSegments(ctx, fileID, partPath, statePath, state, opts.OnProgress)
+	} else {
+		err = c.downloadSequential(ctx, fileID, partPath, info.Size, opts.OnProgress)
+	}
+	if errors.Is(err, errRangeUnsupported) {
+		logging.Info("Server does not support range requests; downloading sequentially")
+		os.Remove(partPath)
+		os.Remove(statePath)
+		err = c.downloadSequential(ctx, fileID, partPath, info.Size, opts.OnProgress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DownloadResult{File: info}
+	if !opts.SkipVerify && expectedHash != "" {
+		if result.Verified, err = verifyFileHash(partPath, expectedHash); err != nil {
+			// A corrupt .part must not be resumed, otherwise the mismatch is permanent
+			os.Remove(partPath)
+			os.Remove(statePath)
+			return nil, err
+		}
+	}
+
+	if err := os.Rename(partPath, outputPath); err != nil {
+		return nil, fmt.Errorf("failed to move download into place: %w", err)
+	}
+	os.Remove(statePath)
+
+	return result, nil
+}
+
+// downloadSequential streams the file into partPath, appending to whatever is already there
+func (c *Client) downloadSequential(ctx context.Context, fileID int, partPath string, size int64, onProgress func(int64, int64)) error {
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer out.Close()
+
+	stat, err := out.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat partial file: %w", err)
+	}
+
+	segment := &downloadSegment{Start: 0, End: size - 1, Done: stat.Size()}
+	if size <= 0 {
+		// Unknown size: fetch everything after what we already have
+		segment.End = -1
+	} else if segment.remaining() <= 0 {
+		return out.Truncate(size)
+	}
+
+	var downloaded atomic.Int64
+	downloaded.Store(segment.Done)
+	report := func(n int64) {
+		total := downloaded.Add(n)
+		if onProgress != nil {
+			onProgress(total, size)
+		}
+	}
+	report(0)
+
+	return c.fetchSegment(ctx, fileID, out, segment, report, nil)
+}
+
+// downloadSegments fetches each unfinished segment concurrently into a preallocated partPath
+func (c *Client) downloadSegments(ctx context.Context, fileID int, partPath, statePath string, state *downloadState, onProgress func(int64, int64)) error {
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer out.Close()
+
+	if err := out.Truncate(state.Size); err != nil {
+		return fmt.Errorf("failed to allocate partial file: %w", err)
+	}
+
+	var mu sync.Mutex
+	var lastSave time.Time
+	save := func(force bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !force && time.Since(lastSave) < 500*time.Millisecond {
+			return
+		}
+		lastSave = time.Now()
+		if err := saveDownloadState(statePath, state); err != nil {
+			logging.Warn("Failed to save download state: %v", err)
+		}
+	}
+	save(true)
+	defer save(true)
+
+	var downloaded atomic.Int64
+	for _, segment := range state.Segments {
+		downloaded.Add(segment.Done)
+	}
+	report := func(n int64) {
+		total := downloaded.Add(n)
+		if onProgress != nil {
+			onProgress(total, state.Size)
+		}
+	}
+	report(0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(state.Segments))
+
+	for i := range state.Segments {
+		segment := &state.Segments[i]
+		if segment.remaining() <= 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.fetchSegment(ctx, fileID, out, segment, report, &mu); err != nil {
+				errs <- err
+				cancel()
+				return
+			}
+			save(false)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// fetchSegment downloads the remaining bytes of a segment, re-issuing the range request
+// from the current offset when the connection drops. mu, if set, guards segment.Done.
+func (c *Client) fetchSegment(ctx context.Context, fileID int, out *os.File, segment *downloadSegment, report func(int64), mu *sync.Mutex) error {
+	endpoint := fmt.Sprintf("/api/v1/files/%d/download", fileID)
+	maxRetries := c.RetryConfig.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = c.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(c.calculateRetryDelay(attempt - 1)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		progressed, err := c.fetchRange(ctx, endpoint, out, segment, report, mu)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var permanent *downloadError
+		if errors.As(err, &permanent) {
+			return err
+		}
+
+		lastErr = err
+		if progressed {
+			// Data is flowing again; only consecutive failures count against the budget
+			attempt = 0
+		}
+		if c.Debug {
+			logging.Debug("Segment %d-%d interrupted, retrying: %v", segment.Start, segment.End, err)
+		}
+	}
+
+	return fmt.Errorf("download failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// errRangeUnsupported is returned by parallel downloads when the server ignores Range headers
+var errRangeUnsupported = errors.New("server does not support range requests")
+
+// downloadError marks failures that retrying will not fix
+type downloadError struct {
+	err error
+}
+
+func (e *downloadError) Error() string { return e.err.Error() }
+func (e *downloadError) Unwrap() error { return e.err }
+
+// fetchRange performs one range request for the unfinished part of a segment
+func (c *Client) fetchRange(ctx context.Context, endpoint string, out *os.File, segment *downloadSegment, report func(int64), mu *sync.Mutex) (bool, error) {
+	lock := func() {
+		if mu != nil {
+			mu.Lock()
+		}
+	}
+	unlock := func() {
+		if mu != nil {
+			mu.Unlock()
+		}
+	}
+
+	lock()
+	offset := segment.Start + segment.Done
+	unlock()
+
+	req, err := c.newRawRequest(ctx, "GET", endpoint, "", nil)
+	if err != nil {
+		return false, &downloadError{err}
+	}
+	if segment.End >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, segment.End))
+	} else if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.doWithRefresh(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the Range header and is sending the whole body
+		if mu != nil {
+			return false, &downloadError{errRangeUnsupported}
+		}
+		if offset != 0 {
+			report(-segment.Done)
+			segment.Done = 0
+			offset = 0
+			if err := out.Truncate(0); err != nil {
+				return false, &downloadError{err}
+			}
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// Everything up to the end was already downloaded
+		return false, nil
+	case resp.StatusCode >= 500:
+		return false, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	default:
+		return false, &downloadError{fmt.Errorf("download failed: %w", c.ParseResponse(resp, nil))}
+	}
+
+	buf := make([]byte, 256*1024)
+	progressed := false
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := out.WriteAt(buf[:n], offset); err != nil {
+				return progressed, &downloadError{fmt.Errorf("failed to write file: %w", err)}
+			}
+			offset += int64(n)
+			progressed = true
+
+			lock()
+			segment.Done += int64(n)
+			report(int64(n))
+			unlock()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return progressed, readErr
+		}
+	}
+
+	lock()
+	remaining := segment.remaining()
+	unlock()
+	if segment.End >= 0 && remaining > 0 {
+		return progressed, io.ErrUnexpectedEOF
+	}
+	return progressed, nil
+}
+
+// newDownloadState splits a file of the given size into roughly equal segments
+func newDownloadState(fileID int, size int64, hash string, segments int) *downloadState {
+	if int64(segments) > size {
+		segments = int(size)
+	}
+
+	state := &downloadState{FileID: fileID, Size: size, Hash: hash}
+	segmentSize := size / int64(segments)
+	for i := 0; i < segments; i++ {
+		start := int64(i) * segmentSize
+		end := start + segmentSize - 1
+		if i == segments-1 {
+			end = size - 1
+		}
+		state.Segments = append(state.Segments, downloadSegment{Start: start, End: end})
+	}
+	return state
+}
+
+// loadDownloadState returns the saved state for a parallel download if it still matches the file
+func loadDownloadState(path string, fileID int, size int64, hash string) (*downloadState, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var state downloadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, false
+	}
+	if state.FileID != fileID || state.Size != size || state.Hash != hash {
+		return nil, false
+	}
+	return &state, true
+}
+
+func saveDownloadState(path string, state *downloadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// verifyFileHash checks a file against a hex digest. The algorithm is taken from an
+// optional "sha256:"/"md5:" prefix or, failing that, from the digest length. It
+// reports false without an error when the algorithm is not supported.
+func verifyFileHash(path, expected string) (bool, error) {
+	algorithm, digest := "", strings.ToLower(expected)
+	if i := strings.Index(digest, ":"); i >= 0 {
+		algorithm, digest = digest[:i], digest[i+1:]
+	}
+
+	var h hash.Hash
+	switch {
+	case algorithm == "sha256" || (algorithm == "" && len(digest) == 64):
+		h = sha256.New()
+	case algorithm == "md5" || (algorithm == "" && len(digest) == 32):
+		h = md5.New()
+	default:
+		logging.Warn("Cannot verify download: unsupported hash %q", expected)
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open download for verification: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return false, fmt.Errorf("failed to hash download: %w", err)
+	}
+
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return false, fmt.Errorf("downloaded file is corrupt: expected hash %s, got %s", digest, actual)
+	}
+	return true, nil
+}
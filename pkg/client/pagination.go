@@ -0,0 +1,332 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DefaultPageSize is the page size used by Paginate when none is configured
+const DefaultPageSize = 50
+
+// PageRequest describes the page a PageFetcher should retrieve.
+// Page is 1-based; Offset is the number of items already returned.
+// Cursor is set once the server has handed out a continuation token.
+type PageRequest struct {
+	Page   int
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// Page is a single page of results returned by a PageFetcher
+type Page[T any] struct {
+	Items      []T
+	Total      int
+	TotalPages int
+	HasNext    bool
+	NextCursor string
+}
+
+// PageFetcher retrieves one page of results
+type PageFetcher[T any] func(ctx context.Context, req PageRequest) (*Page[T], error)
+
+// PaginateOptions configures a Paginator
+type PaginateOptions struct {
+	PageSize int  // Items requested per page (default DefaultPageSize)
+	MaxItems int  // Stop after this many items (0 = unlimited)
+	Prefetch bool // Fetch the next page in the background while the current one is consumed
+}
+
+type pageResult[T any] struct {
+	page *Page[T]
+	err  error
+}
+
+// Paginator lazily streams every item of a paginated listing.
+//
+//	it := client.Paginate(ctx, c.MessagePages(userID), client.PaginateOptions{PageSize: 100})
+//	defer it.Close()
+//	for it.Next() {
+//		msg := it.Item()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Paginator[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	fetch  PageFetcher[T]
+	opts   PaginateOptions
+
+	req     PageRequest
+	items   []T
+	index   int
+	current T
+	count   int
+	pages   int
+	done    bool
+	closed  bool
+	err     error
+	pending chan pageResult[T]
+}
+
+// Paginate returns a Paginator that walks every page produced by fetch.
+// Pages are requested on demand; cancelling ctx stops the iteration.
+func Paginate[T any](ctx context.Context, fetch PageFetcher[T], opts PaginateOptions) *Paginator[T] {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Paginator[T]{
+		ctx:    ctx,
+		cancel: cancel,
+		fetch:  fetch,
+		opts:   opts,
+		req:    PageRequest{Page: 1, Limit: opts.PageSize},
+	}
+}
+
+// Next advances to the next item, fetching a new page when needed.
+// It returns false when the listing is exhausted or an error occurred.
+func (p *Paginator[T]) Next() bool {
+	for {
+		if p.err != nil || p.closed {
+			return false
+		}
+
+		if p.opts.MaxItems > 0 && p.count >= p.opts.MaxItems {
+			p.Close()
+			return false
+		}
+
+		if p.index < len(p.items) {
+			p.current = p.items[p.index]
+			p.index++
+			p.count++
+			return true
+		}
+
+		if p.done {
+			return false
+		}
+
+		if err := p.ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+
+		page, err := p.nextPage()
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		p.pages++
+		p.items = page.Items
+		p.index = 0
+		p.advance(page)
+	}
+}
+
+// Item returns the current item
+func (p *Paginator[T]) Item() T {
+	return p.current
+}
+
+// Err returns the first error encountered while paginating
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// PagesFetched returns the number of pages retrieved so far
+func (p *Paginator[T]) PagesFetched() int {
+	return p.pages
+}
+
+// Close stops any in-flight prefetch. It is safe to call more than once.
+func (p *Paginator[T]) Close() {
+	p.closed = true
+	p.cancel()
+}
+
+// All drains the paginator and returns every remaining item
+func (p *Paginator[T]) All() ([]T, error) {
+	defer p.Close()
+
+	var all []T
+	for p.Next() {
+		all = append(all, p.Item())
+	}
+	return all, p.Err()
+}
+
+// nextPage returns the prefetched page if one is pending, otherwise fetches synchronously
+func (p *Paginator[T]) nextPage() (*Page[T], error) {
+	if p.pending == nil {
+		return p.fetchPage(p.req)
+	}
+
+	pending := p.pending
+	p.pending = nil
+
+	select {
+	case <-p.ctx.Done():
+		return nil, p.ctx.Err()
+	case result := <-pending:
+		return result.page, result.err
+	}
+}
+
+func (p *Paginator[T]) fetchPage(req PageRequest) (*Page[T], error) {
+	page, err := p.fetch(p.ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page %d: %w", req.Page, err)
+	}
+	if page == nil {
+		page = &Page[T]{}
+	}
+	return page, nil
+}
+
+// advance computes the next request from the page just received and,
+// if prefetching is enabled, starts fetching it in the background
+func (p *Paginator[T]) advance(page *Page[T]) {
+	switch {
+	case len(page.Items) == 0:
+		p.done = true
+	case page.NextCursor != "":
+		p.req.Cursor = page.NextCursor
+	case page.HasNext || (page.TotalPages > 0 && p.req.Page < page.TotalPages):
+		p.req.Cursor = ""
+	default:
+		p.done = true
+	}
+
+	if p.done {
+		return
+	}
+
+	p.req.Page++
+	p.req.Offset += len(page.Items)
+
+	if p.opts.MaxItems > 0 && p.count+len(page.Items) >= p.opts.MaxItems {
+		return
+	}
+
+	if p.opts.Prefetch {
+		req := p.req
+		pending := make(chan pageResult[T], 1)
+		p.pending = pending
+		go func() {
+			page, err := p.fetchPage(req)
+			pending <- pageResult[T]{page: page, err: err}
+		}()
+	}
+}
+
+// withCursor appends the cursor query parameter to an endpoint when set
+func withCursor(endpoint, cursor string) string {
+	if cursor == "" {
+		return endpoint
+	}
+	return endpoint + "&cursor=" + url.QueryEscape(cursor)
+}
+
+// MessagePages returns a PageFetcher over the conversation with another user
+func (c *Client) MessagePages(otherUserID string) PageFetcher[Message] {
+	return func(ctx context.Context, req PageRequest) (*Page[Message], error) {
+		endpoint := fmt.Sprintf("/api/v1/messages/conversation/%s?limit=%d&page=%d", otherUserID, req.Limit, req.Page)
+		resp, err := c.Get(ctx, withCursor(endpoint, req.Cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		var listResp MessageListResponse
+		if err := c.ParseResponse(resp, &listResp); err != nil {
+			return nil, err
+		}
+
+		return &Page[Message]{
+			Items:      listResp.Messages,
+			Total:      listResp.Total,
+			TotalPages: listResp.TotalPages,
+			HasNext:    listResp.HasNext,
+			NextCursor: listResp.NextCursor,
+		}, nil
+	}
+}
+
+// UserPages returns a PageFetcher over all users
+func (c *Client) UserPages() PageFetcher[User] {
+	return func(ctx context.Context, req PageRequest) (*Page[User], error) {
+		endpoint := fmt.Sprintf("/api/v1/users?limit=%d&offset=%d", req.Limit, req.Offset)
+		resp, err := c.Get(ctx, withCursor(endpoint, req.Cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		var listResp UserListResponse
+		if err := c.ParseResponse(resp, &listResp); err != nil {
+			return nil, err
+		}
+
+		return &Page[User]{
+			Items:      listResp.Users,
+			Total:      listResp.Total,
+			TotalPages: listResp.TotalPages,
+			HasNext:    listResp.HasNext,
+			NextCursor: listResp.NextCursor,
+		}, nil
+	}
+}
+
+// RoomPages returns a PageFetcher over available chat rooms
+func (c *Client) RoomPages() PageFetcher[Room] {
+	return func(ctx context.Context, req PageRequest) (*Page[Room], error) {
+		endpoint := fmt.Sprintf("/api/v1/rooms?limit=%d&page=%d", req.Limit, req.Page)
+		resp, err := c.Get(ctx, withCursor(endpoint, req.Cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		var listResp RoomListResponse
+		if err := c.ParseResponse(resp, &listResp); err != nil {
+			return nil, err
+		}
+
+		return &Page[Room]{
+			Items:      listResp.Rooms,
+			Total:      listResp.Total,
+			TotalPages: listResp.TotalPages,
+			HasNext:    listResp.HasNext,
+			NextCursor: listResp.NextCursor,
+		}, nil
+	}
+}
+
+// FilePages returns a PageFetcher over uploaded files, optionally filtered by type
+func (c *Client) FilePages(fileType string) PageFetcher[File] {
+	return func(ctx context.Context, req PageRequest) (*Page[File], error) {
+		endpoint := fmt.Sprintf("/api/v1/files?limit=%d&page=%d", req.Limit, req.Page)
+		if fileType != "" {
+			endpoint += "&type=" + fileType
+		}
+		resp, err := c.Get(ctx, withCursor(endpoint, req.Cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		var listResp FileListResponse
+		if err := c.ParseResponse(resp, &listResp); err != nil {
+			return nil, err
+		}
+
+		return &Page[File]{
+			Items:      listResp.Files,
+			Total:      listResp.Total,
+			TotalPages: listResp.TotalPages,
+			HasNext:    listResp.HasNext,
+			NextCursor: listResp.NextCursor,
+		}, nil
+	}
+}
@@ -1,6 +1,10 @@
 package client
 
-import "time"
+import (
+	"time"
+
+	"plexichat-client/pkg/messaging"
+)
 
 // --- 2FA/MFA Types ---
 
@@ -132,6 +136,11 @@ type LoginResponse struct {
 	Message       string    `json:"message,omitempty"`
 }
 
+// RefreshTokenRequest exchanges a refresh token for a new access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // RegisterRequest represents a registration request
 // RegisterResponse represents a registration response
 type RegisterResponse struct {
@@ -171,6 +180,63 @@ type Message struct {
 	Timestamp time.Time `json:"timestamp"`
 	Edited    bool      `json:"edited"`
 	EditedAt  *time.Time `json:"edited_at"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	ParentID  *int      `json:"parent_id,omitempty"`
+	Reactions []messaging.Reaction `json:"reactions,omitempty"`
+	Thread    *messaging.ThreadInfo `json:"thread,omitempty"`
+}
+
+// EditMessageRequest represents a message edit request
+type EditMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// ReplyMessageRequest represents a threaded reply request
+type ReplyMessageRequest struct {
+	Content     string `json:"content"`
+	MessageType string `json:"message_type,omitempty"`
+}
+
+// ReactionRequest represents a request to add or remove a reaction
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// ReadMarkerRequest moves the current user's read position in a room
+type ReadMarkerRequest struct {
+	MessageID int `json:"message_id"`
+}
+
+// Receipt records when a user received or read a message
+type Receipt struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageReceipts lists who a message has been delivered to and who has read it
+type MessageReceipts struct {
+	MessageID   int       `json:"message_id"`
+	DeliveredTo []Receipt `json:"delivered_to"`
+	ReadBy      []Receipt `json:"read_by"`
+}
+
+// MessageDeletedEvent represents the payload of a message_delete WebSocket event
+type MessageDeletedEvent struct {
+	ID        int       `json:"id"`
+	RoomID    int       `json:"room_id"`
+	DeletedBy string    `json:"deleted_by"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ReactionEvent represents the payload of a reaction WebSocket event
+type ReactionEvent struct {
+	MessageID int    `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Action    string `json:"action"` // "add" or "remove"
+	Count     int    `json:"count"`
 }
 
 // SendMessageRequest represents a message sending request
@@ -191,6 +257,42 @@ type Room struct {
 	Created     string `json:"created"`
 }
 
+// CreateRoomRequest represents a room creation request
+type CreateRoomRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	IsPrivate   bool   `json:"is_private"`
+	MemberIDs   []int  `json:"member_ids,omitempty"`
+}
+
+// UpdateRoomRequest represents a room update request. Nil fields are left unchanged.
+type UpdateRoomRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	IsPrivate   *bool   `json:"is_private,omitempty"`
+}
+
+// InviteRoomRequest represents a request to invite users to a room
+type InviteRoomRequest struct {
+	UserIDs []int `json:"user_ids"`
+}
+
+// RoomMember represents a member of a chat room
+type RoomMember struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+	IsOnline bool   `json:"is_online"`
+}
+
+// RoomActionResponse represents the response to a join, leave or invite request
+type RoomActionResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	RoomID  int    `json:"room_id"`
+}
+
 // File represents an uploaded file
 type File struct {
 	ID       int    `json:"id"`
@@ -200,6 +302,27 @@ type File struct {
 	UserID   int    `json:"user_id"`
 	Uploaded string `json:"uploaded"`
 	URL      string `json:"url"`
+	Hash     string `json:"hash,omitempty"`     // MD5 or SHA-256, hex encoded
+	Checksum string `json:"checksum,omitempty"` // SHA-256, hex encoded
+}
+
+// UploadSessionRequest starts a chunked upload session
+type UploadSessionRequest struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ChunkSize   int64  `json:"chunk_size"`
+	TotalChunks int    `json:"total_chunks"`
+	Checksum    string `json:"checksum"` // SHA-256 of the whole file
+	MimeType    string `json:"mime_type,omitempty"`
+}
+
+// UploadSession represents the server-side state of a chunked upload
+type UploadSession struct {
+	UploadID       string `json:"upload_id"`
+	ChunkSize      int64  `json:"chunk_size"`
+	TotalChunks    int    `json:"total_chunks"`
+	ReceivedChunks []int  `json:"received_chunks"`
+	ExpiresAt      string `json:"expires_at,omitempty"`
 }
 
 // SecurityTestRequest represents a security test request
@@ -313,6 +436,7 @@ type ListResponse struct {
 	TotalPages int         `json:"total_pages"`
 	HasNext    bool        `json:"has_next"`
 	HasPrev    bool        `json:"has_prev"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 // MessageListResponse represents a paginated message list
@@ -324,6 +448,7 @@ type MessageListResponse struct {
 	TotalPages int       `json:"total_pages"`
 	HasNext    bool      `json:"has_next"`
 	HasPrev    bool      `json:"has_prev"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 // RoomListResponse represents a paginated room list
@@ -335,6 +460,19 @@ type RoomListResponse struct {
 	TotalPages int    `json:"total_pages"`
 	HasNext    bool   `json:"has_next"`
 	HasPrev    bool   `json:"has_prev"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// RoomMemberListResponse represents a paginated room member list
+type RoomMemberListResponse struct {
+	Members    []RoomMember `json:"items"`
+	Total      int          `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+	HasNext    bool         `json:"has_next"`
+	HasPrev    bool         `json:"has_prev"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 // FileListResponse represents a paginated file list
@@ -346,6 +484,7 @@ type FileListResponse struct {
 	TotalPages int    `json:"total_pages"`
 	HasNext    bool   `json:"has_next"`
 	HasPrev    bool   `json:"has_prev"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // UserListResponse represents a paginated user list
@@ -357,6 +496,7 @@ type UserListResponse struct {
 	TotalPages int    `json:"total_pages"`
 	HasNext    bool   `json:"has_next"`
 	HasPrev    bool   `json:"has_prev"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 
@@ -0,0 +1,469 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"plexichat-client/pkg/files"
+	"plexichat-client/pkg/logging"
+)
+
+// errChunkRetriesExhausted marks a chunk that kept failing with network or server errors
+var errChunkRetriesExhausted = errors.New("chunk retries exhausted")
+
+// IsResumableUploadError reports whether a failed chunked upload can be continued
+// with Resume: it was interrupted, or its chunks kept hitting network or server
+// errors. Rejected requests and local failures need fixing before a retry.
+func IsResumableUploadError(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, errChunkRetriesExhausted)
+}
+
+// ChunkedUploadOptions configures a resumable chunked upload
+type ChunkedUploadOptions struct {
+	ChunkSize   int64  // Bytes per chunk
+	Concurrency int    // Chunks uploaded in parallel
+	ResumeDir   string // Directory holding resume tokens ("" disables persistence)
+	Resume      bool   // Continue from a saved resume token when one matches the file
+	OnProgress  func(progress files.UploadProgress)
+}
+
+// UploadOptionsFromConfig derives chunked upload options from file manager settings
+func UploadOptionsFromConfig(config *files.FileManagerConfig) *ChunkedUploadOptions {
+	if config == nil {
+		config = files.DefaultFileManagerConfig()
+	}
+
+	return &ChunkedUploadOptions{
+		ChunkSize:   config.ChunkSize,
+		Concurrency: config.ConcurrentUploads,
+	}
+}
+
+// UploadResumeToken is persisted on disk so an interrupted upload can be continued
+type UploadResumeToken struct {
+	UploadID    string    `json:"upload_id"`
+	FilePath    string    `json:"file_path"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	ChunkSize   int64     `json:"chunk_size"`
+	TotalChunks int       `json:"total_chunks"`
+	Checksum    string    `json:"checksum"`
+	Completed   []int     `json:"completed"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// matches reports whether the token still describes the file on disk
+func (t *UploadResumeToken) matches(path string, info os.FileInfo, chunkSize int64) bool {
+	return t.FilePath == path &&
+		t.Size == info.Size() &&
+		t.ModTime.Equal(info.ModTime()) &&
+		t.ChunkSize == chunkSize
+}
+
+// uploadTokenPath returns the resume token location for a file
+func uploadTokenPath(dir, absPath string) string {
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".upload.json")
+}
+
+func loadUploadToken(path string) (*UploadResumeToken, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var token UploadResumeToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse resume token: %w", err)
+	}
+	return &token, nil
+}
+
+func saveUploadToken(path string, token *UploadResumeToken) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create resume directory: %w", err)
+	}
+
+	token.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so a crash mid-write never leaves a corrupt token
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write resume token: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// UploadFileChunked uploads a file in checksummed chunks. When opts.ResumeDir is set,
+// progress is recorded in a resume token so a later call with opts.Resume continues
+// where the previous attempt stopped instead of starting over.
+func (c *Client) UploadFileChunked(ctx context.Context, filePath string, opts *ChunkedUploadOptions) (*File, error) {
+	if opts == nil {
+		opts = UploadOptionsFromConfig(nil)
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = files.DefaultFileManagerConfig().ChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file path: %w", err)
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	tokenPath := uploadTokenPath(opts.ResumeDir, absPath)
+	token := c.resumeUploadToken(ctx, tokenPath, absPath, info, chunkSize, opts.Resume)
+
+	if token == nil {
+		checksum, err := fileChecksum(file)
+		if err != nil {
+			return nil, err
+		}
+
+		sessionReq := &UploadSessionRequest{
+			Filename:    filepath.Base(absPath),
+			Size:        info.Size(),
+			ChunkSize:   chunkSize,
+			TotalChunks: chunkCount(info.Size(), chunkSize),
+			Checksum:    checksum,
+			MimeType:    mime.TypeByExtension(filepath.Ext(absPath)),
+		}
+
+		session, err := c.CreateUploadSession(ctx, sessionReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start upload: %w", err)
+		}
+
+		token = &UploadResumeToken{
+			UploadID:    session.UploadID,
+			FilePath:    absPath,
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+			ChunkSize:   chunkSize,
+			TotalChunks: sessionReq.TotalChunks,
+			Checksum:    checksum,
+			Completed:   session.ReceivedChunks,
+		}
+	}
+
+	if err := saveUploadToken(tokenPath, token); err != nil {
+		logging.Warn("Upload will not be resumable: %v", err)
+		tokenPath = ""
+	}
+
+	if err := c.uploadChunks(ctx, file, token, tokenPath, concurrency, opts.OnProgress); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Post(ctx, fmt.Sprintf("/api/v1/files/uploads/%s/complete", token.UploadID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	var uploaded File
+	if err := c.ParseResponse(resp, &uploaded); err != nil {
+		return nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	if tokenPath != "" {
+		os.Remove(tokenPath)
+	}
+
+	return &uploaded, nil
+}
+
+// resumeUploadToken loads a saved token and reconciles it with the server's view of
+// the session. It returns nil when there is nothing usable to resume.
+func (c *Client) resumeUploadToken(ctx context.Context, tokenPath, absPath string, info os.FileInfo, chunkSize int64, resume bool) *UploadResumeToken {
+	if !resume || tokenPath == "" {
+		return nil
+	}
+
+	token, err := loadUploadToken(tokenPath)
+	if err != nil {
+		return nil
+	}
+
+	if !token.matches(absPath, info, chunkSize) {
+		logging.Info("File changed since the interrupted upload; starting over")
+		return nil
+	}
+
+	session, err := c.GetUploadSession(ctx, token.UploadID)
+	if err != nil {
+		logging.Info("Upload session %s is no longer available; starting over: %v", token.UploadID, err)
+		return nil
+	}
+
+	// The server is authoritative: a chunk we recorded may not have been persisted
+	token.Completed = session.ReceivedChunks
+	return token
+}
+
+// CreateUploadSession starts a new chunked upload session
+func (c *Client) CreateUploadSession(ctx context.Context, req *UploadSessionRequest) (*UploadSession, error) {
+	resp, err := c.Post(ctx, "/api/v1/files/uploads", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var session UploadSession
+	err = c.ParseResponse(resp, &session)
+	return &session, err
+}
+
+// GetUploadSession returns the server-side state of a chunked upload
+func (c *Client) GetUploadSession(ctx context.Context, uploadID string) (*UploadSession, error) {
+	resp, err := c.Get(ctx, fmt.Sprintf("/api/v1/files/uploads/%s", uploadID))
+	if err != nil {
+		return nil, err
+	}
+
+	var session UploadSession
+	err = c.ParseResponse(resp, &session)
+	return &session, err
+}
+
+// uploadChunks sends every chunk not yet recorded as completed using a pool of workers
+func (c *Client) uploadChunks(ctx context.Context, file *os.File, token *UploadResumeToken, tokenPath string, concurrency int, onProgress func(files.UploadProgress)) error {
+	done := make(map[int]bool, len(token.Completed))
+	for _, index := range token.Completed {
+		done[index] = true
+	}
+
+	var pending []int
+	var uploaded int64
+	for index := 0; index < token.TotalChunks; index++ {
+		if done[index] {
+			uploaded += chunkLength(token.Size, token.ChunkSize, index)
+		} else {
+			pending = append(pending, index)
+		}
+	}
+
+	progress := files.UploadProgress{
+		FileID:        token.UploadID,
+		BytesUploaded: uploaded,
+		TotalBytes:    token.Size,
+		StartTime:     time.Now(),
+	}
+	resumedBytes := uploaded
+
+	var mu sync.Mutex
+	report := func(delta int64) {
+		progress.BytesUploaded += delta
+		now := time.Now()
+		progress.LastUpdate = now
+		if progress.TotalBytes > 0 {
+			progress.Percentage = float64(progress.BytesUploaded) / float64(progress.TotalBytes) * 100
+		} else {
+			progress.Percentage = 100
+		}
+		if elapsed := now.Sub(progress.StartTime).Seconds(); elapsed > 0 {
+			progress.Speed = int64(float64(progress.BytesUploaded-resumedBytes) / elapsed)
+			if progress.Speed > 0 {
+				remaining := progress.TotalBytes - progress.BytesUploaded
+				progress.ETA = time.Duration(float64(remaining) / float64(progress.Speed) * float64(time.Second))
+			}
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	mu.Lock()
+	report(0)
+	mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var firstErr error
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, token.ChunkSize)
+			for index := range jobs {
+				n, err := c.uploadChunk(ctx, file, token, index, buf)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				token.Completed = append(token.Completed, index)
+				sort.Ints(token.Completed)
+				if saveErr := saveUploadToken(tokenPath, token); saveErr != nil {
+					logging.Warn("Failed to update resume token: %v", saveErr)
+				}
+				report(n)
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, index := range pending {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// uploadChunk sends a single chunk, retrying transient failures and checksum mismatches
+func (c *Client) uploadChunk(ctx context.Context, file *os.File, token *UploadResumeToken, index int, buf []byte) (int64, error) {
+	offset := int64(index) * token.ChunkSize
+	length := chunkLength(token.Size, token.ChunkSize, index)
+
+	n, err := file.ReadAt(buf[:length], offset)
+	if err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to read chunk %d: %w", index, err)
+	}
+	chunk := buf[:n]
+	sum := sha256.Sum256(chunk)
+	checksum := hex.EncodeToString(sum[:])
+
+	endpoint := fmt.Sprintf("/api/v1/files/uploads/%s/chunks/%d", token.UploadID, index)
+	maxRetries := c.RetryConfig.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = c.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(c.calculateRetryDelay(attempt - 1)):
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		req, err := c.newRawRequest(ctx, "PUT", endpoint, "application/octet-stream", chunk)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("X-Chunk-Checksum", "sha256="+checksum)
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(n)-1, token.Size))
+
+		resp, err := c.doWithRefresh(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		switch {
+		case resp.StatusCode < 300:
+			resp.Body.Close()
+			return int64(n), nil
+		case resp.StatusCode >= 500, resp.StatusCode == http.StatusConflict, resp.StatusCode == http.StatusUnprocessableEntity:
+			// Server error or the server saw a corrupted chunk; send it again
+			resp.Body.Close()
+			lastErr = fmt.Errorf("chunk %d rejected with status %d", index, resp.StatusCode)
+			if c.Debug {
+				logging.Debug("Chunk %d failed (attempt %d/%d): status %d", index, attempt+1, maxRetries+1, resp.StatusCode)
+			}
+		default:
+			return 0, fmt.Errorf("failed to upload chunk %d: %w", index, c.ParseResponse(resp, nil))
+		}
+	}
+
+	return 0, fmt.Errorf("failed to upload chunk %d after %d attempts: %w (%w)", index, maxRetries+1, lastErr, errChunkRetriesExhausted)
+}
+
+// newRawRequest builds an authenticated request with a non-JSON body (or none)
+func (c *Client) newRawRequest(ctx context.Context, method, endpoint, contentType string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	c.setAuthHeaders(req.Header)
+
+	return req, nil
+}
+
+// fileChecksum returns the hex SHA-256 of the whole file
+func fileChecksum(file *os.File) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, 1<<62)); err != nil {
+		return "", fmt.Errorf("failed to checksum file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func chunkCount(size, chunkSize int64) int {
+	return int((size + chunkSize - 1) / chunkSize)
+}
+
+func chunkLength(size, chunkSize int64, index int) int64 {
+	offset := int64(index) * chunkSize
+	if remaining := size - offset; remaining < chunkSize {
+		return remaining
+	}
+	return chunkSize
+}
@@ -39,6 +39,9 @@ type Config struct {
 	// File upload settings
 	FileUpload FileUploadConfig `yaml:"file_upload" json:"file_upload"`
 
+	// WebSocket hub settings
+	Hub HubConfig `yaml:"hub" json:"hub"`
+
 	mu sync.RWMutex
 }
 
@@ -80,6 +83,9 @@ type DatabaseConfig struct {
 	BackupRetention int           `yaml:"backup_retention" json:"backup_retention"`
 	VacuumEnabled   bool          `yaml:"vacuum_enabled" json:"vacuum_enabled"`
 	VacuumInterval  time.Duration `yaml:"vacuum_interval" json:"vacuum_interval"`
+
+	// RevisionRetention is how long message edit history is kept; 0 keeps it forever
+	RevisionRetention time.Duration `yaml:"revision_retention" json:"revision_retention"`
 }
 
 // SecurityConfig contains security settings
@@ -242,6 +248,48 @@ type FileUploadConfig struct {
 	RetentionPeriod time.Duration `yaml:"retention_period" json:"retention_period"`
 }
 
+// HubConfig contains WebSocket hub settings
+type HubConfig struct {
+	RateLimits HubRateLimitConfig `yaml:"rate_limits" json:"rate_limits"`
+}
+
+// HubRateLimitConfig limits how fast each user may send frames to the hub
+type HubRateLimitConfig struct {
+	Enabled         bool                     `yaml:"enabled" json:"enabled"`
+	User            RateLimitRule            `yaml:"user" json:"user"`                         // Shared by all of a user's frames
+	Default         RateLimitRule            `yaml:"default" json:"default"`                   // Message types without their own rule
+	MessageTypes    map[string]RateLimitRule `yaml:"message_types" json:"message_types"`       // Keyed by message type, e.g. "typing"
+	MaxViolations   int                      `yaml:"max_violations" json:"max_violations"`     // Throttled frames per ViolationWindow before disconnecting; 0 never disconnects
+	ViolationWindow time.Duration            `yaml:"violation_window" json:"violation_window"` // Window MaxViolations is counted over
+}
+
+// RateLimitRule is a token bucket, optionally capped by a sliding window.
+// A zero Rate and Limit means unlimited.
+type RateLimitRule struct {
+	Rate   float64       `yaml:"rate" json:"rate"`   // Sustained frames per second
+	Burst  int           `yaml:"burst" json:"burst"` // Frames allowed at once
+	Limit  int           `yaml:"limit" json:"limit"` // Frames per Window
+	Window time.Duration `yaml:"window" json:"window"`
+}
+
+// DefaultHubConfig returns the default WebSocket hub settings
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		RateLimits: HubRateLimitConfig{
+			Enabled: true,
+			User:    RateLimitRule{Rate: 10, Burst: 50},
+			Default: RateLimitRule{Rate: 1, Burst: 10, Limit: 60, Window: time.Minute},
+			MessageTypes: map[string]RateLimitRule{
+				"typing":    {Rate: 2, Burst: 5},
+				"delivered": {Rate: 20, Burst: 100},
+				"read":      {Rate: 5, Burst: 20},
+			},
+			MaxViolations:   10,
+			ViolationWindow: time.Minute,
+		},
+	}
+}
+
 // DefaultConfig returns a configuration with default values
 func DefaultConfig() *Config {
 	return &Config{
@@ -277,6 +325,8 @@ func DefaultConfig() *Config {
 			BackupRetention: 7,
 			VacuumEnabled:   true,
 			VacuumInterval:  7 * 24 * time.Hour,
+
+			RevisionRetention: 90 * 24 * time.Hour,
 		},
 		Security: SecurityConfig{
 			EncryptionEnabled: true,
@@ -379,6 +429,7 @@ func DefaultConfig() *Config {
 			CleanupInterval: 24 * time.Hour,
 			RetentionPeriod: 30 * 24 * time.Hour,
 		},
+		Hub: DefaultHubConfig(),
 	}
 }
 
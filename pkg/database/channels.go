@@ -3,15 +3,32 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
-// SaveChannel saves or updates a channel in the database
+// ErrChannelNotFound is returned when a channel lookup matches nothing
+var ErrChannelNotFound = errors.New("channel not found")
+
+// SaveChannel saves or updates a channel in the database. A new channel's
+// creator becomes its owner.
 func (d *Database) SaveChannel(ctx context.Context, channel *Channel) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM channels WHERE id = ?)", channel.ID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check channel: %w", err)
+	}
+
 	query := `
 		INSERT INTO channels (id, name, description, type, private, created_by, created_at, metadata)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
@@ -24,13 +41,29 @@ func (d *Database) SaveChannel(ctx context.Context, channel *Channel) error {
 			updated_at = CURRENT_TIMESTAMP
 	`
 
-	_, err := d.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		channel.ID, channel.Name, channel.Description, channel.Type,
 		channel.Private, channel.CreatedBy, channel.CreatedAt, channel.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to save channel: %w", err)
 	}
 
+	// Updates leave membership alone, so a creator who left stays gone
+	if !exists {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO channel_members (channel_id, user_id, role)
+			VALUES (?, ?, ?)
+			ON CONFLICT(channel_id, user_id) DO NOTHING
+		`, channel.ID, channel.CreatedBy, RoleOwner)
+		if err != nil {
+			return fmt.Errorf("failed to add channel owner: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -52,7 +85,7 @@ func (d *Database) GetChannel(ctx context.Context, channelID string) (*Channel,
 		&channel.UpdatedAt, &channel.LastMessage, &channel.Metadata)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("channel not found: %s", channelID)
+			return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, channelID)
 		}
 		return nil, fmt.Errorf("failed to get channel: %w", err)
 	}
@@ -78,7 +111,7 @@ func (d *Database) GetChannelByName(ctx context.Context, name string) (*Channel,
 		&channel.UpdatedAt, &channel.LastMessage, &channel.Metadata)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("channel not found: %s", name)
+			return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, name)
 		}
 		return nil, fmt.Errorf("failed to get channel: %w", err)
 	}
@@ -156,17 +189,18 @@ func (d *Database) GetPublicChannels(ctx context.Context) ([]*Channel, error) {
 	return channels, rows.Err()
 }
 
-// GetUserChannels retrieves channels created by a specific user
+// GetUserChannels retrieves the channels a user is a member of
 func (d *Database) GetUserChannels(ctx context.Context, userID string) ([]*Channel, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	query := `
-		SELECT id, name, description, type, private, created_by, 
-			   created_at, updated_at, last_message, metadata
-		FROM channels 
-		WHERE created_by = ?
-		ORDER BY created_at DESC
+		SELECT c.id, c.name, c.description, c.type, c.private, c.created_by,
+			   c.created_at, c.updated_at, c.last_message, c.metadata
+		FROM channels c
+		JOIN channel_members cm ON cm.channel_id = c.id
+		WHERE cm.user_id = ?
+		ORDER BY c.created_at DESC
 	`
 
 	rows, err := d.db.QueryContext(ctx, query, userID)
@@ -191,6 +225,22 @@ func (d *Database) GetUserChannels(ctx context.Context, userID string) ([]*Chann
 	return channels, rows.Err()
 }
 
+// IsChannelMember reports whether a user belongs to a channel
+func (d *Database) IsChannelMember(ctx context.Context, channelID, userID string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var count int
+	err := d.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_id = ?",
+		channelID, userID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check channel membership: %w", err)
+	}
+
+	return count > 0, nil
+}
+
 // SearchChannels searches for channels by name or description
 func (d *Database) SearchChannels(ctx context.Context, searchText string, limit int) ([]*Channel, error) {
 	d.mu.RLock()
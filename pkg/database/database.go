@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,12 +15,16 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrMessageNotFound is returned when a message lookup matches nothing
+var ErrMessageNotFound = errors.New("message not found")
+
 // Database represents the local SQLite database
 type Database struct {
 	db     *sql.DB
 	logger *logging.Logger
 	mu     sync.RWMutex
 	path   string
+	fts    bool // Whether the FTS5 search index is available
 }
 
 // Message represents a chat message in the database
@@ -35,6 +40,7 @@ type Message struct {
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 	Metadata    string     `json:"metadata" db:"metadata"`
 	Attachments string     `json:"attachments" db:"attachments"`
+	ParentID    *int64     `json:"parent_id,omitempty" db:"parent_id"` // Thread root this message replies to
 }
 
 // User represents a user in the database
@@ -92,8 +98,29 @@ type Session struct {
 	UserAgent string    `json:"user_agent" db:"user_agent"`
 }
 
-// NewDatabase creates a new database instance
+// NewDatabase opens a database and migrates its schema to the latest version
 func NewDatabase(dbPath string) (*Database, error) {
+	database, err := OpenDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.MigrateUp(context.Background(), 0); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	if err := database.initSearch(); err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	return database, nil
+}
+
+// OpenDatabase opens a database without migrating it, for inspecting or
+// migrating the schema by hand
+func OpenDatabase(dbPath string) (*Database, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -111,127 +138,11 @@ func NewDatabase(dbPath string) (*Database, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	database := &Database{
+	return &Database{
 		db:     db,
 		logger: logging.NewLogger(logging.INFO, nil, true),
 		path:   dbPath,
-	}
-
-	// Initialize database schema
-	if err := database.initSchema(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return database, nil
-}
-
-// initSchema creates the database tables
-func (d *Database) initSchema() error {
-	schema := `
-	-- Users table
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		username TEXT UNIQUE NOT NULL,
-		display_name TEXT NOT NULL,
-		email TEXT,
-		avatar TEXT,
-		status TEXT DEFAULT 'offline',
-		last_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		metadata TEXT DEFAULT '{}'
-	);
-
-	-- Channels table
-	CREATE TABLE IF NOT EXISTS channels (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		description TEXT,
-		type TEXT DEFAULT 'text',
-		private BOOLEAN DEFAULT FALSE,
-		created_by TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_message DATETIME,
-		metadata TEXT DEFAULT '{}',
-		FOREIGN KEY (created_by) REFERENCES users(id)
-	);
-
-	-- Messages table
-	CREATE TABLE IF NOT EXISTS messages (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		channel_id TEXT NOT NULL,
-		user_id TEXT NOT NULL,
-		username TEXT NOT NULL,
-		content TEXT NOT NULL,
-		message_type TEXT DEFAULT 'text',
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		edited_at DATETIME,
-		deleted_at DATETIME,
-		metadata TEXT DEFAULT '{}',
-		attachments TEXT DEFAULT '[]',
-		FOREIGN KEY (channel_id) REFERENCES channels(id),
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	-- Files table
-	CREATE TABLE IF NOT EXISTS files (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		path TEXT NOT NULL,
-		size INTEGER NOT NULL,
-		mime_type TEXT NOT NULL,
-		hash TEXT NOT NULL,
-		uploaded_by TEXT NOT NULL,
-		channel_id TEXT,
-		message_id INTEGER,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		metadata TEXT DEFAULT '{}',
-		FOREIGN KEY (uploaded_by) REFERENCES users(id),
-		FOREIGN KEY (channel_id) REFERENCES channels(id),
-		FOREIGN KEY (message_id) REFERENCES messages(id)
-	);
-
-	-- Sessions table
-	CREATE TABLE IF NOT EXISTS sessions (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		token TEXT NOT NULL,
-		expires_at DATETIME NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_used DATETIME DEFAULT CURRENT_TIMESTAMP,
-		ip_address TEXT,
-		user_agent TEXT,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	-- Indexes for performance
-	CREATE INDEX IF NOT EXISTS idx_messages_channel_timestamp ON messages(channel_id, timestamp);
-	CREATE INDEX IF NOT EXISTS idx_messages_user_timestamp ON messages(user_id, timestamp);
-	CREATE INDEX IF NOT EXISTS idx_files_channel ON files(channel_id);
-	CREATE INDEX IF NOT EXISTS idx_files_user ON files(uploaded_by);
-	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
-	CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
-	CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
-	CREATE INDEX IF NOT EXISTS idx_channels_name ON channels(name);
-
-	-- Triggers for updated_at
-	CREATE TRIGGER IF NOT EXISTS update_users_timestamp 
-		AFTER UPDATE ON users
-		BEGIN
-			UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-		END;
-
-	CREATE TRIGGER IF NOT EXISTS update_channels_timestamp 
-		AFTER UPDATE ON channels
-		BEGIN
-			UPDATE channels SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-		END;
-	`
-
-	_, err := d.db.Exec(schema)
-	return err
+	}, nil
 }
 
 // Close closes the database connection
@@ -281,19 +192,27 @@ func (d *Database) GetStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
-// SaveMessage saves a message to the database
+// SaveMessage saves a message to the database. A message with an ID, such as
+// one received from the server, is stored under that ID; otherwise it gets the
+// next free one. Either way msg.ID is set to the stored ID.
 func (d *Database) SaveMessage(ctx context.Context, msg *Message) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO messages (channel_id, user_id, username, content, message_type, timestamp, metadata, attachments)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO messages (id, channel_id, user_id, username, content, message_type, timestamp, metadata, attachments, parent_id)
+		VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := d.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query, msg.ID,
 		msg.ChannelID, msg.UserID, msg.Username, msg.Content,
-		msg.MessageType, msg.Timestamp, msg.Metadata, msg.Attachments)
+		msg.MessageType, msg.Timestamp, msg.Metadata, msg.Attachments, msg.ParentID)
 	if err != nil {
 		return fmt.Errorf("failed to save message: %w", err)
 	}
@@ -302,15 +221,23 @@ func (d *Database) SaveMessage(ctx context.Context, msg *Message) error {
 	if err != nil {
 		return fmt.Errorf("failed to get message ID: %w", err)
 	}
-	msg.ID = id
+
+	if err := saveMentions(ctx, tx, id, msg.Content); err != nil {
+		return err
+	}
 
 	// Update channel last message time
-	_, err = d.db.ExecContext(ctx, "UPDATE channels SET last_message = ? WHERE id = ?",
+	_, err = tx.ExecContext(ctx, "UPDATE channels SET last_message = ? WHERE id = ?",
 		msg.Timestamp, msg.ChannelID)
 	if err != nil {
 		d.logger.Error("Failed to update channel last message: %v", err)
 	}
 
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	msg.ID = id
+
 	return nil
 }
 
@@ -321,7 +248,7 @@ func (d *Database) GetMessages(ctx context.Context, channelID string, limit, off
 
 	query := `
 		SELECT id, channel_id, user_id, username, content, message_type,
-			   timestamp, edited_at, deleted_at, metadata, attachments
+			   timestamp, edited_at, deleted_at, metadata, attachments, parent_id
 		FROM messages
 		WHERE channel_id = ? AND deleted_at IS NULL
 		ORDER BY timestamp DESC
@@ -339,7 +266,7 @@ func (d *Database) GetMessages(ctx context.Context, channelID string, limit, off
 		msg := &Message{}
 		err := rows.Scan(&msg.ID, &msg.ChannelID, &msg.UserID, &msg.Username,
 			&msg.Content, &msg.MessageType, &msg.Timestamp, &msg.EditedAt,
-			&msg.DeletedAt, &msg.Metadata, &msg.Attachments)
+			&msg.DeletedAt, &msg.Metadata, &msg.Attachments, &msg.ParentID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
@@ -349,65 +276,63 @@ func (d *Database) GetMessages(ctx context.Context, channelID string, limit, off
 	return messages, rows.Err()
 }
 
-// UpdateMessage updates an existing message
-func (d *Database) UpdateMessage(ctx context.Context, messageID int64, content string) error {
+// UpdateMessage updates an existing message, recording its previous content as
+// a revision by editorID
+func (d *Database) UpdateMessage(ctx context.Context, messageID int64, editorID, content string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveRevision(ctx, tx, messageID, RevisionEdit, editorID); err != nil {
+		return err
+	}
+
 	query := `UPDATE messages SET content = ?, edited_at = CURRENT_TIMESTAMP WHERE id = ?`
-	_, err := d.db.ExecContext(ctx, query, content, messageID)
+	_, err = tx.ExecContext(ctx, query, content, messageID)
 	if err != nil {
 		return fmt.Errorf("failed to update message: %w", err)
 	}
 
+	if err := saveMentions(ctx, tx, messageID, content); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-// DeleteMessage soft deletes a message
-func (d *Database) DeleteMessage(ctx context.Context, messageID int64) error {
+// DeleteMessage soft deletes a message, recording its content as a revision by editorID
+func (d *Database) DeleteMessage(ctx context.Context, messageID int64, editorID string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	query := `UPDATE messages SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`
-	_, err := d.db.ExecContext(ctx, query, messageID)
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete message: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return nil
-}
-
-// SearchMessages searches for messages containing the given text
-func (d *Database) SearchMessages(ctx context.Context, searchText string, limit int) ([]*Message, error) {
-	d.mu.RLock()
-	defer d.mu.RUnlock()
-
-	query := `
-		SELECT id, channel_id, user_id, username, content, message_type,
-			   timestamp, edited_at, deleted_at, metadata, attachments
-		FROM messages
-		WHERE content LIKE ? AND deleted_at IS NULL
-		ORDER BY timestamp DESC
-		LIMIT ?
-	`
+	if err := saveRevision(ctx, tx, messageID, RevisionDelete, editorID); err != nil {
+		return err
+	}
 
-	rows, err := d.db.QueryContext(ctx, query, "%"+searchText+"%", limit)
+	query := `UPDATE messages SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err = tx.ExecContext(ctx, query, messageID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search messages: %w", err)
+		return fmt.Errorf("failed to delete message: %w", err)
 	}
-	defer rows.Close()
 
-	var messages []*Message
-	for rows.Next() {
-		msg := &Message{}
-		err := rows.Scan(&msg.ID, &msg.ChannelID, &msg.UserID, &msg.Username,
-			&msg.Content, &msg.MessageType, &msg.Timestamp, &msg.EditedAt,
-			&msg.DeletedAt, &msg.Metadata, &msg.Attachments)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan message: %w", err)
-		}
-		messages = append(messages, msg)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return messages, rows.Err()
+	return nil
 }
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestDatabase creates a migrated database in a temporary directory
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// saveTestUser stores a user with the given ID and username
+func saveTestUser(t *testing.T, db *Database, id, username string) {
+	t.Helper()
+	user := &User{ID: id, Username: username, DisplayName: username, Metadata: "{}"}
+	if err := db.SaveUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to save user %s: %v", id, err)
+	}
+}
+
+// saveTestChannel stores a channel created by createdBy
+func saveTestChannel(t *testing.T, db *Database, id, createdBy string) {
+	t.Helper()
+	channel := &Channel{ID: id, Name: id, Type: "text", CreatedBy: createdBy, CreatedAt: time.Now(), Metadata: "{}"}
+	if err := db.SaveChannel(context.Background(), channel); err != nil {
+		t.Fatalf("Failed to save channel %s: %v", id, err)
+	}
+}
+
+// saveTestMessage stores a message and returns it with its ID set
+func saveTestMessage(t *testing.T, db *Database, channelID, userID, username, content string) *Message {
+	t.Helper()
+	msg := &Message{
+		ChannelID:   channelID,
+		UserID:      userID,
+		Username:    username,
+		Content:     content,
+		MessageType: "text",
+		Timestamp:   time.Now(),
+		Metadata:    "{}",
+		Attachments: "[]",
+	}
+	if err := db.SaveMessage(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to save message %q: %v", content, err)
+	}
+	return msg
+}
+
+func TestDatabase_SaveMessage(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+
+	// A server message keeps its ID, and local ones continue after it
+	received := &Message{ID: 100, ChannelID: "general", UserID: "1", Username: "alice", Content: "from the server"}
+	if err := db.SaveMessage(ctx, received); err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+	if received.ID != 100 {
+		t.Errorf("Expected the server ID 100 to be kept, got %d", received.ID)
+	}
+
+	local := saveTestMessage(t, db, "general", "1", "alice", "local")
+	if local.ID != 101 {
+		t.Errorf("Expected the next ID 101, got %d", local.ID)
+	}
+
+	duplicate := &Message{ID: 100, ChannelID: "general", UserID: "1", Username: "alice", Content: "again"}
+	if err := db.SaveMessage(ctx, duplicate); err == nil {
+		t.Error("Expected saving a taken ID to fail")
+	}
+
+	messages, err := db.GetMessages(ctx, "general", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(messages))
+	}
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MemberRole is what a member may do in a channel
+type MemberRole string
+
+const (
+	RoleOwner     MemberRole = "owner"     // Manages roles and can do anything a moderator can
+	RoleModerator MemberRole = "moderator" // Adds, removes and mutes members
+	RoleMember    MemberRole = "member"
+)
+
+var (
+	// ErrMemberNotFound is returned when a user is not a member of a channel
+	ErrMemberNotFound = errors.New("channel member not found")
+	// ErrLastOwner is returned when a change would leave a channel without an owner
+	ErrLastOwner = errors.New("channel must keep at least one owner")
+)
+
+// ChannelMember is a user's membership in a channel
+type ChannelMember struct {
+	ChannelID string     `json:"channel_id" db:"channel_id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	Role      MemberRole `json:"role" db:"role"`
+	JoinedAt  time.Time  `json:"joined_at" db:"joined_at"`
+	Muted     bool       `json:"muted" db:"muted"` // Muted members may read but not post
+}
+
+// ParseMemberRole checks a role name
+func ParseMemberRole(role string) (MemberRole, error) {
+	switch r := MemberRole(role); r {
+	case RoleOwner, RoleModerator, RoleMember:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid role %q: must be owner, moderator or member", role)
+}
+
+// AtLeast reports whether r ranks at or above other
+func (r MemberRole) AtLeast(other MemberRole) bool {
+	return r.rank() >= other.rank()
+}
+
+func (r MemberRole) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleModerator:
+		return 2
+	case RoleMember:
+		return 1
+	}
+	return 0
+}
+
+// AddMember adds a user to a channel. Adding an existing member leaves their
+// role and join time unchanged.
+func (d *Database) AddMember(ctx context.Context, channelID, userID string, role MemberRole) error {
+	if _, err := ParseMemberRole(string(role)); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	query := `
+		INSERT INTO channel_members (channel_id, user_id, role)
+		VALUES (?, ?, ?)
+		ON CONFLICT(channel_id, user_id) DO NOTHING
+	`
+
+	_, err := d.db.ExecContext(ctx, query, channelID, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to add channel member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a user from a channel
+func (d *Database) RemoveMember(ctx context.Context, channelID, userID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.changeMember(ctx, channelID, userID, true,
+		"DELETE FROM channel_members WHERE channel_id = ? AND user_id = ?",
+		channelID, userID)
+}
+
+// SetMemberRole changes a member's role
+func (d *Database) SetMemberRole(ctx context.Context, channelID, userID string, role MemberRole) error {
+	if _, err := ParseMemberRole(string(role)); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.changeMember(ctx, channelID, userID, role != RoleOwner,
+		"UPDATE channel_members SET role = ? WHERE channel_id = ? AND user_id = ?",
+		role, channelID, userID)
+}
+
+// SetMemberMuted mutes or unmutes a member
+func (d *Database) SetMemberMuted(ctx context.Context, channelID, userID string, muted bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.changeMember(ctx, channelID, userID, false,
+		"UPDATE channel_members SET muted = ? WHERE channel_id = ? AND user_id = ?",
+		muted, channelID, userID)
+}
+
+// changeMember runs a statement against one existing member in a transaction.
+// If demotes is set and the member is the channel's last owner, it fails with
+// ErrLastOwner instead. Callers hold d.mu.
+func (d *Database) changeMember(ctx context.Context, channelID, userID string, demotes bool, query string, args ...interface{}) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var role MemberRole
+	err = tx.QueryRowContext(ctx,
+		"SELECT role FROM channel_members WHERE channel_id = ? AND user_id = ?",
+		channelID, userID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s in %s", ErrMemberNotFound, userID, channelID)
+		}
+		return fmt.Errorf("failed to get channel member: %w", err)
+	}
+
+	if demotes && role == RoleOwner {
+		var owners int
+		err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND role = ?",
+			channelID, RoleOwner).Scan(&owners)
+		if err != nil {
+			return fmt.Errorf("failed to count channel owners: %w", err)
+		}
+		if owners <= 1 {
+			return fmt.Errorf("%w: %s", ErrLastOwner, channelID)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update channel member: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetMember returns a user's membership in a channel, or ErrMemberNotFound
+func (d *Database) GetMember(ctx context.Context, channelID, userID string) (*ChannelMember, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT channel_id, user_id, role, joined_at, muted
+		FROM channel_members
+		WHERE channel_id = ? AND user_id = ?
+	`
+
+	member := &ChannelMember{}
+	err := d.db.QueryRowContext(ctx, query, channelID, userID).Scan(
+		&member.ChannelID, &member.UserID, &member.Role, &member.JoinedAt, &member.Muted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s in %s", ErrMemberNotFound, userID, channelID)
+		}
+		return nil, fmt.Errorf("failed to get channel member: %w", err)
+	}
+
+	return member, nil
+}
+
+// ListMembers returns a channel's members, owners first, then moderators, each
+// in the order they joined
+func (d *Database) ListMembers(ctx context.Context, channelID string) ([]*ChannelMember, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT channel_id, user_id, role, joined_at, muted
+		FROM channel_members
+		WHERE channel_id = ?
+		ORDER BY CASE role WHEN 'owner' THEN 0 WHEN 'moderator' THEN 1 ELSE 2 END, joined_at, user_id
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channel members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*ChannelMember
+	for rows.Next() {
+		member := &ChannelMember{}
+		err := rows.Scan(&member.ChannelID, &member.UserID, &member.Role,
+			&member.JoinedAt, &member.Muted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
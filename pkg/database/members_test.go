@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// memberIDs returns the user IDs of a channel's members in ListMembers order
+func memberIDs(t *testing.T, db *Database, channelID string) []string {
+	t.Helper()
+	members, err := db.ListMembers(context.Background(), channelID)
+	if err != nil {
+		t.Fatalf("Failed to list members: %v", err)
+	}
+	var ids []string
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids
+}
+
+func TestDatabase_SaveChannel_Owner(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	saveTestChannel(t, db, "general", "1")
+
+	member, err := db.GetMember(ctx, "general", "1")
+	if err != nil {
+		t.Fatalf("Failed to get creator: %v", err)
+	}
+	if member.Role != RoleOwner {
+		t.Errorf("Expected the creator to be owner, got %s", member.Role)
+	}
+
+	// Saving the channel again leaves membership alone, even once the creator has left
+	if err := db.AddMember(ctx, "general", "2", RoleOwner); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := db.RemoveMember(ctx, "general", "1"); err != nil {
+		t.Fatalf("Failed to remove creator: %v", err)
+	}
+	saveTestChannel(t, db, "general", "1")
+	if got := memberIDs(t, db, "general"); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("Expected members [2] after saving again, got %v", got)
+	}
+}
+
+func TestDatabase_ChangeMember(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	saveTestChannel(t, db, "general", "1")
+	if err := db.AddMember(ctx, "general", "2", RoleMember); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	// The last owner can neither be demoted nor removed
+	if err := db.SetMemberRole(ctx, "general", "1", RoleModerator); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected ErrLastOwner demoting the last owner, got %v", err)
+	}
+	if err := db.RemoveMember(ctx, "general", "1"); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected ErrLastOwner removing the last owner, got %v", err)
+	}
+
+	// With a second owner, the first can be demoted
+	if err := db.SetMemberRole(ctx, "general", "2", RoleOwner); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	if err := db.SetMemberRole(ctx, "general", "1", RoleMember); err != nil {
+		t.Fatalf("Expected demotion with another owner to succeed, got %v", err)
+	}
+	member, err := db.GetMember(ctx, "general", "1")
+	if err != nil {
+		t.Fatalf("Failed to get member: %v", err)
+	}
+	if member.Role != RoleMember {
+		t.Errorf("Expected role member after demotion, got %s", member.Role)
+	}
+
+	// Muting is not a demotion, so even the last owner can be muted
+	for _, muted := range []bool{true, false} {
+		if err := db.SetMemberMuted(ctx, "general", "2", muted); err != nil {
+			t.Fatalf("Failed to set muted=%v: %v", muted, err)
+		}
+		member, err := db.GetMember(ctx, "general", "2")
+		if err != nil {
+			t.Fatalf("Failed to get member: %v", err)
+		}
+		if member.Muted != muted {
+			t.Errorf("Expected muted=%v, got %v", muted, member.Muted)
+		}
+	}
+
+	if err := db.SetMemberMuted(ctx, "general", "3", true); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("Expected ErrMemberNotFound for a non-member, got %v", err)
+	}
+	if err := db.SetMemberRole(ctx, "general", "2", "admin"); err == nil {
+		t.Error("Expected an invalid role to be rejected")
+	}
+}
+
+func TestDatabase_ListMembers(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		saveTestUser(t, db, id, "user"+id)
+	}
+	saveTestChannel(t, db, "general", "1")
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, member := range []struct {
+		userID string
+		role   MemberRole
+	}{
+		{"5", RoleMember},
+		{"4", RoleModerator},
+		{"3", RoleMember},
+		{"2", RoleOwner},
+	} {
+		if err := db.AddMember(ctx, "general", member.userID, member.role); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+		// Join times one minute apart, in the order added
+		_, err := db.db.Exec("UPDATE channel_members SET joined_at = ? WHERE channel_id = ? AND user_id = ?",
+			start.Add(time.Duration(i+1)*time.Minute), "general", member.userID)
+		if err != nil {
+			t.Fatalf("Failed to set join time: %v", err)
+		}
+	}
+	if _, err := db.db.Exec("UPDATE channel_members SET joined_at = ? WHERE user_id = '1'", start); err != nil {
+		t.Fatalf("Failed to set join time: %v", err)
+	}
+
+	expected := []string{"1", "2", "4", "5", "3"}
+	if got := memberIDs(t, db, "general"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected owners, then moderators, then members by join time %v, got %v", expected, got)
+	}
+}
+
+func TestDatabase_MembersMigration_BackfillsCreators(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, channel := range []*Channel{
+		{ID: "general", Name: "general", Type: "text", CreatedBy: "1", CreatedAt: created, Metadata: "{}"},
+		{ID: "random", Name: "random", Type: "text", CreatedBy: "2", CreatedAt: created, Metadata: "{}"},
+	} {
+		if err := db.SaveChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to save channel: %v", err)
+		}
+	}
+
+	// Back to before channel_members existed, then forward again
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	if _, err := db.MigrateDown(ctx, version-2); err != nil {
+		t.Fatalf("Failed to migrate down to version 2: %v", err)
+	}
+	if _, err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	for channelID, creator := range map[string]string{"general": "1", "random": "2"} {
+		members, err := db.ListMembers(ctx, channelID)
+		if err != nil {
+			t.Fatalf("Failed to list members: %v", err)
+		}
+		if len(members) != 1 || members[0].UserID != creator || members[0].Role != RoleOwner {
+			t.Errorf("Expected %s's creator %s as sole owner, got %+v", channelID, creator, members)
+			continue
+		}
+		if !members[0].JoinedAt.Equal(created) {
+			t.Errorf("Expected %s's creator to have joined at creation %v, got %v", channelID, created, members[0].JoinedAt)
+		}
+	}
+}
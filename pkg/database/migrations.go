@@ -0,0 +1,518 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered, reversible change to the schema. Up and Down are
+// SQL scripts run in a transaction.
+type Migration struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Up          string `json:"-"`
+	Down        string `json:"-"`
+	// Requires names an optional SQLite module Up needs. Without it the
+	// migration stays pending and later ones are applied regardless.
+	Requires string `json:"requires,omitempty"`
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// migrations lists every schema change in order. Append new migrations here;
+// never edit one that has shipped.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "initial schema",
+		// IF NOT EXISTS lets databases created before versioning adopt this version
+		Up: `
+	-- Users table
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		display_name TEXT NOT NULL,
+		email TEXT,
+		avatar TEXT,
+		status TEXT DEFAULT 'offline',
+		last_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		metadata TEXT DEFAULT '{}'
+	);
+
+	-- Channels table
+	CREATE TABLE IF NOT EXISTS channels (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT,
+		type TEXT DEFAULT 'text',
+		private BOOLEAN DEFAULT FALSE,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_message DATETIME,
+		metadata TEXT DEFAULT '{}',
+		FOREIGN KEY (created_by) REFERENCES users(id)
+	);
+
+	-- Messages table
+	CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		content TEXT NOT NULL,
+		message_type TEXT DEFAULT 'text',
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+		edited_at DATETIME,
+		deleted_at DATETIME,
+		metadata TEXT DEFAULT '{}',
+		attachments TEXT DEFAULT '[]',
+		FOREIGN KEY (channel_id) REFERENCES channels(id),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	-- Files table
+	CREATE TABLE IF NOT EXISTS files (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		path TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mime_type TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		uploaded_by TEXT NOT NULL,
+		channel_id TEXT,
+		message_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		metadata TEXT DEFAULT '{}',
+		FOREIGN KEY (uploaded_by) REFERENCES users(id),
+		FOREIGN KEY (channel_id) REFERENCES channels(id),
+		FOREIGN KEY (message_id) REFERENCES messages(id)
+	);
+
+	-- Sessions table
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		token TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used DATETIME DEFAULT CURRENT_TIMESTAMP,
+		ip_address TEXT,
+		user_agent TEXT,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	-- Read positions table: the newest message each user has read per channel
+	CREATE TABLE IF NOT EXISTS read_positions (
+		user_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id INTEGER NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, channel_id)
+	);
+
+	-- Indexes for performance
+	CREATE INDEX IF NOT EXISTS idx_messages_channel_timestamp ON messages(channel_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_messages_user_timestamp ON messages(user_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_files_channel ON files(channel_id);
+	CREATE INDEX IF NOT EXISTS idx_files_user ON files(uploaded_by);
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
+	CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+	CREATE INDEX IF NOT EXISTS idx_channels_name ON channels(name);
+	CREATE INDEX IF NOT EXISTS idx_read_positions_channel ON read_positions(channel_id, message_id);
+
+	-- Triggers for updated_at
+	CREATE TRIGGER IF NOT EXISTS update_users_timestamp
+		AFTER UPDATE ON users
+		BEGIN
+			UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+		END;
+
+	CREATE TRIGGER IF NOT EXISTS update_channels_timestamp
+		AFTER UPDATE ON channels
+		BEGIN
+			UPDATE channels SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+		END;
+	`,
+		Down: `
+	DROP TABLE IF EXISTS read_positions;
+	DROP TABLE IF EXISTS sessions;
+	DROP TABLE IF EXISTS files;
+	DROP TABLE IF EXISTS messages;
+	DROP TABLE IF EXISTS channels;
+	DROP TABLE IF EXISTS users;
+	`,
+	},
+	{
+		Version:     2,
+		Description: "threads and reactions",
+		Up: `
+	ALTER TABLE messages ADD COLUMN parent_id INTEGER;
+	CREATE INDEX idx_messages_parent ON messages(parent_id, timestamp);
+
+	-- One row per thread root, kept current by the triggers below
+	CREATE TABLE message_threads (
+		parent_id INTEGER PRIMARY KEY,
+		reply_count INTEGER NOT NULL DEFAULT 0,
+		last_reply_at DATETIME,
+		FOREIGN KEY (parent_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+
+	CREATE TRIGGER message_threads_reply
+		AFTER INSERT ON messages
+		WHEN NEW.parent_id IS NOT NULL
+		BEGIN
+			INSERT INTO message_threads (parent_id, reply_count, last_reply_at)
+			VALUES (NEW.parent_id, 1, NEW.timestamp)
+			ON CONFLICT(parent_id) DO UPDATE SET
+				reply_count = reply_count + 1,
+				last_reply_at = MAX(last_reply_at, excluded.last_reply_at);
+		END;
+
+	CREATE TRIGGER message_threads_reply_deleted
+		AFTER UPDATE OF deleted_at ON messages
+		WHEN NEW.parent_id IS NOT NULL AND OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL
+		BEGIN
+			UPDATE message_threads SET reply_count = reply_count - 1 WHERE parent_id = NEW.parent_id;
+		END;
+
+	-- One row per user per emoji on a message
+	CREATE TABLE message_reactions (
+		message_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		emoji TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (message_id, emoji, user_id),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS message_reactions;
+	DROP TRIGGER IF EXISTS message_threads_reply_deleted;
+	DROP TRIGGER IF EXISTS message_threads_reply;
+	DROP TABLE IF EXISTS message_threads;
+	DROP INDEX IF EXISTS idx_messages_parent;
+	ALTER TABLE messages DROP COLUMN parent_id;
+	`,
+	},
+	{
+		Version:     3,
+		Description: "channel members",
+		Up: `
+	CREATE TABLE channel_members (
+		channel_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		muted BOOLEAN NOT NULL DEFAULT FALSE,
+		PRIMARY KEY (channel_id, user_id),
+		FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+	CREATE INDEX idx_channel_members_user ON channel_members(user_id);
+
+	-- Creators were the only members before this table existed
+	INSERT INTO channel_members (channel_id, user_id, role, joined_at)
+	SELECT id, created_by, 'owner', created_at FROM channels;
+	`,
+		Down: `
+	DROP TABLE IF EXISTS channel_members;
+	`,
+	},
+	{
+		Version:     4,
+		Description: "unread counts",
+		// Messages saved before this version have no mentions recorded
+		Up: `
+	-- Counting a channel's messages after a read position seeks on this
+	CREATE INDEX idx_messages_channel_id ON messages(channel_id, id);
+
+	-- One row per user mentioned in a message, filled in by SaveMessage
+	CREATE TABLE message_mentions (
+		message_id INTEGER NOT NULL,
+		channel_id TEXT NOT NULL,
+		username TEXT NOT NULL COLLATE NOCASE,
+		PRIMARY KEY (message_id, username),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_message_mentions_user ON message_mentions(username, channel_id, message_id);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS message_mentions;
+	DROP INDEX IF EXISTS idx_messages_channel_id;
+	`,
+	},
+	{
+		Version:     5,
+		Description: "message revisions",
+		Up: `
+	-- What a message said before each edit or delete, and who made it
+	CREATE TABLE message_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message_id INTEGER NOT NULL,
+		action TEXT NOT NULL CHECK (action IN ('edit', 'delete')),
+		content TEXT NOT NULL,
+		editor_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_message_revisions_message ON message_revisions(message_id, id);
+	CREATE INDEX idx_message_revisions_created ON message_revisions(created_at);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS message_revisions;
+	`,
+	},
+	{
+		Version:     6,
+		Description: "full-text search",
+		Requires:    "fts5", // Build tag sqlite_fts5; without it search falls back to LIKE
+		// IF NOT EXISTS adopts indexes created before search was a migration
+		Up: `
+	-- Message content and usernames; the table reads its content from messages
+	CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+		content,
+		username,
+		content='messages',
+		content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_insert
+		AFTER INSERT ON messages
+		BEGIN
+			INSERT INTO messages_fts(rowid, content, username) VALUES (NEW.id, NEW.content, NEW.username);
+		END;
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_delete
+		AFTER DELETE ON messages
+		BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, username) VALUES ('delete', OLD.id, OLD.content, OLD.username);
+		END;
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_update
+		AFTER UPDATE OF content, username ON messages
+		BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, username) VALUES ('delete', OLD.id, OLD.content, OLD.username);
+			INSERT INTO messages_fts(rowid, content, username) VALUES (NEW.id, NEW.content, NEW.username);
+		END;
+
+	-- Index the messages saved before now
+	INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');
+	`,
+		Down: `
+	DROP TRIGGER IF EXISTS messages_fts_update;
+	DROP TRIGGER IF EXISTS messages_fts_delete;
+	DROP TRIGGER IF EXISTS messages_fts_insert;
+	DROP TABLE IF EXISTS messages_fts;
+	`,
+	},
+}
+
+// Migrations returns the known migrations in version order
+func Migrations() []Migration {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
+
+// ensureMigrationsTable creates the table recording applied migrations; callers hold d.mu
+func (d *Database) ensureMigrationsTable(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrations returns when each applied version was applied; callers hold d.mu
+func (d *Database) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	if err := d.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// SchemaVersion returns the newest applied migration, or 0 for an empty database
+func (d *Database) SchemaVersion(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// MigrationStatus lists every known migration and whether it has been applied
+func (d *Database) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range Migrations() {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// MigrateUp applies up to steps pending migrations in version order, or all of
+// them when steps is not positive, and returns how many it applied. Each runs in
+// its own transaction, so a failure leaves the schema at the last good version.
+func (d *Database) MigrateUp(ctx context.Context, steps int) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	known := Migrations()
+	latest := known[len(known)-1].Version
+	for version := range applied {
+		if version > latest {
+			return 0, fmt.Errorf("database schema version %d is newer than this client supports (%d)", version, latest)
+		}
+	}
+
+	count := 0
+	for _, migration := range known {
+		if steps > 0 && count == steps {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := d.runMigration(ctx, migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, description) VALUES (?, ?)",
+				migration.Version, migration.Description)
+			return err
+		})
+		if err != nil && migration.Requires != "" && strings.Contains(err.Error(), "no such module: "+migration.Requires) {
+			d.logger.Info("Skipped migration %d (%s): SQLite was built without %s",
+				migration.Version, migration.Description, migration.Requires)
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		d.logger.Info("Applied migration %d: %s", migration.Version, migration.Description)
+		count++
+	}
+
+	return count, nil
+}
+
+// MigrateDown reverts the newest steps applied migrations, at least one, and
+// returns how many it reverted
+func (d *Database) MigrateDown(ctx context.Context, steps int) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if steps < 1 {
+		steps = 1
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	known := Migrations()
+	count := 0
+	for i := len(known) - 1; i >= 0 && count < steps; i-- {
+		migration := known[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := d.runMigration(ctx, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		d.logger.Info("Reverted migration %d: %s", migration.Version, migration.Description)
+		count++
+	}
+
+	return count, nil
+}
+
+// runMigration runs a migration script and records it in one transaction; callers hold d.mu
+func (d *Database) runMigration(ctx context.Context, script string, record func(tx *sql.Tx) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+// hasColumn reports whether a table has a column
+func hasColumn(t *testing.T, db *Database, table, column string) bool {
+	t.Helper()
+	var count int
+	err := db.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to read columns of %s: %v", table, err)
+	}
+	return count > 0
+}
+
+// schemaObjects counts the tables, indexes and triggers outside the migrations' bookkeeping
+func schemaObjects(t *testing.T, db *Database) int {
+	t.Helper()
+	var count int
+	err := db.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE name NOT IN ('schema_migrations', 'sqlite_sequence') AND name NOT LIKE 'sqlite_autoindex_%'
+	`).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	return count
+}
+
+func TestDatabase_MigrateDownAndUp(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	expected := len(migrations)
+	if !db.fts {
+		expected-- // The full-text search migration stays pending without FTS5
+	}
+	if version != expected {
+		t.Fatalf("Expected a new database at version %d, got %d", expected, version)
+	}
+	objects := schemaObjects(t, db)
+
+	// Data in the way of the downgrade, including a thread reply
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	root := saveTestMessage(t, db, "general", "1", "alice", "root")
+	reply := &Message{ChannelID: "general", UserID: "1", Username: "alice", Content: "reply", ParentID: &root.ID}
+	if err := db.SaveMessage(ctx, reply); err != nil {
+		t.Fatalf("Failed to save reply: %v", err)
+	}
+
+	// Down to version 1, which drops messages.parent_id again
+	if _, err := db.MigrateDown(ctx, version-1); err != nil {
+		t.Fatalf("Failed to migrate down to version 1: %v", err)
+	}
+	if got, _ := db.SchemaVersion(ctx); got != 1 {
+		t.Fatalf("Expected version 1, got %d", got)
+	}
+	if hasColumn(t, db, "messages", "parent_id") {
+		t.Error("Expected migration 2's Down to drop messages.parent_id")
+	}
+
+	reverted, err := db.MigrateDown(ctx, 1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("Failed to migrate down to 0: reverted %d, %v", reverted, err)
+	}
+	if got := schemaObjects(t, db); got != 0 {
+		t.Errorf("Expected an empty schema at version 0, got %d objects", got)
+	}
+
+	applied, err := db.MigrateUp(ctx, 0)
+	if err != nil {
+		t.Fatalf("Failed to migrate up again: %v", err)
+	}
+	if applied != version {
+		t.Errorf("Expected %d migrations applied again, got %d", version, applied)
+	}
+	if got, _ := db.SchemaVersion(ctx); got != version {
+		t.Errorf("Expected version %d again, got %d", version, got)
+	}
+	if got := schemaObjects(t, db); got != objects {
+		t.Errorf("Expected the same %d schema objects after migrating up again, got %d", objects, got)
+	}
+	if !hasColumn(t, db, "messages", "parent_id") {
+		t.Error("Expected messages.parent_id to be back")
+	}
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReactionCount is how many users reacted to a message with one emoji
+type ReactionCount struct {
+	MessageID int64    `json:"message_id" db:"message_id"`
+	Emoji     string   `json:"emoji" db:"emoji"`
+	Count     int      `json:"count" db:"count"`
+	Users     []string `json:"users"` // In the order they reacted
+}
+
+// AddReaction records a user's reaction to a message. Adding the same reaction
+// twice is a no-op.
+func (d *Database) AddReaction(ctx context.Context, messageID int64, userID, emoji string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	query := `
+		INSERT INTO message_reactions (message_id, user_id, emoji)
+		VALUES (?, ?, ?)
+		ON CONFLICT(message_id, emoji, user_id) DO NOTHING
+	`
+
+	_, err := d.db.ExecContext(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveReaction removes a user's reaction from a message
+func (d *Database) RemoveReaction(ctx context.Context, messageID int64, userID, emoji string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.ExecContext(ctx,
+		"DELETE FROM message_reactions WHERE message_id = ? AND user_id = ? AND emoji = ?",
+		messageID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetReactionCounts returns the reactions on each of the given messages, keyed by
+// message ID. Each message's emoji are ordered by their first use.
+func (d *Database) GetReactionCounts(ctx context.Context, messageIDs ...int64) (map[int64][]*ReactionCount, error) {
+	counts := make(map[int64][]*ReactionCount)
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(messageIDs)), ", ")
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		args[i] = id
+	}
+
+	// created_at has second resolution, so rowid breaks ties in order of use.
+	// The unit separator cannot appear in a user ID.
+	query := fmt.Sprintf(`
+		SELECT message_id, emoji, COUNT(*), group_concat(user_id, char(31))
+		FROM (
+			SELECT message_id, emoji, user_id, created_at, rowid AS seq
+			FROM message_reactions
+			WHERE message_id IN (%s)
+			ORDER BY created_at, seq
+		)
+		GROUP BY message_id, emoji
+		ORDER BY message_id, MIN(created_at), MIN(seq)
+	`, placeholders)
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		count := &ReactionCount{}
+		var users string
+		if err := rows.Scan(&count.MessageID, &count.Emoji, &count.Count, &users); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		count.Users = strings.Split(users, "\x1f")
+		counts[count.MessageID] = append(counts[count.MessageID], count)
+	}
+
+	return counts, rows.Err()
+}
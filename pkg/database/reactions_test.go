@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDatabase_GetReactionCounts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	first := saveTestMessage(t, db, "general", "1", "alice", "first")
+	second := saveTestMessage(t, db, "general", "1", "alice", "second")
+	unreacted := saveTestMessage(t, db, "general", "1", "alice", "third")
+
+	for _, reaction := range []struct {
+		messageID int64
+		userID    string
+		emoji     string
+	}{
+		{first.ID, "2", "👍"},
+		{first.ID, "1", "🎉"}, // Sorts before 👍, but was used after it
+		{first.ID, "1", "👍"},
+		{first.ID, "2", "👍"}, // A repeat is a no-op
+		{second.ID, "3", "❤️"},
+		{second.ID, "3", "👀"},
+	} {
+		if err := db.AddReaction(ctx, reaction.messageID, reaction.userID, reaction.emoji); err != nil {
+			t.Fatalf("Failed to add reaction: %v", err)
+		}
+	}
+	if err := db.RemoveReaction(ctx, second.ID, "3", "👀"); err != nil {
+		t.Fatalf("Failed to remove reaction: %v", err)
+	}
+
+	counts, err := db.GetReactionCounts(ctx, first.ID, second.ID, unreacted.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reaction counts: %v", err)
+	}
+
+	expected := map[int64][]*ReactionCount{
+		first.ID: {
+			{MessageID: first.ID, Emoji: "👍", Count: 2, Users: []string{"2", "1"}},
+			{MessageID: first.ID, Emoji: "🎉", Count: 1, Users: []string{"1"}},
+		},
+		second.ID: {
+			{MessageID: second.ID, Emoji: "❤️", Count: 1, Users: []string{"3"}},
+		},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		for id, reactions := range counts {
+			for _, reaction := range reactions {
+				t.Logf("message %d: %+v", id, reaction)
+			}
+		}
+		t.Errorf("Expected reactions aggregated per emoji in order of first use")
+	}
+
+	if counts, err := db.GetReactionCounts(ctx); err != nil || len(counts) != 0 {
+		t.Errorf("Expected no reactions for no messages, got %v, %v", counts, err)
+	}
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReadPosition is the newest message a user has read in a channel
+type ReadPosition struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	ChannelID string    `json:"channel_id" db:"channel_id"`
+	MessageID int64     `json:"message_id" db:"message_id"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SetReadPosition records that a user has read a channel up to messageID.
+// Positions only move forward, so a late receipt for an older message is ignored.
+func (d *Database) SetReadPosition(ctx context.Context, userID, channelID string, messageID int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	query := `
+		INSERT INTO read_positions (user_id, channel_id, message_id, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id, channel_id) DO UPDATE SET
+			message_id = excluded.message_id,
+			updated_at = excluded.updated_at
+		WHERE excluded.message_id > read_positions.message_id
+	`
+
+	_, err := d.db.ExecContext(ctx, query, userID, channelID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to save read position: %w", err)
+	}
+
+	return nil
+}
+
+// GetReadPosition returns the newest message a user has read in a channel, or 0
+func (d *Database) GetReadPosition(ctx context.Context, userID, channelID string) (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var messageID int64
+	err := d.db.QueryRowContext(ctx,
+		"SELECT message_id FROM read_positions WHERE user_id = ? AND channel_id = ?",
+		userID, channelID).Scan(&messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get read position: %w", err)
+	}
+
+	return messageID, nil
+}
+
+// GetReadPositions returns every user's read position in a channel
+func (d *Database) GetReadPositions(ctx context.Context, channelID string) ([]*ReadPosition, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT user_id, channel_id, message_id, updated_at
+		FROM read_positions
+		WHERE channel_id = ?
+		ORDER BY message_id DESC
+	`
+
+	return d.queryReadPositions(ctx, query, channelID)
+}
+
+// GetMessageReaders returns the read positions of users who have read a message
+func (d *Database) GetMessageReaders(ctx context.Context, channelID string, messageID int64) ([]*ReadPosition, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT user_id, channel_id, message_id, updated_at
+		FROM read_positions
+		WHERE channel_id = ? AND message_id >= ?
+		ORDER BY updated_at
+	`
+
+	return d.queryReadPositions(ctx, query, channelID, messageID)
+}
+
+// queryReadPositions runs a read_positions query; callers hold d.mu
+func (d *Database) queryReadPositions(ctx context.Context, query string, args ...interface{}) ([]*ReadPosition, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query read positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []*ReadPosition
+	for rows.Next() {
+		position := &ReadPosition{}
+		if err := rows.Scan(&position.UserID, &position.ChannelID, &position.MessageID, &position.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan read position: %w", err)
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, rows.Err()
+}
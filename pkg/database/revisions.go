@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RevisionAction is the change a revision records
+type RevisionAction string
+
+const (
+	RevisionEdit   RevisionAction = "edit"
+	RevisionDelete RevisionAction = "delete"
+)
+
+// MessageRevision is what a message said before one edit or delete
+type MessageRevision struct {
+	ID        int64          `json:"id" db:"id"`
+	MessageID int64          `json:"message_id" db:"message_id"`
+	Action    RevisionAction `json:"action" db:"action"`
+	Content   string         `json:"content" db:"content"` // Content before the change
+	EditorID  string         `json:"editor_id" db:"editor_id"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// saveRevision copies a message's current content into message_revisions before
+// it changes. Deleted messages cannot be changed, so they fail with
+// ErrMessageNotFound.
+func saveRevision(ctx context.Context, tx *sql.Tx, messageID int64, action RevisionAction, editorID string) error {
+	query := `
+		INSERT INTO message_revisions (message_id, action, content, editor_id)
+		SELECT id, ?, content, ? FROM messages WHERE id = ? AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, action, editorID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to save message revision: %w", err)
+	}
+
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save message revision: %w", err)
+	}
+	if saved == 0 {
+		return fmt.Errorf("%w: %d", ErrMessageNotFound, messageID)
+	}
+
+	return nil
+}
+
+// GetMessageRevisions returns a message's revisions, oldest first
+func (d *Database) GetMessageRevisions(ctx context.Context, messageID int64) ([]*MessageRevision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT id, message_id, action, content, editor_id, created_at
+		FROM message_revisions
+		WHERE message_id = ?
+		ORDER BY id
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*MessageRevision
+	for rows.Next() {
+		revision := &MessageRevision{}
+		err := rows.Scan(&revision.ID, &revision.MessageID, &revision.Action,
+			&revision.Content, &revision.EditorID, &revision.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// PurgeRevisions deletes revisions recorded before the given time and returns
+// how many it deleted
+func (d *Database) PurgeRevisions(ctx context.Context, before time.Time) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// created_at is stored by CURRENT_TIMESTAMP, in UTC and this format
+	result, err := d.db.ExecContext(ctx,
+		"DELETE FROM message_revisions WHERE created_at < ?",
+		before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge message revisions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDatabase_MessageRevisions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	saveTestChannel(t, db, "general", "1")
+	msg := saveTestMessage(t, db, "general", "1", "alice", "first draft")
+
+	if err := db.UpdateMessage(ctx, msg.ID, "1", "second draft"); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if err := db.UpdateMessage(ctx, msg.ID, "2", "final"); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if err := db.DeleteMessage(ctx, msg.ID, "1"); err != nil {
+		t.Fatalf("Failed to delete message: %v", err)
+	}
+
+	// A deleted message can be neither edited nor deleted again
+	if err := db.UpdateMessage(ctx, msg.ID, "1", "too late"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound editing a deleted message, got %v", err)
+	}
+	if err := db.DeleteMessage(ctx, msg.ID, "1"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound deleting a deleted message, got %v", err)
+	}
+	if err := db.UpdateMessage(ctx, msg.ID+1, "1", "nothing here"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound editing a missing message, got %v", err)
+	}
+
+	revisions, err := db.GetMessageRevisions(ctx, msg.ID)
+	if err != nil {
+		t.Fatalf("Failed to get revisions: %v", err)
+	}
+
+	expected := []struct {
+		action   RevisionAction
+		content  string
+		editorID string
+	}{
+		{RevisionEdit, "first draft", "1"},
+		{RevisionEdit, "second draft", "2"},
+		{RevisionDelete, "final", "1"},
+	}
+	if len(revisions) != len(expected) {
+		t.Fatalf("Expected %d revisions, got %d", len(expected), len(revisions))
+	}
+	for i, want := range expected {
+		got := revisions[i]
+		if got.MessageID != msg.ID || got.Action != want.action || got.Content != want.content || got.EditorID != want.editorID {
+			t.Errorf("Expected revision %d to be %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestDatabase_PurgeRevisions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	msg := saveTestMessage(t, db, "general", "1", "alice", "v0")
+
+	// Revisions recorded at these times, as CURRENT_TIMESTAMP stores them
+	recorded := []string{
+		"2024-01-01 12:00:00",
+		"2024-01-01 23:59:59",
+		"2024-01-02 00:00:00",
+		"2024-01-03 08:00:00",
+	}
+	for i, at := range recorded {
+		if err := db.UpdateMessage(ctx, msg.ID, "1", fmt.Sprintf("v%d", i+1)); err != nil {
+			t.Fatalf("Failed to edit message: %v", err)
+		}
+		_, err := db.db.Exec("UPDATE message_revisions SET created_at = ? WHERE id = (SELECT MAX(id) FROM message_revisions)", at)
+		if err != nil {
+			t.Fatalf("Failed to set revision time: %v", err)
+		}
+	}
+
+	// Midnight UTC on January 2nd, given in another zone
+	cutoff := time.Date(2024, 1, 2, 1, 0, 0, 0, time.FixedZone("CET", 60*60))
+	purged, err := db.PurgeRevisions(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("Failed to purge revisions: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Expected 2 revisions purged, got %d", purged)
+	}
+
+	revisions, err := db.GetMessageRevisions(ctx, msg.ID)
+	if err != nil {
+		t.Fatalf("Failed to get revisions: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "v2" || revisions[1].Content != "v3" {
+		t.Errorf("Expected the revisions from the cutoff on to remain, got %+v", revisions)
+	}
+}
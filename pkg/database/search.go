@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Snippets mark matched terms with these
+const (
+	HighlightStart = "«"
+	HighlightEnd   = "»"
+)
+
+// SearchOptions narrows a message search; zero values match everything
+type SearchOptions struct {
+	ChannelID   string
+	UserID      string
+	MessageType string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+// SearchResult is a message matching a search
+type SearchResult struct {
+	Message *Message `json:"message"`
+	Rank    float64  `json:"rank"`    // bm25 score; lower is more relevant
+	Snippet string   `json:"snippet"` // Matched terms wrapped in HighlightStart and HighlightEnd
+}
+
+// initSearch turns on full-text search if migration 6 created the index. Without
+// FTS5 compiled in (build tag sqlite_fts5), search falls back to LIKE matching.
+func (d *Database) initSearch() error {
+	var exists int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'messages_fts'").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check search index: %w", err)
+	}
+
+	d.fts = exists > 0
+	if !d.fts {
+		d.logger.Info("Message search index unavailable; search falls back to LIKE matching")
+	}
+	return nil
+}
+
+// SearchMessages searches message content and usernames, best match first. Words
+// match whole tokens; a trailing * matches a prefix, "quoted words" match a
+// phrase, and AND, OR and NOT combine terms, which are ANDed by default.
+func (d *Database) SearchMessages(ctx context.Context, searchText string, opts SearchOptions) ([]*SearchResult, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	match := ftsQuery(searchText)
+	if match == "" {
+		return nil, nil
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	var query string
+	var args []interface{}
+	if d.fts {
+		query = `
+			SELECT m.id, m.channel_id, m.user_id, m.username, m.content, m.message_type,
+				   m.timestamp, m.edited_at, m.deleted_at, m.metadata, m.attachments, m.parent_id,
+				   bm25(messages_fts), snippet(messages_fts, 0, ?, ?, '…', 16)
+			FROM messages_fts
+			JOIN messages m ON m.id = messages_fts.rowid
+			WHERE messages_fts MATCH ? AND m.deleted_at IS NULL
+		`
+		args = append(args, HighlightStart, HighlightEnd, match)
+	} else {
+		query = `
+			SELECT m.id, m.channel_id, m.user_id, m.username, m.content, m.message_type,
+				   m.timestamp, m.edited_at, m.deleted_at, m.metadata, m.attachments, m.parent_id,
+				   0, m.content
+			FROM messages m
+			WHERE m.content LIKE ? AND m.deleted_at IS NULL
+		`
+		args = append(args, "%"+searchText+"%")
+	}
+
+	if opts.ChannelID != "" {
+		query += " AND m.channel_id = ?"
+		args = append(args, opts.ChannelID)
+	}
+	if opts.UserID != "" {
+		query += " AND m.user_id = ?"
+		args = append(args, opts.UserID)
+	}
+	if opts.MessageType != "" {
+		query += " AND m.message_type = ?"
+		args = append(args, opts.MessageType)
+	}
+	if !opts.Since.IsZero() {
+		query += " AND m.timestamp >= ?"
+		args = append(args, opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		query += " AND m.timestamp <= ?"
+		args = append(args, opts.Until)
+	}
+
+	if d.fts {
+		query += " ORDER BY bm25(messages_fts), m.timestamp DESC LIMIT ?"
+	} else {
+		query += " ORDER BY m.timestamp DESC LIMIT ?"
+	}
+	args = append(args, opts.Limit)
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "fts5: syntax error") {
+			return nil, fmt.Errorf("invalid search query %q: %w", searchText, err)
+		}
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		msg := &Message{}
+		result := &SearchResult{Message: msg}
+		err := rows.Scan(&msg.ID, &msg.ChannelID, &msg.UserID, &msg.Username,
+			&msg.Content, &msg.MessageType, &msg.Timestamp, &msg.EditedAt,
+			&msg.DeletedAt, &msg.Metadata, &msg.Attachments, &msg.ParentID,
+			&result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// Highlights returns the terms a snippet marks as matched
+func (r *SearchResult) Highlights() []string {
+	var highlights []string
+	rest := r.Snippet
+	for {
+		start := strings.Index(rest, HighlightStart)
+		if start < 0 {
+			return highlights
+		}
+		rest = rest[start+len(HighlightStart):]
+
+		end := strings.Index(rest, HighlightEnd)
+		if end < 0 {
+			return highlights
+		}
+		highlights = append(highlights, rest[:end])
+		rest = rest[end+len(HighlightEnd):]
+	}
+}
+
+// ftsQuery turns search text into an FTS5 query. Every word and phrase is quoted,
+// so punctuation in it is not read as query syntax, keeping a trailing * for prefix
+// searches. AND, OR and NOT stay operators where they sit between two terms.
+func ftsQuery(text string) string {
+	type token struct {
+		text     string
+		operator bool
+	}
+
+	var tokens []token
+	for {
+		text = strings.TrimLeft(text, " \t\r\n")
+		if text == "" {
+			break
+		}
+
+		var term string
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				term, text = text[1:], ""
+			} else {
+				term, text = text[1:end+1], text[end+2:]
+			}
+			if strings.HasPrefix(text, "*") {
+				term += "*"
+				text = text[1:]
+			}
+		} else {
+			end := strings.IndexAny(text, " \t\r\n\"")
+			if end < 0 {
+				end = len(text)
+			}
+			term, text = text[:end], text[end:]
+
+			if term == "AND" || term == "OR" || term == "NOT" {
+				tokens = append(tokens, token{text: term, operator: true})
+				continue
+			}
+		}
+
+		prefix := strings.HasSuffix(term, "*")
+		term = strings.TrimSpace(strings.TrimRight(term, "*"))
+		if term == "" {
+			continue
+		}
+
+		quoted := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			quoted += "*"
+		}
+		tokens = append(tokens, token{text: quoted})
+	}
+
+	// Drop operators that do not join two terms, which FTS5 rejects
+	var parts []string
+	for i, tok := range tokens {
+		if tok.operator {
+			if len(parts) == 0 || tokens[i-1].operator || i == len(tokens)-1 || tokens[i+1].operator {
+				continue
+			}
+		}
+		parts = append(parts, tok.text)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// requireFTS skips a test unless the database has the full-text index
+func requireFTS(t *testing.T, db *Database) {
+	t.Helper()
+	if !db.fts {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+}
+
+// indexedIDs returns the message IDs the full-text index matches, ignoring deletion
+func indexedIDs(t *testing.T, db *Database, match string) []int64 {
+	t.Helper()
+	rows, err := db.db.Query("SELECT rowid FROM messages_fts WHERE messages_fts MATCH ? ORDER BY rowid", match)
+	if err != nil {
+		t.Fatalf("Failed to query search index: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("Failed to scan search index: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// resultIDs returns the IDs of search results, sorted
+func resultIDs(results []*SearchResult) []int64 {
+	var ids []int64
+	for _, result := range results {
+		ids = append(ids, result.Message.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestDatabase_SearchIndexTriggers(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	requireFTS(t, db)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	msg := saveTestMessage(t, db, "general", "1", "alice", "the quick brown fox")
+
+	if got := indexedIDs(t, db, "fox"); !reflect.DeepEqual(got, []int64{msg.ID}) {
+		t.Errorf("Expected insert to index message %d, got %v", msg.ID, got)
+	}
+	if got := indexedIDs(t, db, "username:alice"); !reflect.DeepEqual(got, []int64{msg.ID}) {
+		t.Errorf("Expected insert to index the username, got %v", got)
+	}
+
+	if err := db.UpdateMessage(ctx, msg.ID, "1", "a slow turtle"); err != nil {
+		t.Fatalf("Failed to update message: %v", err)
+	}
+	if got := indexedIDs(t, db, "fox"); len(got) != 0 {
+		t.Errorf("Expected update to remove the old content, got %v", got)
+	}
+	if got := indexedIDs(t, db, "turtle"); !reflect.DeepEqual(got, []int64{msg.ID}) {
+		t.Errorf("Expected update to index the new content, got %v", got)
+	}
+
+	if err := db.DeleteChannel(ctx, "general"); err != nil {
+		t.Fatalf("Failed to delete channel: %v", err)
+	}
+	if got := indexedIDs(t, db, "turtle"); len(got) != 0 {
+		t.Errorf("Expected delete to remove the message from the index, got %v", got)
+	}
+}
+
+func TestDatabase_SearchMessages(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	requireFTS(t, db)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	ids := make(map[string]int64)
+	for _, content := range []string{
+		"deploy the server tonight",
+		"server deployment failed",
+		"the server is fine",
+		"tonight we deploy",
+	} {
+		ids[content] = saveTestMessage(t, db, "general", "1", "alice", content).ID
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"deploy", []string{"deploy the server tonight", "tonight we deploy"}},
+		{"deploy*", []string{"deploy the server tonight", "server deployment failed", "tonight we deploy"}},
+		{`"deploy the server"`, []string{"deploy the server tonight"}},
+		{`"the server"`, []string{"deploy the server tonight", "the server is fine"}},
+		{"server tonight", []string{"deploy the server tonight"}},
+		{"server AND tonight", []string{"deploy the server tonight"}},
+		{"fine OR failed", []string{"server deployment failed", "the server is fine"}},
+		{"server NOT fine", []string{"deploy the server tonight", "server deployment failed"}},
+		{"server:", []string{"deploy the server tonight", "server deployment failed", "the server is fine"}}, // Not column syntax
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := db.SearchMessages(ctx, tt.query, SearchOptions{})
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+
+			var expected []int64
+			for _, content := range tt.expected {
+				expected = append(expected, ids[content])
+			}
+			sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+			if got := resultIDs(results); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestDatabase_SearchMessages_Ranking(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	requireFTS(t, db)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	// The better match is older, so newest-first ordering would put it last
+	best := saveTestMessage(t, db, "general", "1", "alice", "cache cache cache")
+	saveTestMessage(t, db, "general", "1", "alice", "after a long day the cache was finally cleared by the nightly job")
+
+	results, err := db.SearchMessages(ctx, "cache", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Message.ID != best.ID {
+		t.Errorf("Expected message %d ranked first, got %d", best.ID, results[0].Message.ID)
+	}
+	if results[0].Rank >= results[1].Rank {
+		t.Errorf("Expected ascending bm25 ranks, got %f then %f", results[0].Rank, results[1].Rank)
+	}
+	if got := results[1].Highlights(); !reflect.DeepEqual(got, []string{"cache"}) {
+		t.Errorf("Expected the snippet to highlight cache, got %v", got)
+	}
+}
+
+func TestDatabase_SearchMessages_LikeFallback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := NewDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	saveTestUser(t, db, "1", "alice")
+	saveTestChannel(t, db, "general", "1")
+	older := saveTestMessage(t, db, "general", "1", "alice", "server deployment failed")
+	newer := saveTestMessage(t, db, "general", "1", "alice", "the server is fine")
+	saveTestMessage(t, db, "general", "1", "alice", "tonight we deploy")
+
+	// Without the search index, as on a build without FTS5
+	if db.fts {
+		if _, err := db.MigrateDown(ctx, 1); err != nil {
+			t.Fatalf("Failed to revert the search migration: %v", err)
+		}
+	}
+	db.Close()
+
+	db, err = OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if db.fts {
+		t.Fatal("Expected search to fall back without the index")
+	}
+
+	// A substring matches, which whole-token matching would not
+	results, err := db.SearchMessages(ctx, "erver", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Message.ID != newer.ID || results[1].Message.ID != older.ID {
+		t.Errorf("Expected newest first, got %d then %d", results[0].Message.ID, results[1].Message.ID)
+	}
+	if results[0].Snippet != "the server is fine" {
+		t.Errorf("Expected the content as snippet, got %q", results[0].Snippet)
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Thread summarizes the replies to a message
+type Thread struct {
+	ParentID     int64      `json:"parent_id" db:"parent_id"`
+	ReplyCount   int        `json:"reply_count" db:"reply_count"`
+	LastReplyAt  *time.Time `json:"last_reply_at,omitempty" db:"last_reply_at"`
+	Participants []string   `json:"participants"` // Users who replied, first reply first
+}
+
+// GetThread returns the thread rooted at parentID, or nil if it has no replies
+func (d *Database) GetThread(ctx context.Context, parentID int64) (*Thread, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	thread := &Thread{ParentID: parentID}
+	err := d.db.QueryRowContext(ctx,
+		"SELECT reply_count, last_reply_at FROM message_threads WHERE parent_id = ?",
+		parentID).Scan(&thread.ReplyCount, &thread.LastReplyAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+	if thread.ReplyCount <= 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT user_id
+		FROM messages
+		WHERE parent_id = ? AND deleted_at IS NULL
+		GROUP BY user_id
+		ORDER BY MIN(timestamp)
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread participants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan thread participant: %w", err)
+		}
+		thread.Participants = append(thread.Participants, userID)
+	}
+
+	return thread, rows.Err()
+}
+
+// GetThreadReplies returns the replies to a message, oldest first
+func (d *Database) GetThreadReplies(ctx context.Context, parentID int64, limit, offset int) ([]*Message, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	query := `
+		SELECT id, channel_id, user_id, username, content, message_type,
+			   timestamp, edited_at, deleted_at, metadata, attachments, parent_id
+		FROM messages
+		WHERE parent_id = ? AND deleted_at IS NULL
+		ORDER BY timestamp, id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, parentID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread replies: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		err := rows.Scan(&msg.ID, &msg.ChannelID, &msg.UserID, &msg.Username,
+			&msg.Content, &msg.MessageType, &msg.Timestamp, &msg.EditedAt,
+			&msg.DeletedAt, &msg.Metadata, &msg.Attachments, &msg.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// saveTestReply stores a reply to parentID sent at the given time
+func saveTestReply(t *testing.T, db *Database, parentID int64, userID, username string, at time.Time) *Message {
+	t.Helper()
+	msg := &Message{
+		ChannelID:   "general",
+		UserID:      userID,
+		Username:    username,
+		Content:     "reply from " + username,
+		MessageType: "text",
+		Timestamp:   at,
+		Metadata:    "{}",
+		Attachments: "[]",
+		ParentID:    &parentID,
+	}
+	if err := db.SaveMessage(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to save reply: %v", err)
+	}
+	return msg
+}
+
+func TestDatabase_GetThread(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	saveTestChannel(t, db, "general", "1")
+	root := saveTestMessage(t, db, "general", "1", "alice", "root")
+	lonely := saveTestMessage(t, db, "general", "1", "alice", "no replies")
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := saveTestReply(t, db, root.ID, "2", "bob", start.Add(time.Minute))
+	second := saveTestReply(t, db, root.ID, "1", "alice", start.Add(2*time.Minute))
+	third := saveTestReply(t, db, root.ID, "2", "bob", start.Add(3*time.Minute))
+
+	thread, err := db.GetThread(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("Failed to get thread: %v", err)
+	}
+	if thread == nil || thread.ReplyCount != 3 {
+		t.Fatalf("Expected 3 replies, got %+v", thread)
+	}
+	if thread.LastReplyAt == nil || !thread.LastReplyAt.Equal(third.Timestamp) {
+		t.Errorf("Expected last reply at %v, got %v", third.Timestamp, thread.LastReplyAt)
+	}
+	if !reflect.DeepEqual(thread.Participants, []string{"2", "1"}) {
+		t.Errorf("Expected participants [2 1], got %v", thread.Participants)
+	}
+
+	// Deleting bob's first reply leaves alice as the first to reply
+	if err := db.DeleteMessage(ctx, first.ID, "2"); err != nil {
+		t.Fatalf("Failed to delete reply: %v", err)
+	}
+	thread, err = db.GetThread(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("Failed to get thread: %v", err)
+	}
+	if thread == nil || thread.ReplyCount != 2 {
+		t.Fatalf("Expected 2 replies after a delete, got %+v", thread)
+	}
+	if !reflect.DeepEqual(thread.Participants, []string{"1", "2"}) {
+		t.Errorf("Expected participants [1 2], got %v", thread.Participants)
+	}
+
+	replies, err := db.GetThreadReplies(ctx, root.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get replies: %v", err)
+	}
+	if len(replies) != 2 || replies[0].ID != second.ID || replies[1].ID != third.ID {
+		t.Errorf("Expected the remaining replies oldest first, got %v", replies)
+	}
+
+	for _, reply := range []*Message{second, third} {
+		if err := db.DeleteMessage(ctx, reply.ID, reply.UserID); err != nil {
+			t.Fatalf("Failed to delete reply: %v", err)
+		}
+	}
+	if thread, err := db.GetThread(ctx, root.ID); err != nil || thread != nil {
+		t.Errorf("Expected no thread once every reply is deleted, got %+v, %v", thread, err)
+	}
+
+	if thread, err := db.GetThread(ctx, lonely.ID); err != nil || thread != nil {
+		t.Errorf("Expected no thread without replies, got %+v, %v", thread, err)
+	}
+}
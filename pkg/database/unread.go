@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// mentionPattern matches @username, as messaging's MentionFilter does
+var mentionPattern = regexp.MustCompile(`@(\w+)`)
+
+// UnreadCount is how much of a channel a user has yet to read
+type UnreadCount struct {
+	ChannelID  string `json:"channel_id"`
+	LastReadID int64  `json:"last_read_id"` // 0 if the user has read nothing
+	Unread     int    `json:"unread"`       // Other users' messages after LastReadID
+	Mentions   int    `json:"mentions"`     // Unread messages that mention the user
+}
+
+// saveMentions records the users a message's content mentions, replacing any
+// recorded before; callers hold d.mu
+func saveMentions(ctx context.Context, tx *sql.Tx, messageID int64, content string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM message_mentions WHERE message_id = ?", messageID); err != nil {
+		return fmt.Errorf("failed to clear mentions: %w", err)
+	}
+
+	query := `
+		INSERT INTO message_mentions (message_id, channel_id, username)
+		SELECT id, channel_id, ? FROM messages WHERE id = ?
+		ON CONFLICT(message_id, username) DO NOTHING
+	`
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if _, err := tx.ExecContext(ctx, query, match[1], messageID); err != nil {
+			return fmt.Errorf("failed to save mention: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// unreadQuery counts each channel's unread messages and mentions from the
+// user's read position. ?1 is the user ID and ?2 their username.
+const unreadQuery = `
+	SELECT c.id, COALESCE(rp.message_id, 0),
+		(SELECT COUNT(*) FROM messages m
+		 WHERE m.channel_id = c.id AND m.id > COALESCE(rp.message_id, 0)
+		   AND m.deleted_at IS NULL AND m.user_id != ?1),
+		(SELECT COUNT(*) FROM message_mentions mm
+		 JOIN messages m ON m.id = mm.message_id
+		 WHERE mm.username = ?2 AND mm.channel_id = c.id AND mm.message_id > COALESCE(rp.message_id, 0)
+		   AND m.deleted_at IS NULL AND m.user_id != ?1)
+	FROM channels c
+	LEFT JOIN read_positions rp ON rp.channel_id = c.id AND rp.user_id = ?1
+`
+
+// GetUnreadCounts returns the user's unread counts for every channel, keyed by
+// channel ID. Mentions are matched on username.
+func (d *Database) GetUnreadCounts(ctx context.Context, userID, username string) (map[string]*UnreadCount, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.QueryContext(ctx, unreadQuery, userID, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unread counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]*UnreadCount)
+	for rows.Next() {
+		count := &UnreadCount{}
+		if err := rows.Scan(&count.ChannelID, &count.LastReadID, &count.Unread, &count.Mentions); err != nil {
+			return nil, fmt.Errorf("failed to scan unread count: %w", err)
+		}
+		counts[count.ChannelID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetUnreadCount returns the user's unread counts for one channel
+func (d *Database) GetUnreadCount(ctx context.Context, userID, username, channelID string) (*UnreadCount, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	count := &UnreadCount{}
+	err := d.db.QueryRowContext(ctx, unreadQuery+" WHERE c.id = ?3", userID, username, channelID).Scan(
+		&count.ChannelID, &count.LastReadID, &count.Unread, &count.Mentions)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, channelID)
+		}
+		return nil, fmt.Errorf("failed to get unread count: %w", err)
+	}
+
+	return count, nil
+}
+
+// MarkChannelRead moves the user's read position to the channel's newest message
+// and returns it, or 0 if the channel has no messages
+func (d *Database) MarkChannelRead(ctx context.Context, userID, channelID string) (int64, error) {
+	d.mu.RLock()
+	var latest sql.NullInt64
+	err := d.db.QueryRowContext(ctx,
+		"SELECT MAX(id) FROM messages WHERE channel_id = ?", channelID).Scan(&latest)
+	d.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest message: %w", err)
+	}
+	if !latest.Valid {
+		return 0, nil
+	}
+
+	if err := d.SetReadPosition(ctx, userID, channelID, latest.Int64); err != nil {
+		return 0, err
+	}
+	return latest.Int64, nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDatabase_GetUnreadCounts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	saveTestUser(t, db, "1", "alice")
+	saveTestUser(t, db, "2", "bob")
+	saveTestChannel(t, db, "general", "1")
+	saveTestChannel(t, db, "random", "1")
+
+	greeting := saveTestMessage(t, db, "general", "2", "bob", "hi @Alice")
+	plain := saveTestMessage(t, db, "general", "2", "bob", "hello")
+	saveTestMessage(t, db, "general", "2", "bob", "@ALICE, @alice again")
+	saveTestMessage(t, db, "general", "2", "bob", "@alicex is someone else")
+	saveTestMessage(t, db, "general", "1", "alice", "talking to myself @alice")
+	deleted := saveTestMessage(t, db, "general", "2", "bob", "@alice never mind")
+	if err := db.DeleteMessage(ctx, deleted.ID, "2"); err != nil {
+		t.Fatalf("Failed to delete message: %v", err)
+	}
+
+	// expectCounts checks alice's counts for a channel
+	expectCounts := func(channelID string, unread, mentions int) {
+		t.Helper()
+		counts, err := db.GetUnreadCounts(ctx, "1", "alice")
+		if err != nil {
+			t.Fatalf("Failed to get unread counts: %v", err)
+		}
+		count := counts[channelID]
+		if count == nil {
+			t.Fatalf("Expected counts for %s, got none", channelID)
+		}
+		if count.Unread != unread || count.Mentions != mentions {
+			t.Errorf("Expected %s to have %d unread and %d mentions, got %d and %d",
+				channelID, unread, mentions, count.Unread, count.Mentions)
+		}
+	}
+
+	// Bob's four live messages; the own and deleted messages are left out
+	expectCounts("general", 4, 2)
+	expectCounts("random", 0, 0)
+
+	// Editing replaces a message's mentions
+	if err := db.UpdateMessage(ctx, plain.ID, "2", "hello @alice"); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	expectCounts("general", 4, 3)
+	if err := db.UpdateMessage(ctx, greeting.ID, "2", "hi everyone"); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	expectCounts("general", 4, 2)
+
+	latest, err := db.MarkChannelRead(ctx, "1", "general")
+	if err != nil {
+		t.Fatalf("Failed to mark channel read: %v", err)
+	}
+	if latest != deleted.ID {
+		t.Errorf("Expected read position %d, got %d", deleted.ID, latest)
+	}
+	expectCounts("general", 0, 0)
+
+	after := saveTestMessage(t, db, "general", "2", "bob", "back again, @alice")
+	count, err := db.GetUnreadCount(ctx, "1", "alice", "general")
+	if err != nil {
+		t.Fatalf("Failed to get unread count: %v", err)
+	}
+	if count.LastReadID != latest || count.Unread != 1 || count.Mentions != 1 {
+		t.Errorf("Expected 1 unread mention of message %d after %d, got %+v", after.ID, latest, count)
+	}
+
+	if _, err := db.GetUnreadCount(ctx, "1", "alice", "missing"); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("Expected ErrChannelNotFound, got %v", err)
+	}
+	if latest, err := db.MarkChannelRead(ctx, "1", "random"); err != nil || latest != 0 {
+		t.Errorf("Expected an empty channel to mark read at 0, got %d, %v", latest, err)
+	}
+}
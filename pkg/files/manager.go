@@ -171,28 +171,33 @@ type FileManager struct {
 	cancel    context.CancelFunc
 }
 
+// DefaultFileManagerConfig returns the default file manager configuration
+func DefaultFileManagerConfig() *FileManagerConfig {
+	return &FileManagerConfig{
+		StorageDir:         "storage/files",
+		ThumbnailDir:       "storage/thumbnails",
+		PreviewDir:         "storage/previews",
+		TempDir:            "storage/temp",
+		MaxFileSize:        100 * 1024 * 1024, // 100MB
+		AllowedTypes:       []string{"image/*", "text/*", "application/pdf"},
+		GenerateThumbnails: true,
+		GeneratePreviews:   true,
+		VirusScanEnabled:   false,
+		VersioningEnabled:  true,
+		MaxVersions:        10,
+		CompressionEnabled: false,
+		EncryptionEnabled:  false,
+		CleanupInterval:    24 * time.Hour,
+		RetentionDays:      30,
+		ChunkSize:          1024 * 1024, // 1MB
+		ConcurrentUploads:  5,
+	}
+}
+
 // NewFileManager creates a new file manager
 func NewFileManager(config *FileManagerConfig) *FileManager {
 	if config == nil {
-		config = &FileManagerConfig{
-			StorageDir:         "storage/files",
-			ThumbnailDir:       "storage/thumbnails",
-			PreviewDir:         "storage/previews",
-			TempDir:            "storage/temp",
-			MaxFileSize:        100 * 1024 * 1024, // 100MB
-			AllowedTypes:       []string{"image/*", "text/*", "application/pdf"},
-			GenerateThumbnails: true,
-			GeneratePreviews:   true,
-			VirusScanEnabled:   false,
-			VersioningEnabled:  true,
-			MaxVersions:        10,
-			CompressionEnabled: false,
-			EncryptionEnabled:  false,
-			CleanupInterval:    24 * time.Hour,
-			RetentionDays:      30,
-			ChunkSize:          1024 * 1024, // 1MB
-			ConcurrentUploads:  5,
-		}
+		config = DefaultFileManagerConfig()
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
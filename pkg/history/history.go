@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"plexichat-client/pkg/cache"
 	"plexichat-client/pkg/client"
+	"plexichat-client/pkg/database"
 	"plexichat-client/pkg/logging"
 )
 
@@ -26,9 +28,10 @@ type MessageFilter struct {
 // MessageSearchResult represents a search result
 type MessageSearchResult struct {
 	Message    *client.Message `json:"message"`
-	Relevance  float64         `json:"relevance"`  // Search relevance score
-	Context    []string        `json:"context"`    // Surrounding message context
-	Highlights []string        `json:"highlights"` // Highlighted search terms
+	Relevance  float64         `json:"relevance"`         // Search relevance score; higher is better
+	Context    []string        `json:"context"`           // Surrounding message context
+	Highlights []string        `json:"highlights"`        // Highlighted search terms
+	Snippet    string          `json:"snippet,omitempty"` // Content excerpt with highlights marked
 }
 
 // ConversationSummary provides summary of a conversation
@@ -42,9 +45,13 @@ type ConversationSummary struct {
 	UnreadCount    int       `json:"unread_count"`
 }
 
+// conversationPageSize is the number of messages requested per page when walking a conversation
+const conversationPageSize = 100
+
 // HistoryManager manages message history and search
 type HistoryManager struct {
 	client *cache.CachedClient
+	index  *database.Database
 	logger *logging.Logger
 }
 
@@ -56,10 +63,22 @@ func NewHistoryManager(cachedClient *cache.CachedClient) *HistoryManager {
 	}
 }
 
-// SearchMessages searches through message history
+// SetSearchIndex makes SearchMessages query the full-text index of a local
+// database instead of scanning recent conversations
+func (h *HistoryManager) SetSearchIndex(index *database.Database) {
+	h.index = index
+}
+
+// SearchMessages searches through message history. With a search index, results
+// are ranked by bm25 and highlighted; otherwise recent conversations are scanned
+// and matches returned newest first.
 func (h *HistoryManager) SearchMessages(ctx context.Context, filter *MessageFilter) ([]*MessageSearchResult, error) {
 	h.logger.Info("Searching messages with query: %s", filter.Query)
 
+	if h.index != nil && filter.Query != "" {
+		return h.searchIndex(ctx, filter)
+	}
+
 	var allResults []*MessageSearchResult
 
 	// For now, we'll search through recent conversations
@@ -78,9 +97,8 @@ func (h *HistoryManager) SearchMessages(ctx context.Context, filter *MessageFilt
 		allResults = append(allResults, messages...)
 	}
 
-	// Sort by relevance
 	sort.Slice(allResults, func(i, j int) bool {
-		return allResults[i].Relevance > allResults[j].Relevance
+		return allResults[i].Message.Timestamp.After(allResults[j].Message.Timestamp)
 	})
 
 	// Apply limit
@@ -92,45 +110,60 @@ func (h *HistoryManager) SearchMessages(ctx context.Context, filter *MessageFilt
 	return allResults, nil
 }
 
+// searchIndex runs a search against the local full-text index
+func (h *HistoryManager) searchIndex(ctx context.Context, filter *MessageFilter) ([]*MessageSearchResult, error) {
+	matches, err := h.index.SearchMessages(ctx, filter.Query, database.SearchOptions{
+		UserID:      filter.UserID,
+		MessageType: filter.MessageType,
+		Since:       filter.StartDate,
+		Until:       filter.EndDate,
+		Limit:       filter.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	results := make([]*MessageSearchResult, 0, len(matches))
+	for _, match := range matches {
+		userID, _ := strconv.Atoi(match.Message.UserID)
+		roomID, _ := strconv.Atoi(match.Message.ChannelID)
+		results = append(results, &MessageSearchResult{
+			Message: &client.Message{
+				ID:        int(match.Message.ID),
+				Content:   match.Message.Content,
+				UserID:    userID,
+				Username:  match.Message.Username,
+				RoomID:    roomID,
+				Timestamp: match.Message.Timestamp,
+				Edited:    match.Message.EditedAt != nil,
+				EditedAt:  match.Message.EditedAt,
+			},
+			Relevance:  -match.Rank, // bm25 scores fall as relevance rises
+			Highlights: match.Highlights(),
+			Snippet:    match.Snippet,
+		})
+	}
+
+	h.logger.Info("Found %d matching messages", len(results))
+	return results, nil
+}
+
 // searchInConversation searches messages in a specific conversation
 func (h *HistoryManager) searchInConversation(ctx context.Context, userID string, filter *MessageFilter) ([]*MessageSearchResult, error) {
-	var allMessages []client.Message
-	page := 1
-	limit := 50
-
 	// Fetch all messages from conversation
-	for {
-		resp, err := h.client.GetMessages(userID, limit, page)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(resp.Messages) == 0 {
-			break
-		}
-
-		allMessages = append(allMessages, resp.Messages...)
-
-		if !resp.HasNext {
-			break
-		}
-		page++
+	allMessages, err := h.conversationMessages(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	var results []*MessageSearchResult
 
 	for i, msg := range allMessages {
 		if h.matchesFilter(&msg, filter) {
-			relevance := h.calculateRelevance(&msg, filter.Query)
-			if relevance > 0 {
-				result := &MessageSearchResult{
-					Message:    &msg,
-					Relevance:  relevance,
-					Context:    h.getMessageContext(allMessages, i, 2),
-					Highlights: h.getHighlights(msg.Content, filter.Query),
-				}
-				results = append(results, result)
-			}
+			results = append(results, &MessageSearchResult{
+				Message: &msg,
+				Context: h.getMessageContext(allMessages, i, 2),
+			})
 		}
 	}
 
@@ -167,51 +200,6 @@ func (h *HistoryManager) matchesFilter(msg *client.Message, filter *MessageFilte
 	return true
 }
 
-// calculateRelevance calculates search relevance score
-func (h *HistoryManager) calculateRelevance(msg *client.Message, query string) float64 {
-	if query == "" {
-		return 1.0
-	}
-
-	query = strings.ToLower(query)
-	content := strings.ToLower(msg.Content)
-
-	var score float64
-
-	// Exact match gets highest score
-	if strings.Contains(content, query) {
-		score += 1.0
-	}
-
-	// Word matches
-	queryWords := strings.Fields(query)
-	contentWords := strings.Fields(content)
-
-	matchedWords := 0
-	for _, qWord := range queryWords {
-		for _, cWord := range contentWords {
-			if strings.Contains(cWord, qWord) {
-				matchedWords++
-				break
-			}
-		}
-	}
-
-	if len(queryWords) > 0 {
-		score += float64(matchedWords) / float64(len(queryWords)) * 0.5
-	}
-
-	// Boost recent messages
-	age := time.Since(msg.Timestamp)
-	if age < 24*time.Hour {
-		score *= 1.2
-	} else if age < 7*24*time.Hour {
-		score *= 1.1
-	}
-
-	return score
-}
-
 // getMessageContext returns surrounding messages for context
 func (h *HistoryManager) getMessageContext(messages []client.Message, index, contextSize int) []string {
 	var context []string
@@ -238,24 +226,33 @@ func (h *HistoryManager) getMessageContext(messages []client.Message, index, con
 	return context
 }
 
-// getHighlights extracts highlighted terms from content
-func (h *HistoryManager) getHighlights(content, query string) []string {
-	if query == "" {
-		return nil
-	}
-
-	var highlights []string
-	query = strings.ToLower(query)
-	content = strings.ToLower(content)
+// WalkConversation calls fn for every message in the conversation with userID,
+// newest first, fetching pages lazily. Iteration stops at the first error from fn.
+func (h *HistoryManager) WalkConversation(ctx context.Context, userID string, fn func(msg *client.Message) error) error {
+	pages := client.Paginate(ctx, h.client.MessagePages(userID), client.PaginateOptions{
+		PageSize: conversationPageSize,
+		Prefetch: true,
+	})
+	defer pages.Close()
 
-	queryWords := strings.Fields(query)
-	for _, word := range queryWords {
-		if strings.Contains(content, word) {
-			highlights = append(highlights, word)
+	for pages.Next() {
+		msg := pages.Item()
+		if err := fn(&msg); err != nil {
+			return err
 		}
 	}
 
-	return highlights
+	return pages.Err()
+}
+
+// conversationMessages fetches every message in a conversation, newest first
+func (h *HistoryManager) conversationMessages(ctx context.Context, userID string) ([]client.Message, error) {
+	var messages []client.Message
+	err := h.WalkConversation(ctx, userID, func(msg *client.Message) error {
+		messages = append(messages, *msg)
+		return nil
+	})
+	return messages, err
 }
 
 // GetRecentConversations gets a list of recent conversations
@@ -316,27 +313,10 @@ func (h *HistoryManager) GetRecentConversations(ctx context.Context, limit int)
 func (h *HistoryManager) GetMessageStats(ctx context.Context, userID string) (map[string]interface{}, error) {
 	h.logger.Info("Getting message statistics for user: %s", userID)
 
-	var allMessages []client.Message
-	page := 1
-	limit := 100
-
 	// Fetch all messages
-	for {
-		resp, err := h.client.GetMessages(userID, limit, page)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(resp.Messages) == 0 {
-			break
-		}
-
-		allMessages = append(allMessages, resp.Messages...)
-
-		if !resp.HasNext {
-			break
-		}
-		page++
+	allMessages, err := h.conversationMessages(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(allMessages) == 0 {
@@ -380,27 +360,10 @@ func (h *HistoryManager) GetMessageStats(ctx context.Context, userID string) (ma
 func (h *HistoryManager) ExportConversation(ctx context.Context, userID string, format string) ([]byte, error) {
 	h.logger.Info("Exporting conversation with user: %s (format: %s)", userID, format)
 
-	var allMessages []client.Message
-	page := 1
-	limit := 100
-
 	// Fetch all messages
-	for {
-		resp, err := h.client.GetMessages(userID, limit, page)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(resp.Messages) == 0 {
-			break
-		}
-
-		allMessages = append(allMessages, resp.Messages...)
-
-		if !resp.HasNext {
-			break
-		}
-		page++
+	allMessages, err := h.conversationMessages(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Reverse to get chronological order
@@ -120,8 +120,10 @@ func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
 		logLine = l.colorizeLogLine(level, logLine)
 	}
 
-	// Write to output
-	fmt.Fprintln(l.output, logLine)
+	// Write to output; a nil writer discards the line
+	if l.output != nil {
+		fmt.Fprintln(l.output, logLine)
+	}
 
 	// Exit on fatal errors
 	if level == FATAL {
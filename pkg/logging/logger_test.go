@@ -136,6 +136,14 @@ func TestLogger_Prefix(t *testing.T) {
 	}
 }
 
+func TestLogger_NilOutput(t *testing.T) {
+	logger := NewLogger(INFO, nil, true)
+
+	// Must not panic
+	logger.Info("discarded message")
+	logger.Error("discarded error")
+}
+
 func TestToASCII(t *testing.T) {
 	tests := []struct {
 		input    string
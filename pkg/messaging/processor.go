@@ -189,6 +189,10 @@ func (mp *MessageProcessor) ProcessMessage(ctx context.Context, rawMsg *database
 		}
 	}
 
+	if mp.db != nil {
+		mp.loadRelations(ctx, rawMsg, processed)
+	}
+
 	// Apply filters
 	mp.mu.RLock()
 	filters := make([]MessageFilter, len(mp.filters))
@@ -220,6 +224,45 @@ func (mp *MessageProcessor) ProcessMessage(ctx context.Context, rawMsg *database
 	return processed, nil
 }
 
+// loadRelations fills a message's reactions and thread from the database
+func (mp *MessageProcessor) loadRelations(ctx context.Context, rawMsg *database.Message, processed *ProcessedMessage) {
+	counts, err := mp.db.GetReactionCounts(ctx, rawMsg.ID)
+	if err != nil {
+		mp.logger.Error("Failed to load reactions for message %d: %v", rawMsg.ID, err)
+	}
+	for _, count := range counts[rawMsg.ID] {
+		processed.Reactions = append(processed.Reactions, Reaction{
+			Emoji: count.Emoji,
+			Users: count.Users,
+			Count: count.Count,
+		})
+	}
+
+	// A reply belongs to its parent's thread
+	rootID := rawMsg.ID
+	if rawMsg.ParentID != nil {
+		rootID = *rawMsg.ParentID
+	}
+
+	thread, err := mp.db.GetThread(ctx, rootID)
+	if err != nil {
+		mp.logger.Error("Failed to load thread for message %d: %v", rawMsg.ID, err)
+		return
+	}
+	if thread == nil {
+		return
+	}
+
+	processed.Thread = &ThreadInfo{
+		ParentID:     thread.ParentID,
+		ReplyCount:   thread.ReplyCount,
+		Participants: thread.Participants,
+	}
+	if thread.LastReplyAt != nil {
+		processed.Thread.LastReplyAt = *thread.LastReplyAt
+	}
+}
+
 // TextMessageHandler handles plain text messages
 type TextMessageHandler struct{}
 
@@ -19,6 +19,8 @@ type RealtimeManager struct {
 	db          *database.Database
 	processor   *messaging.MessageProcessor
 	logger      *logging.Logger
+	userID      string // Local user, whose read state is tracked
+	username    string
 	subscribers map[string][]EventSubscriber
 	eventQueue  chan *Event
 	mu          sync.RWMutex
@@ -46,6 +48,7 @@ const (
 	EventTypeNotification  EventType = "notification"
 	EventTypeFileUpload    EventType = "file_upload"
 	EventTypeSystemMessage EventType = "system_message"
+	EventTypeUnread        EventType = "unread"
 )
 
 // Event represents a real-time event
@@ -101,6 +104,15 @@ func NewRealtimeManager(wsClient *websocket.Client, db *database.Database) *Real
 	}
 }
 
+// SetUser sets the local user whose unread counts the manager keeps
+func (rm *RealtimeManager) SetUser(userID, username string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.userID = userID
+	rm.username = username
+}
+
 // Start starts the real-time manager
 func (rm *RealtimeManager) Start() error {
 	rm.mu.Lock()
@@ -290,6 +302,102 @@ func (rm *RealtimeManager) handleWebSocketMessages() {
 	}
 }
 
+// ReceiveMessage stores a message that arrived over the connection and publishes
+// it, followed by the channel's new unread counts. The local user's own messages
+// count as read. The message's channel and author must already be stored.
+//
+// Neither the CLI nor the GUI feeds received messages in here yet, so their
+// unread counts stay empty until an embedding application does.
+func (rm *RealtimeManager) ReceiveMessage(ctx context.Context, msg *database.Message) error {
+	if err := rm.db.SaveMessage(ctx, msg); err != nil {
+		return err
+	}
+
+	rm.mu.RLock()
+	userID := rm.userID
+	rm.mu.RUnlock()
+
+	if userID != "" && msg.UserID == userID {
+		if err := rm.db.SetReadPosition(ctx, userID, msg.ChannelID, msg.ID); err != nil {
+			return err
+		}
+	}
+
+	processed, err := rm.processor.ProcessMessage(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to process message %d: %w", msg.ID, err)
+	}
+
+	rm.PublishEvent(&Event{
+		ID:        fmt.Sprintf("message_%d", msg.ID),
+		Type:      EventTypeMessage,
+		ChannelID: msg.ChannelID,
+		UserID:    msg.UserID,
+		Timestamp: msg.Timestamp,
+		Data:      map[string]interface{}{"message": processed},
+	})
+
+	return rm.publishUnread(ctx, msg.ChannelID)
+}
+
+// MarkRead marks a channel read up to its newest message
+func (rm *RealtimeManager) MarkRead(ctx context.Context, channelID string) error {
+	rm.mu.RLock()
+	userID := rm.userID
+	rm.mu.RUnlock()
+
+	if userID == "" {
+		return fmt.Errorf("no user set")
+	}
+	if _, err := rm.db.MarkChannelRead(ctx, userID, channelID); err != nil {
+		return err
+	}
+
+	return rm.publishUnread(ctx, channelID)
+}
+
+// UnreadCounts returns the local user's unread counts, keyed by channel ID
+func (rm *RealtimeManager) UnreadCounts(ctx context.Context) (map[string]*database.UnreadCount, error) {
+	rm.mu.RLock()
+	userID, username := rm.userID, rm.username
+	rm.mu.RUnlock()
+
+	if userID == "" {
+		return nil, fmt.Errorf("no user set")
+	}
+	return rm.db.GetUnreadCounts(ctx, userID, username)
+}
+
+// publishUnread publishes the local user's unread counts for a channel, if a user is set
+func (rm *RealtimeManager) publishUnread(ctx context.Context, channelID string) error {
+	rm.mu.RLock()
+	userID, username := rm.userID, rm.username
+	rm.mu.RUnlock()
+
+	if userID == "" {
+		return nil
+	}
+
+	count, err := rm.db.GetUnreadCount(ctx, userID, username, channelID)
+	if err != nil {
+		return err
+	}
+
+	rm.PublishEvent(&Event{
+		ID:        fmt.Sprintf("unread_%s_%d", channelID, time.Now().UnixNano()),
+		Type:      EventTypeUnread,
+		ChannelID: channelID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"last_read_id": count.LastReadID,
+			"unread":       count.Unread,
+			"mentions":     count.Mentions,
+		},
+	})
+	return nil
+}
+
 // periodicTasks runs periodic maintenance tasks
 func (rm *RealtimeManager) periodicTasks() {
 	ticker := time.NewTicker(30 * time.Second)
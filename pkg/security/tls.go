@@ -0,0 +1,196 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TransportConfig describes how outbound connections to a PlexiChat server are
+// secured and routed. The same config is applied to REST, WebSocket and updater
+// traffic so every connection trusts the same CAs and goes through the same proxy.
+type TransportConfig struct {
+	CACertFile         string   `json:"ca_cert,omitempty"`              // PEM bundle trusted in addition to the system roots
+	ClientCertFile     string   `json:"client_cert,omitempty"`          // PEM client certificate for mutual TLS
+	ClientKeyFile      string   `json:"client_key,omitempty"`           // PEM private key matching ClientCertFile
+	InsecureSkipVerify bool     `json:"insecure_skip_verify,omitempty"` // Disable chain verification (pins still apply)
+	ServerName         string   `json:"server_name,omitempty"`          // Override the name checked against the certificate
+	PinnedKeys         []string `json:"pinned_keys,omitempty"`          // SHA-256 of a certificate's public key, "sha256/<base64>" or hex
+	ProxyURL           string   `json:"proxy_url,omitempty"`            // http, https or socks5 proxy; empty uses the environment
+}
+
+// TLSConfig builds the client TLS configuration
+func (tc *TransportConfig) TLSConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if tc == nil {
+		return config, nil
+	}
+
+	config.InsecureSkipVerify = tc.InsecureSkipVerify
+	config.ServerName = tc.ServerName
+
+	if tc.CACertFile != "" {
+		pem, err := os.ReadFile(tc.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", tc.CACertFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if tc.ClientCertFile != "" || tc.ClientKeyFile != "" {
+		if tc.ClientCertFile == "" || tc.ClientKeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be configured together")
+		}
+		cert, err := tls.LoadX509KeyPair(tc.ClientCertFile, tc.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(tc.PinnedKeys) > 0 {
+		pins, err := parsePins(tc.PinnedKeys)
+		if err != nil {
+			return nil, err
+		}
+		insecure := tc.InsecureSkipVerify
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(pinCandidates(state, insecure), pins)
+		}
+	}
+
+	return config, nil
+}
+
+// Proxy returns the proxy selector for http.Transport. Without an explicit
+// ProxyURL the standard HTTP_PROXY/HTTPS_PROXY/NO_PROXY variables are honoured.
+func (tc *TransportConfig) Proxy() (func(*http.Request) (*url.URL, error), error) {
+	if tc == nil || tc.ProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := url.Parse(tc.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", proxyURL.Scheme)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// Apply configures an existing transport with the TLS and proxy settings
+func (tc *TransportConfig) Apply(transport *http.Transport) error {
+	tlsConfig, err := tc.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	proxy, err := tc.Proxy()
+	if err != nil {
+		return err
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = proxy
+	return nil
+}
+
+// NewTransport returns an http.Transport with Go's default pooling and timeouts
+// plus the configured TLS and proxy settings
+func (tc *TransportConfig) NewTransport() (*http.Transport, error) {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if err := tc.Apply(transport); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
+
+// PublicKeyPin returns the "sha256/<base64>" pin of a certificate's public key
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parsePins decodes pins given as "sha256/<base64>", bare base64 or hex
+func parsePins(pins []string) ([][]byte, error) {
+	decoded := make([][]byte, 0, len(pins))
+	for _, pin := range pins {
+		value := strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+
+		var sum []byte
+		if raw, err := hex.DecodeString(value); err == nil && len(raw) == sha256.Size {
+			sum = raw
+		} else if raw, err := base64.StdEncoding.DecodeString(value); err == nil && len(raw) == sha256.Size {
+			sum = raw
+		} else {
+			return nil, fmt.Errorf("invalid certificate pin %q: expected a SHA-256 digest", pin)
+		}
+
+		decoded = append(decoded, sum)
+	}
+	return decoded, nil
+}
+
+// pinCandidates returns the certificates a pin may match. The peer can send any
+// certificates it likes, so only those in a verified chain count; without
+// verification that is just the leaf, which the peer must hold the key for.
+func pinCandidates(state tls.ConnectionState, insecure bool) []*x509.Certificate {
+	if insecure {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		return state.PeerCertificates[:1]
+	}
+
+	var certs []*x509.Certificate
+	for _, chain := range state.VerifiedChains {
+		certs = append(certs, chain...)
+	}
+	return certs
+}
+
+// verifyPins accepts the connection if any of the certificates matches a pin
+func verifyPins(certs []*x509.Certificate, pins [][]byte) error {
+	for _, cert := range certs {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if string(sum[:]) == string(pin) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("server certificate does not match any pinned key")
+}
@@ -0,0 +1,145 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and its key
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate for 127.0.0.1, signed by parent or self-signed
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+// newChainServer serves TLS with leaf's key, sending leaf followed by extra
+func newChainServer(t *testing.T, leaf *testCert, extra ...*testCert) *httptest.Server {
+	chain := [][]byte{leaf.cert.Raw}
+	for _, cert := range extra {
+		chain = append(chain, cert.cert.Raw)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: chain, PrivateKey: leaf.key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeCAFile(t *testing.T, ca *testCert) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+	return path
+}
+
+func TestTransportConfig_PinnedKeys(t *testing.T) {
+	ca := newTestCert(t, "Test CA", true, nil)
+	pinned := newTestCert(t, "Pinned", false, nil) // The certificate the client trusts
+	forged := newTestCert(t, "Forged", false, nil) // An attacker's self-signed leaf
+	issued := newTestCert(t, "Issued", false, ca)  // A leaf the CA vouches for
+	caFile := writeCAFile(t, ca)
+
+	tests := []struct {
+		name     string
+		server   *httptest.Server
+		config   TransportConfig
+		expected bool
+	}{
+		{
+			name:     "unverified leaf matches pin",
+			server:   newChainServer(t, pinned),
+			config:   TransportConfig{InsecureSkipVerify: true, PinnedKeys: []string{PublicKeyPin(pinned.cert)}},
+			expected: true,
+		},
+		{
+			name:     "forged leaf with pinned certificate appended",
+			server:   newChainServer(t, forged, pinned),
+			config:   TransportConfig{InsecureSkipVerify: true, PinnedKeys: []string{PublicKeyPin(pinned.cert)}},
+			expected: false,
+		},
+		{
+			name:     "verified chain matches pinned CA",
+			server:   newChainServer(t, issued),
+			config:   TransportConfig{CACertFile: caFile, PinnedKeys: []string{PublicKeyPin(ca.cert)}},
+			expected: true,
+		},
+		{
+			name:     "unverified extra certificate matches pin",
+			server:   newChainServer(t, issued, pinned),
+			config:   TransportConfig{CACertFile: caFile, PinnedKeys: []string{PublicKeyPin(pinned.cert)}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := tt.config.NewTransport()
+			if err != nil {
+				t.Fatalf("Failed to build transport: %v", err)
+			}
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(tt.server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != tt.expected {
+				t.Errorf("Expected connected=%v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
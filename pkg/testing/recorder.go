@@ -0,0 +1,655 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"plexichat-client/pkg/client"
+
+	"github.com/gorilla/websocket"
+)
+
+// RecordMode selects whether a Recorder talks to a real server or replays a cassette
+type RecordMode string
+
+const (
+	ModeReplay RecordMode = "replay"
+	ModeRecord RecordMode = "record"
+)
+
+// RecordEnvVar switches recorders created with ModeFromEnv into record mode
+const RecordEnvVar = "PLEXICHAT_RECORD"
+
+// redactedValue replaces secrets in recorded fixtures
+const redactedValue = "[REDACTED]"
+
+// Frame directions in a WebSocket transcript
+const (
+	FrameSent     = "send"
+	FrameReceived = "receive"
+)
+
+// ModeFromEnv returns ModeRecord when PLEXICHAT_RECORD is set to a true value
+func ModeFromEnv() RecordMode {
+	if record, _ := strconv.ParseBool(os.Getenv(RecordEnvVar)); record {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Cassette is the fixture file holding recorded HTTP and WebSocket traffic
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+	WebSockets   []*Transcript  `json:"websockets,omitempty"`
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+	used     bool
+}
+
+// RecordedRequest is the redacted form of an outgoing request
+type RecordedRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body,omitempty"`
+	Encoding string      `json:"encoding,omitempty"`
+}
+
+// RecordedResponse is the redacted form of a server response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Encoding   string      `json:"encoding,omitempty"`
+}
+
+// Transcript is the ordered list of frames exchanged over one WebSocket connection
+type Transcript struct {
+	URL        string  `json:"url"`
+	StatusCode int     `json:"status_code,omitempty"` // Set when the handshake was rejected
+	Frames     []Frame `json:"frames"`
+	used       bool
+}
+
+// Frame is a single WebSocket data message
+type Frame struct {
+	Direction string `json:"direction"`
+	Type      int    `json:"type"`
+	Data      string `json:"data"`
+	Encoding  string `json:"encoding,omitempty"`
+}
+
+// Recorder is an http.RoundTripper and WebSocket relay that records traffic to a
+// cassette or replays it without a server
+type Recorder struct {
+	path     string
+	mode     RecordMode
+	cassette *Cassette
+	upstream http.RoundTripper
+
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+
+	wsServer   *httptest.Server
+	wsBase     string
+	wsUpstream *websocket.Dialer
+	upgrader   websocket.Upgrader
+	errs       []error
+
+	mu sync.Mutex
+}
+
+// NewRecorder creates a recorder for the cassette at path
+func NewRecorder(path string, mode RecordMode) *Recorder {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		cassette: &Cassette{},
+		upstream: http.DefaultTransport,
+		redactHeaders: map[string]bool{
+			"Authorization": true,
+			"X-Api-Key":     true,
+			"Cookie":        true,
+			"Set-Cookie":    true,
+		},
+		redactFields: map[string]bool{
+			"password":      true,
+			"token":         true,
+			"access_token":  true,
+			"refresh_token": true,
+			"api_key":       true,
+			"secret":        true,
+			"client_secret": true,
+			"totp_code":     true,
+			"backup_code":   true,
+		},
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+	return r
+}
+
+// Mode returns the recorder mode
+func (r *Recorder) Mode() RecordMode {
+	return r.mode
+}
+
+// RedactHeaders adds header names whose values are never written to the cassette
+func (r *Recorder) RedactHeaders(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.redactHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+}
+
+// RedactFields adds JSON field and query parameter names whose values are never written to the cassette
+func (r *Recorder) RedactFields(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.redactFields[strings.ToLower(name)] = true
+	}
+}
+
+// Start loads the cassette in replay mode; in record mode it starts an empty one
+func (r *Recorder) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		r.cassette = &Cassette{}
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return fmt.Errorf("failed to parse cassette %s: %w", r.path, err)
+	}
+	r.cassette = &cassette
+	return nil
+}
+
+// Stop shuts down the WebSocket relay and, in record mode, writes the cassette.
+// Transcript mismatches seen during replay are returned as an error.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	server := r.wsServer
+	r.wsServer = nil
+	r.mu.Unlock()
+
+	if server != nil {
+		server.CloseClientConnections()
+		server.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		if err := r.save(); err != nil {
+			return err
+		}
+	}
+	return errors.Join(r.errs...)
+}
+
+// save writes the cassette as indented JSON so fixtures diff cleanly
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	if err := os.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Attach routes the client's REST requests and WebSocket connections through the recorder
+func (r *Recorder) Attach(c *client.Client) {
+	r.mu.Lock()
+	if c.HTTPClient.Transport != nil && c.HTTPClient.Transport != http.RoundTripper(r) {
+		r.upstream = c.HTTPClient.Transport
+	}
+	r.wsUpstream = c.WebSocketDialer()
+	r.wsBase = strings.Replace(c.BaseURL, "http://", "ws://", 1)
+	r.wsBase = strings.Replace(r.wsBase, "https://", "wss://", 1)
+	if r.wsServer == nil {
+		r.wsServer = httptest.NewServer(http.HandlerFunc(r.serveWebSocket))
+	}
+	addr := r.wsServer.Listener.Addr().String()
+	r.mu.Unlock()
+
+	c.HTTPClient.Transport = r
+
+	// Every WebSocket dial, plain or TLS, lands on the local relay
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	c.SetWebSocketDialer(&websocket.Dialer{
+		NetDialContext:    dial,
+		NetDialTLSContext: dial,
+		HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+	})
+}
+
+// RoundTrip records or replays a single HTTP exchange
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	recorded := r.recordRequest(req, body)
+	r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+// record forwards the request upstream and stores the redacted exchange
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interaction := &Interaction{
+		Request:  recorded,
+		Response: RecordedResponse{StatusCode: resp.StatusCode, Header: r.redactHeader(resp.Header)},
+	}
+	interaction.Response.Body, interaction.Response.Encoding = encodeBody(r.redactBody(respBody))
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return resp, nil
+}
+
+// replay returns the first unused recorded response matching the request
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.used || !sameRequest(interaction.Request, recorded) {
+			continue
+		}
+		interaction.used = true
+
+		body, err := decodeBody(interaction.Response.Body, interaction.Response.Encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %s in %s", recorded.Method, recorded.URL, r.path)
+}
+
+// sameRequest matches requests on method, URL and body; headers are ignored
+func sameRequest(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.URL == b.URL && a.Body == b.Body && a.Encoding == b.Encoding
+}
+
+// recordRequest builds the redacted, host-independent form of a request
+func (r *Recorder) recordRequest(req *http.Request, body []byte) RecordedRequest {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    r.redactURL(req.URL),
+		Header: r.redactHeader(req.Header),
+	}
+	recorded.Body, recorded.Encoding = encodeBody(r.redactBody(body))
+	return recorded
+}
+
+// readRequestBody reads the request body and leaves a fresh copy on the request
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// redactURL drops the host and masks secret query parameters
+func (r *Recorder) redactURL(u *url.URL) string {
+	query := u.Query()
+	for key := range query {
+		if r.redactFields[strings.ToLower(key)] {
+			query.Set(key, redactedValue)
+		}
+	}
+
+	recorded := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return recorded.String()
+}
+
+// redactHeader copies a header with secret values masked
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	redacted := header.Clone()
+	for key := range redacted {
+		if r.redactHeaders[http.CanonicalHeaderKey(key)] {
+			redacted[key] = []string{redactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactBody masks secret fields in JSON bodies; other bodies are kept as-is
+func (r *Recorder) redactBody(body []byte) []byte {
+	var value interface{}
+	if len(body) == 0 || json.Unmarshal(body, &value) != nil {
+		return body
+	}
+
+	if !r.redactValue(value) {
+		return body
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue masks secret fields in place and reports whether anything changed
+func (r *Recorder) redactValue(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.redactFields[strings.ToLower(key)] {
+				if field != redactedValue {
+					v[key] = redactedValue
+					changed = true
+				}
+				continue
+			}
+			if r.redactValue(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if r.redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// encodeBody stores text bodies verbatim and binary bodies as base64
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeBody reverses encodeBody
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body in cassette: %w", err)
+		}
+		return data, nil
+	}
+	return []byte(body), nil
+}
+
+// serveWebSocket handles connections redirected by Attach
+func (r *Recorder) serveWebSocket(w http.ResponseWriter, req *http.Request) {
+	if r.mode == ModeRecord {
+		r.relayWebSocket(w, req)
+		return
+	}
+	r.replayWebSocket(w, req)
+}
+
+// relayWebSocket proxies a connection upstream and records every data frame
+func (r *Recorder) relayWebSocket(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	target := r.wsBase + req.URL.RequestURI()
+	dialer := r.wsUpstream
+	transcript := &Transcript{URL: r.redactURL(req.URL)}
+	r.cassette.WebSockets = append(r.cassette.WebSockets, transcript)
+	r.mu.Unlock()
+
+	header := http.Header{}
+	for key, values := range req.Header {
+		switch http.CanonicalHeaderKey(key) {
+		case "Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions":
+			continue
+		}
+		header[key] = values
+	}
+
+	upstream, resp, err := dialer.DialContext(req.Context(), target, header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		r.mu.Lock()
+		transcript.StatusCode = status
+		r.mu.Unlock()
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer upstream.Close()
+
+	conn, err := r.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	done := make(chan struct{}, 2)
+	go r.pump(conn, upstream, transcript, FrameSent, done)
+	go r.pump(upstream, conn, transcript, FrameReceived, done)
+	<-done
+}
+
+// pump copies frames from src to dst, appending them to the transcript
+func (r *Recorder) pump(src, dst *websocket.Conn, transcript *Transcript, direction string, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			// Pass the close code on so the other side sees the same shutdown
+			code := websocket.CloseNormalClosure
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code != websocket.CloseNoStatusReceived {
+				code = closeErr.Code
+			}
+			dst.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""))
+			return
+		}
+
+		r.mu.Lock()
+		frame := Frame{Direction: direction, Type: messageType}
+		frame.Data, frame.Encoding = encodeBody(r.redactBody(data))
+		transcript.Frames = append(transcript.Frames, frame)
+		r.mu.Unlock()
+
+		if err := dst.WriteMessage(messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+// replayWebSocket plays back the next unused transcript recorded for the URL.
+// Received frames are written in order; sent frames are read and compared.
+func (r *Recorder) replayWebSocket(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	recordedURL := r.redactURL(req.URL)
+	var transcript *Transcript
+	for _, candidate := range r.cassette.WebSockets {
+		if !candidate.used && candidate.URL == recordedURL {
+			candidate.used = true
+			transcript = candidate
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if transcript == nil {
+		r.fail(fmt.Errorf("no recorded WebSocket transcript for %s in %s", recordedURL, r.path))
+		http.Error(w, "no recorded transcript", http.StatusNotFound)
+		return
+	}
+	if transcript.StatusCode != 0 {
+		http.Error(w, http.StatusText(transcript.StatusCode), transcript.StatusCode)
+		return
+	}
+
+	conn, err := r.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for i, frame := range transcript.Frames {
+		data, err := decodeBody(frame.Data, frame.Encoding)
+		if err != nil {
+			r.fail(err)
+			return
+		}
+
+		if frame.Direction == FrameReceived {
+			if err := conn.WriteMessage(frame.Type, data); err != nil {
+				return
+			}
+			continue
+		}
+
+		_, sent, err := conn.ReadMessage()
+		if err != nil {
+			r.fail(fmt.Errorf("WebSocket %s closed before frame %d was sent: %w", recordedURL, i, err))
+			return
+		}
+
+		r.mu.Lock()
+		redacted := r.redactBody(sent)
+		r.mu.Unlock()
+		if !bytes.Equal(redacted, data) {
+			r.fail(fmt.Errorf("WebSocket %s frame %d: expected %q, got %q", recordedURL, i, data, redacted))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseProtocolError, "unexpected frame"))
+			return
+		}
+	}
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// fail records a replay mismatch reported by Stop
+func (r *Recorder) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+// UseCassette wires a recorder into the suite's setup and teardown. Tests attach
+// their clients with the returned recorder's Attach.
+func (s *TestSuite) UseCassette(path string, mode RecordMode) *Recorder {
+	recorder := NewRecorder(path, mode)
+
+	setup := s.SetupFunc
+	s.SetupFunc = func() error {
+		if err := recorder.Start(); err != nil {
+			return err
+		}
+		if setup != nil {
+			return setup()
+		}
+		return nil
+	}
+
+	teardown := s.TeardownFunc
+	s.TeardownFunc = func() error {
+		var teardownErr error
+		if teardown != nil {
+			teardownErr = teardown()
+		}
+		return errors.Join(teardownErr, recorder.Stop())
+	}
+
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]interface{})
+	}
+	s.Metadata["cassette"] = path
+	s.Metadata["record_mode"] = string(mode)
+	return recorder
+}
@@ -0,0 +1,222 @@
+package testing_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"plexichat-client/pkg/client"
+	ptesting "plexichat-client/pkg/testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func newAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "secret-access",
+			"refresh_token": "secret-refresh",
+			"username":      "alice",
+		})
+	})
+	mux.HandleFunc("/api/v1/auth/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "1", "username": "alice"})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"welcome"}`))
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, append([]byte("echo:"), data...))
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func newRecordedClient(baseURL string, recorder *ptesting.Recorder) *client.Client {
+	c := client.NewClient(baseURL)
+	c.SetRetryConfig(0, 0) // No retries for test
+	c.SetAdvancedRetryConfig(client.RetryConfig{})
+	recorder.Attach(c)
+	return c
+}
+
+func TestRecorder_RecordAndReplayHTTP(t *testing.T) {
+	server := newAPIServer(t)
+	cassette := filepath.Join(t.TempDir(), "fixtures", "login.json")
+
+	recorder := ptesting.NewRecorder(cassette, ptesting.ModeRecord)
+	if err := recorder.Start(); err != nil {
+		t.Fatalf("Expected no error starting recorder, got %v", err)
+	}
+	c := newRecordedClient(server.URL, recorder)
+
+	login, err := c.Login(context.Background(), "alice", "hunter2")
+	if err != nil {
+		t.Fatalf("Expected login to succeed, got %v", err)
+	}
+	c.SetToken(login.AccessToken)
+	if _, err := c.GetCurrentUser(context.Background()); err != nil {
+		t.Fatalf("Expected GetCurrentUser to succeed, got %v", err)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Expected no error stopping recorder, got %v", err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("Expected cassette to be written, got %v", err)
+	}
+	for _, secret := range []string{"hunter2", "secret-access", "secret-refresh"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be redacted from the cassette", secret)
+		}
+	}
+
+	// Replay runs without the server
+	replayer := ptesting.NewRecorder(cassette, ptesting.ModeReplay)
+	if err := replayer.Start(); err != nil {
+		t.Fatalf("Expected no error loading cassette, got %v", err)
+	}
+	c = newRecordedClient(server.URL, replayer)
+
+	if _, err := c.Login(context.Background(), "alice", "a-different-password"); err != nil {
+		t.Fatalf("Expected replayed login to succeed, got %v", err)
+	}
+	user, err := c.GetCurrentUser(context.Background())
+	if err != nil {
+		t.Fatalf("Expected replayed GetCurrentUser to succeed, got %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("Expected username alice, got %s", user.Username)
+	}
+
+	// Each recorded interaction is served once
+	if _, err := c.GetCurrentUser(context.Background()); err == nil {
+		t.Error("Expected an error for a request missing from the cassette")
+	}
+}
+
+func TestRecorder_WebSocketTranscript(t *testing.T) {
+	server := newAPIServer(t)
+	cassette := filepath.Join(t.TempDir(), "ws.json")
+
+	recorder := ptesting.NewRecorder(cassette, ptesting.ModeRecord)
+	recorder.Start()
+	c := newRecordedClient(server.URL, recorder)
+	c.SetToken("secret-access")
+
+	converse := func(c *client.Client, message string) []string {
+		conn, err := c.ConnectWebSocket(context.Background(), "/ws")
+		if err != nil {
+			t.Fatalf("Expected WebSocket to connect, got %v", err)
+		}
+		defer conn.Close()
+
+		var received []string
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Expected welcome frame, got %v", err)
+		}
+		received = append(received, string(data))
+
+		conn.WriteMessage(websocket.TextMessage, []byte(message))
+		if _, data, err = conn.ReadMessage(); err == nil {
+			received = append(received, string(data))
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		return received
+	}
+
+	recorded := converse(c, "hello")
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Expected no error stopping recorder, got %v", err)
+	}
+	server.Close()
+
+	replayer := ptesting.NewRecorder(cassette, ptesting.ModeReplay)
+	if err := replayer.Start(); err != nil {
+		t.Fatalf("Expected no error loading cassette, got %v", err)
+	}
+	c = newRecordedClient(server.URL, replayer)
+
+	replayed := converse(c, "hello")
+	if strings.Join(replayed, "|") != strings.Join(recorded, "|") {
+		t.Errorf("Expected replayed frames %v, got %v", recorded, replayed)
+	}
+	if err := replayer.Stop(); err != nil {
+		t.Errorf("Expected a clean replay, got %v", err)
+	}
+
+	// A client that sends something else is reported as a mismatch
+	replayer = ptesting.NewRecorder(cassette, ptesting.ModeReplay)
+	replayer.Start()
+	c = newRecordedClient(server.URL, replayer)
+	converse(c, "goodbye")
+	if err := replayer.Stop(); err == nil {
+		t.Error("Expected a transcript mismatch error")
+	}
+}
+
+func TestTestSuite_UseCassette(t *testing.T) {
+	server := newAPIServer(t)
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "suite.json")
+
+	run := func(mode ptesting.RecordMode) *ptesting.TestResult {
+		suite := &ptesting.TestSuite{Name: "client", Type: ptesting.TestTypeAPI}
+		recorder := suite.UseCassette(cassette, mode)
+		suite.Tests = []*ptesting.TestCase{{
+			Name: "health",
+			TestFunc: func(tc *ptesting.TestContext) error {
+				c := newRecordedClient(server.URL, recorder)
+				_, err := c.Login(context.Background(), "alice", "hunter2")
+				return err
+			},
+		}}
+
+		framework := ptesting.NewTestFramework(nil)
+		results, err := framework.RunSuite(context.Background(), suite)
+		if err != nil {
+			t.Fatalf("Expected suite to run, got %v", err)
+		}
+		if suite.Metadata["cassette"] != cassette {
+			t.Errorf("Expected cassette path in suite metadata, got %v", suite.Metadata["cassette"])
+		}
+		return results[0]
+	}
+
+	if result := run(ptesting.ModeRecord); result.Status != ptesting.StatusPassed {
+		t.Fatalf("Expected recording run to pass, got %s: %s", result.Status, result.Error)
+	}
+	if _, err := os.Stat(cassette); err != nil {
+		t.Fatalf("Expected suite teardown to write the cassette, got %v", err)
+	}
+
+	server.Close()
+	if result := run(ptesting.ModeReplay); result.Status != ptesting.StatusPassed {
+		t.Errorf("Expected replay run to pass without a server, got %s: %s", result.Status, result.Error)
+	}
+}
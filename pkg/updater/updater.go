@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/security"
 )
 
 const (
@@ -83,6 +84,17 @@ func NewUpdater() *Updater {
 	}
 }
 
+// SetTransportConfig routes update checks and downloads through the configured
+// proxy and TLS settings
+func (u *Updater) SetTransportConfig(config *security.TransportConfig) error {
+	transport, err := config.NewTransport()
+	if err != nil {
+		return fmt.Errorf("invalid transport configuration: %w", err)
+	}
+	u.httpClient.Transport = transport
+	return nil
+}
+
 // CheckForUpdates checks if a new version is available
 func (u *Updater) CheckForUpdates(ctx context.Context) (*UpdateInfo, error) {
 	logging.Info("Checking for updates...")
@@ -454,7 +466,7 @@ func (u *Updater) downloadUpdate(ctx context.Context, updateInfo *UpdateInfo) (s
 	defer tempFile.Close()
 
 	// Download with timeout
-	client := &http.Client{Timeout: DownloadTimeout}
+	client := &http.Client{Timeout: DownloadTimeout, Transport: u.httpClient.Transport}
 	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
 	if err != nil {
 		return "", err
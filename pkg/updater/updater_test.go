@@ -0,0 +1,76 @@
+package updater
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"plexichat-client/pkg/security"
+)
+
+func TestUpdater_SetTransportConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "` + CurrentVersion + `"}`))
+	}))
+	defer server.Close()
+
+	u := NewUpdater()
+	u.repoURL = server.URL + "/releases"
+
+	// The system roots do not trust the test server
+	if _, err := u.CheckForUpdates(context.Background()); err == nil {
+		t.Fatal("Expected untrusted certificate to be rejected")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+
+	if err := u.SetTransportConfig(&security.TransportConfig{CACertFile: caFile}); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+
+	info, err := u.CheckForUpdates(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdates with custom CA failed: %v", err)
+	}
+	if info.Available {
+		t.Errorf("Expected no update for the current version, got %s", info.LatestVersion)
+	}
+}
+
+func TestUpdater_SetTransportConfigProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"tag_name": "` + CurrentVersion + `"}`))
+	}))
+	defer proxy.Close()
+
+	u := NewUpdater()
+	u.repoURL = "http://releases.internal/repos/plexichat/releases"
+	if err := u.SetTransportConfig(&security.TransportConfig{ProxyURL: proxy.URL}); err != nil {
+		t.Fatalf("SetTransportConfig failed: %v", err)
+	}
+
+	if _, err := u.CheckForUpdates(context.Background()); err != nil {
+		t.Fatalf("CheckForUpdates through proxy failed: %v", err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://releases.internal/repos/plexichat/releases/latest" {
+		t.Errorf("Expected update check to go through the proxy, got %v", proxied)
+	}
+
+	if err := u.SetTransportConfig(&security.TransportConfig{CACertFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("Expected missing CA bundle to be rejected")
+	}
+}
@@ -0,0 +1,179 @@
+package websocket
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"plexichat-client/pkg/database"
+	"plexichat-client/pkg/errors"
+	"plexichat-client/pkg/security"
+)
+
+// ChannelAction is something a client asks to do in a channel
+type ChannelAction string
+
+const (
+	ActionJoin ChannelAction = "join" // Subscribe to the channel's traffic
+	ActionSend ChannelAction = "send" // Broadcast a message to the channel
+)
+
+// authorizeTimeout bounds a single authorization check
+const authorizeTimeout = 5 * time.Second
+
+// Authorizer decides whether a client may join or broadcast to a channel.
+// A denial should be a *errors.PlexiChatError so clients get a useful code.
+type Authorizer interface {
+	AuthorizeChannel(ctx context.Context, client *Client, channelID string, action ChannelAction) error
+}
+
+// ChannelStore is the channel data DatabaseAuthorizer needs; *database.Database implements it
+type ChannelStore interface {
+	GetChannel(ctx context.Context, channelID string) (*database.Channel, error)
+	GetMember(ctx context.Context, channelID, userID string) (*database.ChannelMember, error)
+}
+
+// DatabaseAuthorizer allows public channels to everyone and private channels to
+// their members. Muted members may join but not send.
+type DatabaseAuthorizer struct {
+	store ChannelStore
+}
+
+// NewDatabaseAuthorizer creates an authorizer backed by the channels and channel_members tables
+func NewDatabaseAuthorizer(store ChannelStore) *DatabaseAuthorizer {
+	return &DatabaseAuthorizer{store: store}
+}
+
+// AuthorizeChannel checks the channel exists, that the client's user is a member
+// if it is private, and that they are not muted if sending
+func (a *DatabaseAuthorizer) AuthorizeChannel(ctx context.Context, client *Client, channelID string, action ChannelAction) error {
+	channel, err := a.store.GetChannel(ctx, channelID)
+	if err != nil {
+		if stderrors.Is(err, database.ErrChannelNotFound) {
+			return errors.NewError(errors.ErrorTypeNotFound, "CHANNEL_NOT_FOUND",
+				fmt.Sprintf("Channel %s does not exist", channelID))
+		}
+		return fmt.Errorf("failed to load channel: %w", err)
+	}
+
+	member, err := a.store.GetMember(ctx, channelID, client.UserID)
+	if err != nil && !stderrors.Is(err, database.ErrMemberNotFound) {
+		return err
+	}
+
+	if member == nil {
+		if channel.Private {
+			return errors.NewError(errors.ErrorTypePermission, "CHANNEL_PRIVATE",
+				fmt.Sprintf("Channel %s is private", channelID)).
+				WithSuggestion("Ask a channel member to invite you")
+		}
+		return nil
+	}
+
+	if action == ActionSend && member.Muted {
+		return errors.NewError(errors.ErrorTypePermission, "CHANNEL_MUTED",
+			fmt.Sprintf("You are muted in channel %s", channelID)).
+			WithSuggestion("Ask a channel moderator to unmute you")
+	}
+	return nil
+}
+
+// JWTAuthorizer grants channel access from the permissions in a client's token.
+// "channels:<action>" covers every channel, "channels:<id>:<action>" one channel,
+// and "*" or the admin role covers everything.
+type JWTAuthorizer struct{}
+
+// NewJWTAuthorizer creates an authorizer that reads Client.Claims
+func NewJWTAuthorizer() *JWTAuthorizer {
+	return &JWTAuthorizer{}
+}
+
+// AuthorizeChannel checks the client's claims for a permission covering the action
+func (a *JWTAuthorizer) AuthorizeChannel(ctx context.Context, client *Client, channelID string, action ChannelAction) error {
+	claims := client.Claims
+	if claims == nil {
+		return errors.NewAuthError("TOKEN_REQUIRED", "A validated token is required to use channels")
+	}
+
+	if hasChannelPermission(claims, channelID, action) {
+		return nil
+	}
+	return errors.NewError(errors.ErrorTypePermission, "CHANNEL_FORBIDDEN",
+		fmt.Sprintf("Missing permission to %s channel %s", action, channelID))
+}
+
+// hasChannelPermission reports whether claims allow action in channelID
+func hasChannelPermission(claims *security.JWTClaims, channelID string, action ChannelAction) bool {
+	for _, role := range claims.Roles {
+		if role == "admin" {
+			return true
+		}
+	}
+
+	global := fmt.Sprintf("channels:%s", action)
+	scoped := fmt.Sprintf("channels:%s:%s", channelID, action)
+	for _, permission := range claims.Permissions {
+		if permission == "*" || permission == global || permission == scoped {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAuthorizer makes the hub check joins and client broadcasts; without one every channel is open
+func (h *Hub) SetAuthorizer(authorizer Authorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authorizer = authorizer
+}
+
+// SetTokenValidator makes HandleWebSocket validate bearer tokens and attach their
+// claims to the client, for use by JWTAuthorizer
+func (h *Hub) SetTokenValidator(validate func(token string) (*security.JWTClaims, error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.validateToken = validate
+}
+
+// authorize runs the hub's authorizer, if any. Denials are reported to the client
+// as an error frame and returned.
+func (h *Hub) authorize(client *Client, channelID string, action ChannelAction) error {
+	h.mu.RLock()
+	authorizer := h.authorizer
+	h.mu.RUnlock()
+
+	if authorizer == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authorizeTimeout)
+	defer cancel()
+
+	err := authorizer.AuthorizeChannel(ctx, client, channelID, action)
+	if err == nil {
+		return nil
+	}
+
+	security.LogSecurityEvent("WEBSOCKET_CHANNEL_DENIED", "", "",
+		fmt.Sprintf("User: %s, Channel: %s, Action: %s, Reason: %v", client.Username, channelID, action, err))
+
+	var plexiErr *errors.PlexiChatError
+	if !stderrors.As(err, &plexiErr) {
+		// Internal failures are not described to the client
+		plexiErr = errors.NewServerError("AUTHORIZATION_FAILED", "Could not check channel access")
+	}
+	h.sendError(client, channelID, plexiErr.WithContext("action", string(action)))
+	return err
+}
+
+// sendError sends a client a structured MessageTypeError frame
+func (h *Hub) sendError(client *Client, channelID string, plexiErr *errors.PlexiChatError) {
+	errorMsg := Message{
+		Type:      MessageTypeError,
+		Data:      plexiErr,
+		Timestamp: time.Now(),
+		ChannelID: channelID,
+	}
+	h.sendTo([]*Client{client}, errorMsg, "")
+}
@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"plexichat-client/pkg/database"
+	"plexichat-client/pkg/errors"
+	"plexichat-client/pkg/security"
+)
+
+// fakeChannelStore serves channels and memberships from maps
+type fakeChannelStore struct {
+	channels map[string]*database.Channel
+	members  map[string]*database.ChannelMember // channelID/userID
+}
+
+func (s *fakeChannelStore) GetChannel(ctx context.Context, channelID string) (*database.Channel, error) {
+	channel, ok := s.channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", database.ErrChannelNotFound, channelID)
+	}
+	return channel, nil
+}
+
+func (s *fakeChannelStore) GetMember(ctx context.Context, channelID, userID string) (*database.ChannelMember, error) {
+	member, ok := s.members[channelID+"/"+userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s in %s", database.ErrMemberNotFound, userID, channelID)
+	}
+	return member, nil
+}
+
+func TestDatabaseAuthorizer_AuthorizeChannel(t *testing.T) {
+	authorizer := NewDatabaseAuthorizer(&fakeChannelStore{
+		channels: map[string]*database.Channel{
+			"general": {ID: "general"},
+			"staff":   {ID: "staff", Private: true},
+		},
+		members: map[string]*database.ChannelMember{
+			"staff/1":   {Role: database.RoleOwner},
+			"staff/3":   {Role: database.RoleMember, Muted: true},
+			"general/4": {Role: database.RoleMember, Muted: true},
+		},
+	})
+
+	tests := []struct {
+		channelID string
+		userID    string
+		action    ChannelAction
+		code      string // Empty when allowed
+	}{
+		{"general", "2", ActionJoin, ""},
+		{"general", "2", ActionSend, ""},
+		{"staff", "1", ActionSend, ""},
+		{"staff", "2", ActionJoin, "CHANNEL_PRIVATE"},
+		{"staff", "3", ActionJoin, ""},
+		{"staff", "3", ActionSend, "CHANNEL_MUTED"},
+		{"general", "4", ActionSend, "CHANNEL_MUTED"},
+		{"missing", "1", ActionJoin, "CHANNEL_NOT_FOUND"},
+	}
+
+	for _, test := range tests {
+		client := &Client{UserID: test.userID}
+		err := authorizer.AuthorizeChannel(context.Background(), client, test.channelID, test.action)
+		if test.code == "" {
+			if err != nil {
+				t.Errorf("Expected user %s to %s in %s, got %v", test.userID, test.action, test.channelID, err)
+			}
+			continue
+		}
+
+		plexiErr, ok := err.(*errors.PlexiChatError)
+		if !ok || plexiErr.Code != test.code {
+			t.Errorf("Expected %s for user %s in %s, got %v", test.code, test.userID, test.channelID, err)
+		}
+	}
+}
+
+func TestJWTAuthorizer_AuthorizeChannel(t *testing.T) {
+	authorizer := NewJWTAuthorizer()
+
+	tests := []struct {
+		name     string
+		claims   *security.JWTClaims
+		action   ChannelAction
+		expected bool
+	}{
+		{"no claims", nil, ActionJoin, false},
+		{"global join", &security.JWTClaims{Permissions: []string{"channels:join"}}, ActionJoin, true},
+		{"join does not cover send", &security.JWTClaims{Permissions: []string{"channels:join"}}, ActionSend, false},
+		{"scoped send", &security.JWTClaims{Permissions: []string{"channels:general:send"}}, ActionSend, true},
+		{"other channel", &security.JWTClaims{Permissions: []string{"channels:staff:join"}}, ActionJoin, false},
+		{"wildcard", &security.JWTClaims{Permissions: []string{"*"}}, ActionSend, true},
+		{"admin role", &security.JWTClaims{Roles: []string{"admin"}}, ActionJoin, true},
+	}
+
+	for _, test := range tests {
+		err := authorizer.AuthorizeChannel(context.Background(), &Client{Claims: test.claims}, "general", test.action)
+		if (err == nil) != test.expected {
+			t.Errorf("%s: expected allowed=%v, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestHub_JoinChannel_Denied(t *testing.T) {
+	hub := NewHub()
+	hub.SetAuthorizer(NewDatabaseAuthorizer(&fakeChannelStore{
+		channels: map[string]*database.Channel{"staff": {ID: "staff", Private: true}},
+	}))
+
+	client := newTestClient(hub, "bob_1", "2", "bob")
+
+	if err := hub.JoinChannel(client.ID, "staff"); err == nil {
+		t.Fatal("Expected joining a private channel to fail")
+	}
+
+	frame := receive(t, client, MessageTypeError)
+	plexiErr, ok := frame.Data.(*errors.PlexiChatError)
+	if !ok || plexiErr.Code != "CHANNEL_PRIVATE" || frame.ChannelID != "staff" {
+		t.Errorf("Expected a CHANNEL_PRIVATE error frame for staff, got %+v", frame)
+	}
+	if plexiErr != nil && plexiErr.Context["action"] != "join" {
+		t.Errorf("Expected the error to name the join action, got %v", plexiErr.Context)
+	}
+
+	if users := hub.GetChannelUsers("staff"); len(users) != 0 {
+		t.Errorf("Expected nobody in staff, got %v", users)
+	}
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if client.Channels["staff"] {
+		t.Error("Expected the denied channel not to be recorded on the client")
+	}
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// EnvelopeTarget selects which clients an envelope is delivered to
+type EnvelopeTarget string
+
+const (
+	TargetChannel EnvelopeTarget = "channel"
+	TargetUser    EnvelopeTarget = "user"
+	TargetAll     EnvelopeTarget = "all"
+)
+
+// Envelope is a message published through a Broker together with its audience
+type Envelope struct {
+	Target   EnvelopeTarget `json:"target"`
+	TargetID string         `json:"target_id,omitempty"` // Channel or user ID
+	Message  Message        `json:"message"`
+}
+
+// Presence records one client connection, either hub-wide (empty ChannelID) or in a channel
+type Presence struct {
+	HubID     string `json:"hub_id"`
+	ClientID  string `json:"client_id"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	ChannelID string `json:"channel_id,omitempty"`
+}
+
+// Broker carries hub traffic and presence between hub instances, so clients
+// connected to different hubs can reach each other
+type Broker interface {
+	// Publish delivers an envelope to every subscribed hub, including the publisher
+	Publish(ctx context.Context, envelope Envelope) error
+
+	// Subscribe registers a handler for published envelopes and returns a function that removes it
+	Subscribe(handler func(Envelope)) (unsubscribe func())
+
+	// UpdatePresence records a client as present, or removes it when online is false
+	UpdatePresence(ctx context.Context, presence Presence, online bool) error
+
+	// Presence lists clients in a channel, or every connected client for an empty channelID
+	Presence(ctx context.Context, channelID string) ([]Presence, error)
+
+	// Close releases the broker's resources
+	Close() error
+}
+
+// presenceKey identifies a presence record
+type presenceKey struct {
+	clientID  string
+	channelID string
+}
+
+// MemoryBroker is a Broker for hubs that share a process
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[int]func(Envelope)
+	nextID   int
+	presence map[presenceKey]Presence
+}
+
+// NewMemoryBroker creates an in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers: make(map[int]func(Envelope)),
+		presence: make(map[presenceKey]Presence),
+	}
+}
+
+// Publish delivers the envelope synchronously to every subscriber
+func (b *MemoryBroker) Publish(ctx context.Context, envelope Envelope) error {
+	b.mu.RLock()
+	handlers := make([]func(Envelope), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for published envelopes
+func (b *MemoryBroker) Subscribe(handler func(Envelope)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// UpdatePresence records or removes a presence entry
+func (b *MemoryBroker) UpdatePresence(ctx context.Context, presence Presence, online bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := presenceKey{clientID: presence.ClientID, channelID: presence.ChannelID}
+	if online {
+		b.presence[key] = presence
+	} else {
+		delete(b.presence, key)
+	}
+	return nil
+}
+
+// Presence lists the clients present in a channel
+func (b *MemoryBroker) Presence(ctx context.Context, channelID string) ([]Presence, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var result []Presence
+	for key, presence := range b.presence {
+		if key.channelID == channelID {
+			result = append(result, presence)
+		}
+	}
+	sortPresence(result)
+	return result, nil
+}
+
+// Close removes all subscribers
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = make(map[int]func(Envelope))
+	return nil
+}
+
+// sortPresence orders presence by username, then client ID, for stable output
+func sortPresence(presence []Presence) {
+	sort.Slice(presence, func(i, j int) bool {
+		if presence[i].Username != presence[j].Username {
+			return presence[i].Username < presence[j].Username
+		}
+		return presence[i].ClientID < presence[j].ClientID
+	})
+}
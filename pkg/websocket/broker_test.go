@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestClient registers a client with hub directly, without a connection
+func newTestClient(hub *Hub, id, userID, username string) *Client {
+	client := &Client{
+		ID:       id,
+		UserID:   userID,
+		Username: username,
+		Send:     make(chan Message, 16),
+		Hub:      hub,
+		Channels: make(map[string]bool),
+	}
+	hub.registerClient(client)
+	<-client.Send // Welcome message
+	return client
+}
+
+// receive waits for the next message of the given type on a client
+func receive(t *testing.T, client *Client, msgType MessageType) Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-client.Send:
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("Expected a %s message for client %s", msgType, client.ID)
+			return Message{}
+		}
+	}
+}
+
+// testBrokerFanOut checks that two hubs on connected brokers reach each other's clients
+func testBrokerFanOut(t *testing.T, first, second *Hub) {
+	alice := newTestClient(first, "alice_1", "1", "alice")
+	bob := newTestClient(second, "bob_1", "2", "bob")
+
+	if err := first.JoinChannel(alice.ID, "general"); err != nil {
+		t.Fatalf("Expected alice to join, got %v", err)
+	}
+	receive(t, alice, MessageTypeJoin) // Her own announcement
+	if err := second.JoinChannel(bob.ID, "general"); err != nil {
+		t.Fatalf("Expected bob to join, got %v", err)
+	}
+
+	// Bob's join is announced to alice on the other hub
+	if msg := receive(t, alice, MessageTypeJoin); msg.UserID != "2" {
+		t.Errorf("Expected bob's join announcement, got user %s", msg.UserID)
+	}
+
+	first.broadcastToChannel("general", Message{Type: MessageTypeChat, Data: "hello", ChannelID: "general"})
+	if msg := receive(t, bob, MessageTypeChat); msg.Data != "hello" {
+		t.Errorf("Expected bob to receive hello, got %v", msg.Data)
+	}
+
+	second.SendToUser("1", Message{Type: MessageTypeNotification, Data: "ping"})
+	if msg := receive(t, alice, MessageTypeNotification); msg.Data != "ping" {
+		t.Errorf("Expected alice to receive ping, got %v", msg.Data)
+	}
+
+	for _, hub := range []*Hub{first, second} {
+		if users := hub.GetChannelUsers("general"); len(users) != 2 || users[0] != "alice" || users[1] != "bob" {
+			t.Errorf("Expected [alice bob] in general from hub %s, got %v", hub.ID(), users)
+		}
+		if users := hub.GetOnlineUsers(); len(users) != 2 {
+			t.Errorf("Expected 2 online users from hub %s, got %v", hub.ID(), users)
+		}
+	}
+
+	second.unregisterClient(bob)
+	if msg := receive(t, alice, MessageTypeLeave); msg.UserID != "2" {
+		t.Errorf("Expected bob's leave announcement, got user %s", msg.UserID)
+	}
+	if users := first.GetChannelUsers("general"); len(users) != 1 || users[0] != "alice" {
+		t.Errorf("Expected only alice in general after bob left, got %v", users)
+	}
+}
+
+func TestMemoryBroker_FanOut(t *testing.T) {
+	broker := NewMemoryBroker()
+	defer broker.Close()
+
+	testBrokerFanOut(t, NewHubWithBroker(broker), NewHubWithBroker(broker))
+}
+
+func TestSQLiteBroker_FanOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broker.db")
+	config := SQLiteBrokerConfig{Path: path, PollInterval: 10 * time.Millisecond}
+
+	// Two brokers on one file stand in for two processes
+	first, err := NewSQLiteBroker(config)
+	if err != nil {
+		t.Fatalf("Expected broker to open, got %v", err)
+	}
+	defer first.Close()
+
+	second, err := NewSQLiteBroker(config)
+	if err != nil {
+		t.Fatalf("Expected broker to open, got %v", err)
+	}
+	defer second.Close()
+
+	testBrokerFanOut(t, NewHubWithBroker(first), NewHubWithBroker(second))
+}
+
+func TestSQLiteBroker_CloseClearsPresence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broker.db")
+	config := SQLiteBrokerConfig{Path: path, PollInterval: 10 * time.Millisecond}
+
+	first, err := NewSQLiteBroker(config)
+	if err != nil {
+		t.Fatalf("Expected broker to open, got %v", err)
+	}
+	second, err := NewSQLiteBroker(config)
+	if err != nil {
+		t.Fatalf("Expected broker to open, got %v", err)
+	}
+	defer second.Close()
+
+	newTestClient(NewHubWithBroker(first), "alice_1", "1", "alice")
+	if users := NewHubWithBroker(second).GetOnlineUsers(); len(users) != 1 {
+		t.Fatalf("Expected alice online, got %v", users)
+	}
+
+	first.Close()
+	if users := NewHubWithBroker(second).GetOnlineUsers(); len(users) != 0 {
+		t.Errorf("Expected no online users after the broker closed, got %v", users)
+	}
+}
@@ -0,0 +1,303 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"plexichat-client/pkg/logging"
+	"plexichat-client/pkg/security"
+)
+
+const (
+	// longPollTimeout is how long a poll waits for a message before returning empty
+	longPollTimeout = 25 * time.Second
+
+	// longPollSessionTimeout is how long a long-poll session survives without a poll
+	longPollSessionTimeout = time.Minute
+
+	// longPollBatchSize caps the messages returned by one poll
+	longPollBatchSize = 100
+)
+
+// LongPollResponse is the body of a long-poll response
+type LongPollResponse struct {
+	Session  string    `json:"session"`
+	Messages []Message `json:"messages"`
+}
+
+// newHTTPClient creates a client for a connection that is not a WebSocket; it has no
+// Conn or pumps and reads its Send channel in the request handlers
+func (h *Hub) newHTTPClient(userID, username string, claims *security.JWTClaims) *Client {
+	return &Client{
+		ID:       fmt.Sprintf("%s_%d", userID, time.Now().UnixNano()),
+		UserID:   userID,
+		Username: username,
+		Send:     make(chan Message, 256),
+		Hub:      h,
+		Channels: make(map[string]bool),
+		Claims:   claims,
+	}
+}
+
+// streamChannels reads the channels a streaming request joins, from the comma
+// separated channels parameter, and the message ID to replay history after
+func streamChannels(r *http.Request) ([]string, int64) {
+	var channels []string
+	for _, channelID := range strings.Split(r.URL.Query().Get("channels"), ",") {
+		if channelID = strings.TrimSpace(channelID); channelID != "" {
+			channels = append(channels, channelID)
+		}
+	}
+
+	// An SSE reconnect names the last event it saw
+	marker := r.Header.Get("Last-Event-ID")
+	if marker == "" {
+		marker = r.URL.Query().Get("since")
+	}
+	sinceID := int64(-1)
+	if id, err := strconv.ParseInt(marker, 10, 64); err == nil && id >= 0 {
+		sinceID = id
+	}
+	return channels, sinceID
+}
+
+// joinAll joins a client to channels, replaying history after sinceID. Denials are
+// reported to the client by join.
+func (h *Hub) joinAll(client *Client, channels []string, sinceID int64) {
+	for _, channelID := range channels {
+		if err := h.join(client.ID, channelID, sinceID); err != nil {
+			logging.Error("Client %s failed to join %s: %v", client.ID, channelID, err)
+		}
+	}
+}
+
+// HandleSSE streams the hub's messages to a client as Server-Sent Events, for
+// clients behind proxies that block WebSocket upgrades. It authenticates like
+// HandleWebSocket. The channels query parameter lists channels to join; on a
+// reconnect, Last-Event-ID replays the history the client missed.
+func (h *Hub) HandleSSE(w http.ResponseWriter, r *http.Request, userID, username string) {
+	userID, username, claims, ok := h.authenticate(w, r, userID, username)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	security.LogSecurityEvent("SSE_CONNECT_ATTEMPT", security.GetClientIP(r), r.Header.Get("User-Agent"),
+		fmt.Sprintf("User: %s", username))
+
+	client := h.newHTTPClient(userID, username, claims)
+	client.closing = make(chan struct{})
+	client.done = make(chan struct{})
+	defer close(client.done)
+	defer h.unregisterClient(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	h.registerClient(client)
+
+	channels, sinceID := streamChannels(r)
+	go h.joinAll(client, channels, sinceID)
+
+	for {
+		select {
+		case message, ok := <-client.Send:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, message); err != nil {
+				logging.Error("SSE write error: %v", err)
+				return
+			}
+			flusher.Flush()
+
+		case <-client.closing:
+			// Flush what is queued, then tell the client when to reconnect
+			for flushing := true; flushing; {
+				select {
+				case message, ok := <-client.Send:
+					if !ok {
+						flushing = false
+						break
+					}
+					writeEvent(w, message)
+				default:
+					flushing = false
+				}
+			}
+			fmt.Fprintf(w, "retry: %d\n\n", shutdownReconnectAfter.Milliseconds())
+			flusher.Flush()
+			return
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a message as one Server-Sent Event. Stored chat messages carry
+// their Seq as the event ID, so a reconnect can resume after it; receipts and acks
+// name other messages and leave the resume point alone.
+func writeEvent(w http.ResponseWriter, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	if message.Type == MessageTypeChat && message.Seq != 0 {
+		fmt.Fprintf(w, "id: %d\n", message.Seq)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data)
+	return err
+}
+
+// HandleLongPoll serves the hub's messages to clients that can use neither
+// WebSockets nor SSE. A request without a session parameter opens a session and
+// returns its ID at once; each request with ?session=<id> then waits up to 25s for
+// messages. Sessions not polled for a minute are closed, and polling a closed
+// session answers 410 Gone. Channels are joined as for HandleSSE.
+func (h *Hub) HandleLongPoll(w http.ResponseWriter, r *http.Request, userID, username string) {
+	userID, username, claims, ok := h.authenticate(w, r, userID, username)
+	if !ok {
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session")
+	if sessionID == "" {
+		security.LogSecurityEvent("LONGPOLL_CONNECT_ATTEMPT", security.GetClientIP(r), r.Header.Get("User-Agent"),
+			fmt.Sprintf("User: %s", username))
+
+		client := h.newHTTPClient(userID, username, claims)
+		client.lastPoll = time.Now()
+		h.registerClient(client)
+		if !h.isRegistered(client) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(shutdownReconnectAfter/time.Second)))
+			http.Error(w, "Server restarting", http.StatusServiceUnavailable)
+			return
+		}
+
+		channels, sinceID := streamChannels(r)
+		go h.joinAll(client, channels, sinceID)
+
+		writeLongPoll(w, LongPollResponse{Session: client.ID, Messages: []Message{}})
+		return
+	}
+
+	h.mu.RLock()
+	client, exists := h.clients[sessionID]
+	h.mu.RUnlock()
+	if !exists || client.UserID != userID || !client.isLongPoll() {
+		http.Error(w, "Session expired", http.StatusGone)
+		return
+	}
+
+	// One poll at a time per session, or messages would be split between them
+	if !client.pollMu.TryLock() {
+		http.Error(w, "Session already polling", http.StatusConflict)
+		return
+	}
+	defer client.pollMu.Unlock()
+
+	client.touchPoll()
+	defer client.touchPoll()
+
+	timer := time.NewTimer(longPollTimeout)
+	defer timer.Stop()
+
+	// Wait for the first message, then take whatever else is already queued
+	var messages []Message
+	select {
+	case message, ok := <-client.Send:
+		if !ok {
+			http.Error(w, "Session expired", http.StatusGone)
+			return
+		}
+		messages = append(messages, message)
+	case <-timer.C:
+	case <-r.Context().Done():
+		return
+	}
+
+	for collecting := len(messages) > 0; collecting && len(messages) < longPollBatchSize; {
+		select {
+		case message, ok := <-client.Send:
+			if !ok {
+				collecting = false
+				break
+			}
+			messages = append(messages, message)
+		default:
+			collecting = false
+		}
+	}
+
+	if messages == nil {
+		messages = []Message{}
+	}
+	writeLongPoll(w, LongPollResponse{Session: client.ID, Messages: messages})
+}
+
+// writeLongPoll writes a long-poll response body
+func writeLongPoll(w http.ResponseWriter, response LongPollResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logging.Error("Long-poll write error: %v", err)
+	}
+}
+
+// isLongPoll reports whether the client is a long-poll session
+func (c *Client) isLongPoll() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.lastPoll.IsZero()
+}
+
+// touchPoll records that a long-poll client is still polling
+func (c *Client) touchPoll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastPoll = time.Now()
+}
+
+// isRegistered reports whether the hub accepted a client
+func (h *Hub) isRegistered(client *Client) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.clients[client.ID] == client
+}
+
+// expireLongPolls closes long-poll sessions that have stopped polling
+func (h *Hub) expireLongPolls() {
+	cutoff := time.Now().Add(-longPollSessionTimeout)
+
+	h.mu.RLock()
+	var expired []*Client
+	for _, client := range h.clients {
+		client.mu.RLock()
+		if !client.lastPoll.IsZero() && client.lastPoll.Before(cutoff) && client.pollMu.TryLock() {
+			client.pollMu.Unlock() // Not mid-poll
+			expired = append(expired, client)
+		}
+		client.mu.RUnlock()
+	}
+	h.mu.RUnlock()
+
+	for _, client := range expired {
+		logging.Info("Long-poll session %s (%s) expired", client.ID, client.Username)
+		h.unregisterClient(client)
+	}
+}
@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fallbackGet sends an authenticated request to a fallback endpoint
+func fallbackGet(t *testing.T, url string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer test-token-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed, got %v", err)
+	}
+	return resp
+}
+
+// readEvent reads the next Server-Sent Event's fields
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected an event, got %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		fields[field] = value
+	}
+}
+
+func TestHub_HandleSSE(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleSSE(w, r, "1", "alice")
+	}))
+	defer server.Close()
+
+	resp := fallbackGet(t, server.URL+"?channels=general")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", contentType)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if event := readEvent(t, reader); event["event"] != string(MessageTypeNotification) {
+		t.Errorf("Expected the welcome notification first, got %v", event)
+	}
+
+	// A receipt names another message, so it must not move the resume point
+	hub.SendToUser("1", Message{Type: MessageTypeRead, ChannelID: "general", MessageID: "41"})
+	hub.SendToUser("1", Message{Type: MessageTypeChat, Data: "hello", MessageID: "ack-1", Seq: 42})
+	for {
+		event := readEvent(t, reader)
+		if event["event"] == string(MessageTypeRead) {
+			if id, ok := event["id"]; ok {
+				t.Errorf("Expected no event ID on a receipt, got %q", id)
+			}
+			continue
+		}
+		if event["event"] != string(MessageTypeChat) {
+			continue // Join notices
+		}
+		if event["id"] != "42" {
+			t.Errorf("Expected event ID 42, got %q", event["id"])
+		}
+		var msg Message
+		if err := json.Unmarshal([]byte(event["data"]), &msg); err != nil || msg.Data != "hello" {
+			t.Errorf("Expected the chat message as data, got %q (%v)", event["data"], err)
+		}
+		break
+	}
+
+	// Shutting down tells the client when to reconnect and ends the stream
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer shutdownCancel()
+	if _, err := hub.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	for {
+		event := readEvent(t, reader)
+		if retry, ok := event["retry"]; ok {
+			if retry != "2000" {
+				t.Errorf("Expected a 2000ms retry, got %s", retry)
+			}
+			break
+		}
+	}
+}
+
+func TestHub_HandleLongPoll(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleLongPoll(w, r, "1", "alice")
+	}))
+	defer server.Close()
+
+	poll := func(url string) (int, LongPollResponse) {
+		resp := fallbackGet(t, url)
+		defer resp.Body.Close()
+		var body LongPollResponse
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Expected a poll response, got %v", err)
+			}
+		}
+		return resp.StatusCode, body
+	}
+
+	status, opened := poll(server.URL)
+	if status != http.StatusOK || opened.Session == "" {
+		t.Fatalf("Expected a session, got %d %+v", status, opened)
+	}
+	sessionURL := server.URL + "?session=" + opened.Session
+
+	if _, body := poll(sessionURL); len(body.Messages) == 0 || body.Messages[0].Type != MessageTypeNotification {
+		t.Errorf("Expected the welcome notification, got %+v", body.Messages)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		hub.SendToUser("1", Message{Type: MessageTypeChat, Data: "hello"})
+	}()
+	if _, body := poll(sessionURL); len(body.Messages) != 1 || body.Messages[0].Data != "hello" {
+		t.Errorf("Expected the poll to wait for the chat message, got %+v", body.Messages)
+	}
+
+	if status, _ := poll(server.URL + "?session=unknown"); status != http.StatusGone {
+		t.Errorf("Expected 410 for an unknown session, got %d", status)
+	}
+
+	// A session that stops polling is closed
+	hub.mu.RLock()
+	client := hub.clients[opened.Session]
+	hub.mu.RUnlock()
+	client.mu.Lock()
+	client.lastPoll = time.Now().Add(-2 * longPollSessionTimeout)
+	client.mu.Unlock()
+	hub.expireLongPolls()
+
+	if status, _ := poll(sessionURL); status != http.StatusGone {
+		t.Errorf("Expected 410 for an expired session, got %d", status)
+	}
+}
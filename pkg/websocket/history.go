@@ -0,0 +1,275 @@
+package websocket
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"plexichat-client/pkg/database"
+	"plexichat-client/pkg/logging"
+)
+
+const (
+	// historyPageSize is the number of stored messages read per query during a replay
+	historyPageSize = 100
+
+	// maxReplayMessages caps how much backlog one join replays
+	maxReplayMessages = 1000
+
+	// replaySendTimeout bounds how long a replay waits for room in a client's send buffer
+	replaySendTimeout = 5 * time.Second
+
+	// replayRetryInterval is how often a replay checks a full send buffer for room
+	replayRetryInterval = 10 * time.Millisecond
+)
+
+// HistoryStore keeps the chat messages clients send and provides those a join replays
+type HistoryStore interface {
+	// SaveMessage stores a message and sets its ID
+	SaveMessage(ctx context.Context, msg *database.Message) error
+	// GetMessages returns a channel's messages, newest first
+	GetMessages(ctx context.Context, channelID string, limit, offset int) ([]*database.Message, error)
+}
+
+// SetHistoryStore makes the hub store chat messages sent to a channel, giving
+// each a Seq, and enables history replay for JoinChannelSince
+func (h *Hub) SetHistoryStore(store HistoryStore) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = store
+}
+
+// storeChat stores a client's channel chat message when the hub has a history
+// store and sets its Seq to the stored ID. The message is still broadcast if
+// storing fails, just without a Seq.
+func (h *Hub) storeChat(client *Client, message *Message) {
+	h.mu.RLock()
+	store := h.history
+	h.mu.RUnlock()
+
+	content, ok := message.Data.(string)
+	if store == nil || !ok || message.ChannelID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := &database.Message{
+		ChannelID:   message.ChannelID,
+		UserID:      client.UserID,
+		Username:    client.Username,
+		Content:     content,
+		MessageType: "text",
+		Timestamp:   message.Timestamp,
+	}
+	if err := store.SaveMessage(ctx, msg); err != nil {
+		logging.Error("Failed to store message from %s in channel %s: %v", client.Username, message.ChannelID, err)
+		return
+	}
+	message.Seq = msg.ID
+}
+
+// JoinChannelSince adds a client to a channel and first sends it every stored
+// message after sinceID, oldest first. Live messages arriving meanwhile are held
+// back and sent afterwards, skipping any whose Seq the replay already covered. Without a
+// history store this is the same as JoinChannel.
+func (h *Hub) JoinChannelSince(clientID, channelID string, sinceID int64) error {
+	if sinceID < 0 {
+		sinceID = 0
+	}
+	return h.join(clientID, channelID, sinceID)
+}
+
+// joinMarker reads the "since" message ID from a join frame's data ({"since": 42}),
+// or -1 when the client did not ask for a replay
+func joinMarker(data interface{}) int64 {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+
+	switch since := fields["since"].(type) {
+	case float64:
+		if since >= 0 {
+			return int64(since)
+		}
+	case int64: // Binary encodings keep integers as integers
+		if since >= 0 {
+			return since
+		}
+	case uint64:
+		if since <= math.MaxInt64 {
+			return int64(since)
+		}
+	case string:
+		if id, err := strconv.ParseInt(since, 10, 64); err == nil && id >= 0 {
+			return id
+		}
+	}
+	return -1
+}
+
+// holdForReplay buffers a live channel message while the channel's history is
+// being replayed to the client, and reports whether it did
+func (c *Client) holdForReplay(channelID string, message Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held, replaying := c.replays[channelID]
+	if !replaying {
+		return false
+	}
+	c.replays[channelID] = append(held, message)
+	return true
+}
+
+// replayHistory sends a client the channel's stored messages after sinceID,
+// then the live messages held back meanwhile. No locks are held while sending,
+// so a slow client cannot stall the hub; live traffic stays held until the
+// held messages are drained.
+func (h *Hub) replayHistory(client *Client, channelID string, sinceID int64) {
+	backlog, err := h.loadHistory(channelID, sinceID)
+	if err != nil {
+		logging.Error("Failed to load history of channel %s for client %s: %v", channelID, client.ID, err)
+	}
+
+	lastID := sinceID
+	for _, msg := range backlog {
+		if !client.isReplaying(channelID) {
+			return // Left the channel meanwhile
+		}
+		if !h.sendReplayed(client, historyMessage(msg)) {
+			return
+		}
+		lastID = msg.ID
+	}
+
+	for {
+		held, more := client.takeHeld(channelID)
+		if !more {
+			return
+		}
+
+		// Live messages already covered by the backlog are not sent twice
+		for _, msg := range held {
+			if msg.Seq != 0 && msg.Seq <= lastID {
+				continue
+			}
+			if !h.sendReplayed(client, msg) {
+				return
+			}
+		}
+	}
+}
+
+// isReplaying reports whether live traffic for channelID is still held back
+func (c *Client) isReplaying(channelID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, replaying := c.replays[channelID]
+	return replaying
+}
+
+// takeHeld removes and returns the live messages held for channelID. Once none
+// are left it ends the replay, so later messages go straight to the client, and
+// reports false.
+func (c *Client) takeHeld(channelID string) ([]Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held, replaying := c.replays[channelID]
+	if !replaying {
+		return nil, false
+	}
+	if len(held) == 0 {
+		delete(c.replays, channelID)
+		return nil, false
+	}
+	c.replays[channelID] = nil
+	return held, true
+}
+
+// sendReplayed queues a replayed message, waiting briefly for buffer space since a
+// backlog can exceed the buffer. Slow clients are dropped. h.mu is only held for
+// each attempt, as unregistering closes the send buffer.
+func (h *Hub) sendReplayed(client *Client, message Message) bool {
+	deadline := time.Now().Add(replaySendTimeout)
+	for {
+		h.mu.RLock()
+		_, registered := h.clients[client.ID]
+		sent := false
+		if registered {
+			select {
+			case client.Send <- message:
+				sent = true
+			default:
+			}
+		}
+		h.mu.RUnlock()
+
+		switch {
+		case sent:
+			return true
+		case !registered:
+			return false // Disconnected meanwhile
+		case time.Now().After(deadline):
+			h.dropClient(client)
+			return false
+		}
+		time.Sleep(replayRetryInterval)
+	}
+}
+
+// loadHistory reads the channel's stored messages after sinceID, oldest first
+func (h *Hub) loadHistory(channelID string, sinceID int64) ([]*database.Message, error) {
+	h.mu.RLock()
+	store := h.history
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var backlog []*database.Message
+	for offset := 0; len(backlog) < maxReplayMessages; offset += historyPageSize {
+		page, err := store.GetMessages(ctx, channelID, historyPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		reachedMarker := false
+		for _, msg := range page {
+			if msg.ID <= sinceID {
+				reachedMarker = true
+				continue
+			}
+			backlog = append(backlog, msg)
+		}
+
+		if reachedMarker || len(page) < historyPageSize {
+			break
+		}
+	}
+
+	// Pages come newest first; IDs give the order messages were stored in
+	sort.Slice(backlog, func(i, j int) bool { return backlog[i].ID < backlog[j].ID })
+	if len(backlog) > maxReplayMessages {
+		backlog = backlog[len(backlog)-maxReplayMessages:]
+	}
+	return backlog, nil
+}
+
+// historyMessage converts a stored message into a chat frame
+func historyMessage(msg *database.Message) Message {
+	return Message{
+		Type:      MessageTypeChat,
+		Data:      msg.Content,
+		Timestamp: msg.Timestamp,
+		UserID:    msg.UserID,
+		ChannelID: msg.ChannelID,
+		MessageID: strconv.FormatInt(msg.ID, 10),
+		Seq:       msg.ID,
+	}
+}
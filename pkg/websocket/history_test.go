@@ -0,0 +1,203 @@
+package websocket
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"plexichat-client/pkg/database"
+)
+
+// fakeHistoryStore serves stored messages newest first, like database.GetMessages
+type fakeHistoryStore struct {
+	messages []*database.Message // Oldest first
+	onQuery  func()
+}
+
+func (s *fakeHistoryStore) SaveMessage(ctx context.Context, msg *database.Message) error {
+	msg.ID = int64(len(s.messages) + 1)
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *fakeHistoryStore) GetMessages(ctx context.Context, channelID string, limit, offset int) ([]*database.Message, error) {
+	if s.onQuery != nil {
+		s.onQuery()
+		s.onQuery = nil
+	}
+
+	var page []*database.Message
+	for i := len(s.messages) - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, s.messages[i])
+	}
+	return page, nil
+}
+
+func storedMessages(channelID string, ids ...int64) []*database.Message {
+	messages := make([]*database.Message, len(ids))
+	for i, id := range ids {
+		messages[i] = &database.Message{
+			ID:        id,
+			ChannelID: channelID,
+			UserID:    "1",
+			Content:   "message " + strconv.FormatInt(id, 10),
+			Timestamp: time.Unix(id, 0),
+		}
+	}
+	return messages
+}
+
+func TestHub_JoinChannelSince(t *testing.T) {
+	hub := NewHub()
+	store := &fakeHistoryStore{messages: storedMessages("general", 1, 2, 3, 4, 5)}
+	hub.SetHistoryStore(store)
+
+	client := newTestClient(hub, "bob_1", "2", "bob")
+
+	// Messages 5 and 6 go out live while the history is loading; 5 is also in the
+	// backlog. Their senders' ack IDs must not be mistaken for stored IDs.
+	store.onQuery = func() {
+		for _, seq := range []int64{5, 6} {
+			hub.broadcastToChannel("general", Message{Type: MessageTypeChat, Data: "live", ChannelID: "general", MessageID: "1", Seq: seq})
+		}
+	}
+
+	if err := hub.JoinChannelSince(client.ID, "general", 2); err != nil {
+		t.Fatalf("Expected join to succeed, got %v", err)
+	}
+
+	var received []int64
+	for len(received) < 4 {
+		received = append(received, receive(t, client, MessageTypeChat).Seq)
+	}
+
+	expected := []int64{3, 4, 5, 6}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Fatalf("Expected messages %v in order, got %v", expected, received)
+		}
+	}
+
+	select {
+	case msg := <-client.Send:
+		if msg.Type == MessageTypeChat {
+			t.Errorf("Expected no duplicate after the backlog, got message %d", msg.Seq)
+		}
+	default:
+	}
+}
+
+func TestHub_JoinChannelSince_Paged(t *testing.T) {
+	hub := NewHub()
+	ids := make([]int64, 250)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	hub.SetHistoryStore(&fakeHistoryStore{messages: storedMessages("general", ids...)})
+
+	client := newTestClient(hub, "bob_1", "2", "bob")
+	client.Send = make(chan Message, 256)
+
+	if err := hub.JoinChannelSince(client.ID, "general", 40); err != nil {
+		t.Fatalf("Expected join to succeed, got %v", err)
+	}
+
+	for want := 41; want <= 250; want++ {
+		if got := receive(t, client, MessageTypeChat).MessageID; got != strconv.Itoa(want) {
+			t.Fatalf("Expected message %d, got %s", want, got)
+		}
+	}
+}
+
+func TestHub_JoinChannelSince_SlowClient(t *testing.T) {
+	hub := NewHub()
+	hub.SetHistoryStore(&fakeHistoryStore{messages: storedMessages("general", 1, 2, 3, 4, 5, 6, 7, 8)})
+
+	alice := newTestClient(hub, "alice_1", "1", "alice")
+	if err := hub.JoinChannel(alice.ID, "general"); err != nil {
+		t.Fatalf("Expected join to succeed, got %v", err)
+	}
+
+	// Bob's buffer fills up partway through the backlog
+	bob := newTestClient(hub, "bob_1", "2", "bob")
+	bob.Send = make(chan Message, 2)
+	joined := make(chan error, 1)
+	go func() { joined <- hub.JoinChannelSince(bob.ID, "general", 0) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(bob.Send) < cap(bob.Send) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the replay to fill the client's buffer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Broadcasting to the channel must not wait for bob
+	sent := make(chan struct{})
+	go func() {
+		hub.broadcastToChannel("general", Message{Type: MessageTypeChat, Data: "live", ChannelID: "general", MessageID: "9"})
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the broadcast not to block behind a replay")
+	}
+	if got := receive(t, alice, MessageTypeChat).MessageID; got != "9" {
+		t.Errorf("Expected alice to get message 9, got %s", got)
+	}
+
+	for want := 1; want <= 9; want++ {
+		if got := receive(t, bob, MessageTypeChat).MessageID; got != strconv.Itoa(want) {
+			t.Fatalf("Expected message %d, got %s", want, got)
+		}
+	}
+	if err := <-joined; err != nil {
+		t.Errorf("Expected join to succeed, got %v", err)
+	}
+}
+
+func TestHub_StoreChat(t *testing.T) {
+	hub := NewHub()
+	store := &fakeHistoryStore{messages: storedMessages("general", 1, 2)}
+	hub.SetHistoryStore(store)
+	client := newTestClient(hub, "alice_1", "1", "alice")
+
+	message := Message{Type: MessageTypeChat, Data: "hello", ChannelID: "general", MessageID: "ack-1"}
+	hub.storeChat(client, &message)
+	if message.Seq != 3 {
+		t.Fatalf("Expected the stored ID 3 as Seq, got %d", message.Seq)
+	}
+	if stored := store.messages[2]; stored.Content != "hello" || stored.Username != "alice" {
+		t.Errorf("Expected alice's message to be stored, got %+v", stored)
+	}
+
+	// Messages outside a channel are not history
+	direct := Message{Type: MessageTypeChat, Data: "hi"}
+	hub.storeChat(client, &direct)
+	if direct.Seq != 0 || len(store.messages) != 3 {
+		t.Errorf("Expected a message without a channel not to be stored, got Seq %d", direct.Seq)
+	}
+}
+
+func TestJoinMarker(t *testing.T) {
+	tests := []struct {
+		data     interface{}
+		expected int64
+	}{
+		{map[string]interface{}{"since": float64(42)}, 42},
+		{map[string]interface{}{"since": "17"}, 17},
+		{map[string]interface{}{"since": int64(9)}, 9},
+		{map[string]interface{}{"since": uint64(12)}, 12},
+		{map[string]interface{}{"since": float64(-3)}, -1},
+		{map[string]interface{}{}, -1},
+		{"general", -1},
+	}
+
+	for _, test := range tests {
+		if got := joinMarker(test.data); got != test.expected {
+			t.Errorf("Expected marker %d for %v, got %d", test.expected, test.data, got)
+		}
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"plexichat-client/pkg/config"
 	"plexichat-client/pkg/logging"
 	"plexichat-client/pkg/security"
+	"plexichat-client/pkg/wire"
 
 	"github.com/gorilla/websocket"
 )
@@ -27,8 +30,16 @@ const (
 	MessageTypeError        MessageType = "error"
 	MessageTypePing         MessageType = "ping"
 	MessageTypePong         MessageType = "pong"
+	MessageTypeAck          MessageType = "ack"       // Hub accepted a client's message
+	MessageTypeDelivered    MessageType = "delivered" // A recipient received a message
+	MessageTypeRead         MessageType = "read"      // A recipient read up to a message
 )
 
+// ReceiptStore persists how far each user has read in each channel
+type ReceiptStore interface {
+	SetReadPosition(ctx context.Context, userID, channelID string, messageID int64) error
+}
+
 // Message represents a WebSocket message
 type Message struct {
 	Type      MessageType `json:"type"`
@@ -37,6 +48,7 @@ type Message struct {
 	UserID    string      `json:"user_id,omitempty"`
 	ChannelID string      `json:"channel_id,omitempty"`
 	MessageID string      `json:"message_id,omitempty"`
+	Seq       int64       `json:"seq,omitempty"` // Stored ID the hub gave a chat message; orders history and live frames
 }
 
 // Client represents a WebSocket client connection
@@ -47,33 +59,80 @@ type Client struct {
 	Conn     *websocket.Conn
 	Send     chan Message
 	Hub      *Hub
-	Channels map[string]bool // Channels the client is subscribed to
+	Channels map[string]bool     // Channels the client is subscribed to
+	Claims   *security.JWTClaims // Set when the hub has a token validator
+	Codec    wire.Codec          // Frame encoding negotiated by subprotocol; nil means JSON
 	mu       sync.RWMutex
 
-	// Rate limiting fields
-	lastMessageTime time.Time
-	messageCount    int
+	// Live channel messages held back while the channel's history is replayed
+	replays map[string][]Message
+
+	closing    chan struct{} // Closed to make writePump flush and send closeFrame
+	closeFrame []byte
+	closeOnce  sync.Once
+	done       chan struct{} // Closed once both pumps have exited
+
+	// Long-poll sessions only
+	lastPoll time.Time
+	pollMu   sync.Mutex // Held while a poll waits
 }
 
 // Hub maintains active clients and broadcasts messages
 type Hub struct {
-	clients    map[string]*Client
-	channels   map[string]map[string]*Client // channelID -> clientID -> client
-	register   chan *Client
-	unregister chan *Client
-	broadcast  chan Message
-	mu         sync.RWMutex
+	id          string
+	clients     map[string]*Client
+	channels    map[string]map[string]*Client // channelID -> clientID -> client
+	register    chan *Client
+	unregister  chan *Client
+	broadcast   chan Message
+	broker      Broker
+	unsubscribe func()
+	receipts    ReceiptStore
+	history     HistoryStore
+	authorizer  Authorizer
+	limiter     *rateLimiter // Nil when rate limiting is disabled
+	mu          sync.RWMutex
+
+	shuttingDown bool
+	stopped      chan struct{} // Closed by Shutdown to stop Run
+	stopOnce     sync.Once
+
+	validateToken func(token string) (*security.JWTClaims, error)
 }
 
-// NewHub creates a new WebSocket hub
+// NewHub creates a new WebSocket hub backed by an in-memory broker
 func NewHub() *Hub {
-	return &Hub{
+	return NewHubWithBroker(NewMemoryBroker())
+}
+
+// NewHubWithBroker creates a hub whose broadcasts and presence go through broker,
+// so several hubs sharing a broker behave as one
+func NewHubWithBroker(broker Broker) *Hub {
+	h := &Hub{
+		id:         newBrokerID(),
 		clients:    make(map[string]*Client),
 		channels:   make(map[string]map[string]*Client),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan Message),
+		broker:     broker,
+		limiter:    newRateLimiter(config.DefaultHubConfig().RateLimits),
+		stopped:    make(chan struct{}),
 	}
+	h.unsubscribe = broker.Subscribe(h.deliver)
+	return h
+}
+
+// SetReceiptStore makes the hub persist read receipts; without one they are only relayed
+func (h *Hub) SetReceiptStore(store ReceiptStore) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.receipts = store
+}
+
+// ID returns the identifier this hub uses in presence records
+func (h *Hub) ID() string {
+	return h.id
 }
 
 // Run starts the hub's main loop
@@ -87,6 +146,9 @@ func (h *Hub) Run(ctx context.Context) {
 			logging.Info("WebSocket hub shutting down")
 			return
 
+		case <-h.stopped:
+			return
+
 		case client := <-h.register:
 			h.registerClient(client)
 
@@ -98,6 +160,7 @@ func (h *Hub) Run(ctx context.Context) {
 
 		case <-ticker.C:
 			h.pingClients()
+			h.expireLongPolls()
 		}
 	}
 }
@@ -105,11 +168,17 @@ func (h *Hub) Run(ctx context.Context) {
 // registerClient registers a new client
 func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
+	if h.shuttingDown {
+		h.mu.Unlock()
+		close(client.Send) // writePump answers with a close frame
+		return
+	}
 	h.clients[client.ID] = client
+	h.mu.Unlock()
 	logging.Info("Client %s (%s) connected", client.ID, client.Username)
 
+	h.updatePresence(client, "", true)
+
 	// Send welcome message
 	welcomeMsg := Message{
 		Type:      MessageTypeNotification,
@@ -120,42 +189,64 @@ func (h *Hub) registerClient(client *Client) {
 	select {
 	case client.Send <- welcomeMsg:
 	default:
-		close(client.Send)
-		delete(h.clients, client.ID)
+		h.unregisterClient(client)
 	}
 }
 
 // unregisterClient unregisters a client
 func (h *Hub) unregisterClient(client *Client) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	if _, ok := h.clients[client.ID]; !ok {
+		h.mu.Unlock()
+		return
+	}
+
+	// Remove from all channels
+	client.mu.RLock()
+	channelIDs := make([]string, 0, len(client.Channels))
+	for channelID := range client.Channels {
+		channelIDs = append(channelIDs, channelID)
+	}
+	client.mu.RUnlock()
 
-	if _, ok := h.clients[client.ID]; ok {
-		// Remove from all channels
-		for channelID := range client.Channels {
-			if channelClients, exists := h.channels[channelID]; exists {
-				delete(channelClients, client.ID)
-				if len(channelClients) == 0 {
-					delete(h.channels, channelID)
-				}
+	for _, channelID := range channelIDs {
+		if channelClients, exists := h.channels[channelID]; exists {
+			delete(channelClients, client.ID)
+			if len(channelClients) == 0 {
+				delete(h.channels, channelID)
 			}
 		}
+	}
 
-		delete(h.clients, client.ID)
-		close(client.Send)
-		logging.Info("Client %s (%s) disconnected", client.ID, client.Username)
-
-		// Broadcast leave message to channels
-		for channelID := range client.Channels {
-			leaveMsg := Message{
-				Type:      MessageTypeLeave,
-				Data:      map[string]string{"username": client.Username},
-				Timestamp: time.Now(),
-				UserID:    client.UserID,
-				ChannelID: channelID,
-			}
-			h.broadcastToChannel(channelID, leaveMsg)
+	delete(h.clients, client.ID)
+	close(client.Send)
+	h.mu.Unlock()
+	logging.Info("Client %s (%s) disconnected", client.ID, client.Username)
+
+	h.updatePresence(client, "", false)
+
+	// Broadcast leave message to channels
+	for _, channelID := range channelIDs {
+		h.updatePresence(client, channelID, false)
+
+		leaveMsg := Message{
+			Type:      MessageTypeLeave,
+			Data:      map[string]string{"username": client.Username},
+			Timestamp: time.Now(),
+			UserID:    client.UserID,
+			ChannelID: channelID,
 		}
+		h.broadcastToChannel(channelID, leaveMsg)
+	}
+}
+
+// submit hands a message to Run for broadcasting. Once Shutdown has stopped Run
+// it publishes the message directly, so senders never block on a dead loop.
+func (h *Hub) submit(message Message) {
+	select {
+	case h.broadcast <- message:
+	case <-h.stopped:
+		h.broadcastMessage(message)
 	}
 }
 
@@ -168,40 +259,98 @@ func (h *Hub) broadcastMessage(message Message) {
 	}
 }
 
-// broadcastToChannel broadcasts message to all clients in a channel
+// broadcastToChannel broadcasts message to all clients in a channel on every hub
 func (h *Hub) broadcastToChannel(channelID string, message Message) {
-	h.mu.RLock()
-	channelClients, exists := h.channels[channelID]
-	h.mu.RUnlock()
+	h.publish(Envelope{Target: TargetChannel, TargetID: channelID, Message: message})
+}
 
-	if !exists {
-		return
+// broadcastToAll broadcasts message to all connected clients on every hub
+func (h *Hub) broadcastToAll(message Message) {
+	h.publish(Envelope{Target: TargetAll, Message: message})
+}
+
+// publish hands an envelope to the broker
+func (h *Hub) publish(envelope Envelope) {
+	if err := h.broker.Publish(context.Background(), envelope); err != nil {
+		logging.Error("Failed to publish %s message to %s %s: %v",
+			envelope.Message.Type, envelope.Target, envelope.TargetID, err)
 	}
+}
 
-	for _, client := range channelClients {
-		select {
-		case client.Send <- message:
-		default:
-			h.unregister <- client
+// deliver sends a brokered envelope to the matching clients on this hub
+func (h *Hub) deliver(envelope Envelope) {
+	h.mu.RLock()
+	var recipients []*Client
+	switch envelope.Target {
+	case TargetChannel:
+		for _, client := range h.channels[envelope.TargetID] {
+			recipients = append(recipients, client)
+		}
+	case TargetUser:
+		for _, client := range h.clients {
+			if client.UserID == envelope.TargetID {
+				recipients = append(recipients, client)
+			}
+		}
+	case TargetAll:
+		for _, client := range h.clients {
+			recipients = append(recipients, client)
 		}
 	}
+	h.mu.RUnlock()
+
+	channelID := ""
+	if envelope.Target == TargetChannel {
+		channelID = envelope.TargetID
+	}
+	h.sendTo(recipients, envelope.Message, channelID)
 }
 
-// broadcastToAll broadcasts message to all connected clients
-func (h *Hub) broadcastToAll(message Message) {
+// sendTo queues message for each client, dropping clients whose buffer is full.
+// Traffic for channelID is held back from clients still replaying its history.
+func (h *Hub) sendTo(recipients []*Client, message Message, channelID string) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for _, client := range h.clients {
+	for _, client := range recipients {
+		// Skip clients unregistered since the recipients were collected
+		if _, ok := h.clients[client.ID]; !ok {
+			continue
+		}
+
+		if channelID != "" && client.holdForReplay(channelID, message) {
+			continue
+		}
+
 		select {
 		case client.Send <- message:
 		default:
-			h.unregister <- client
+			h.dropClient(client)
 		}
 	}
 }
 
-// pingClients sends ping messages to all clients
+// dropClient unregisters a slow client without blocking the caller, which may
+// be the Run loop itself
+func (h *Hub) dropClient(client *Client) {
+	go func() { h.unregister <- client }()
+}
+
+// updatePresence records a client's presence in a channel ("" for hub-wide) with the broker
+func (h *Hub) updatePresence(client *Client, channelID string, online bool) {
+	presence := Presence{
+		HubID:     h.id,
+		ClientID:  client.ID,
+		UserID:    client.UserID,
+		Username:  client.Username,
+		ChannelID: channelID,
+	}
+	if err := h.broker.UpdatePresence(context.Background(), presence, online); err != nil {
+		logging.Error("Failed to update presence for client %s: %v", client.ID, err)
+	}
+}
+
+// pingClients sends ping messages to this hub's clients
 func (h *Hub) pingClients() {
 	pingMsg := Message{
 		Type:      MessageTypePing,
@@ -209,37 +358,68 @@ func (h *Hub) pingClients() {
 	}
 
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+	recipients := make([]*Client, 0, len(h.clients))
 	for _, client := range h.clients {
-		select {
-		case client.Send <- pingMsg:
-		default:
-			h.unregister <- client
-		}
+		recipients = append(recipients, client)
 	}
+	h.mu.RUnlock()
+
+	h.sendTo(recipients, pingMsg, "")
 }
 
-// JoinChannel adds a client to a channel
+// JoinChannel adds a client to a channel; it receives messages sent from now on
 func (h *Hub) JoinChannel(clientID, channelID string) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	return h.join(clientID, channelID, -1)
+}
+
+// join adds a client to a channel, first replaying history after message
+// sinceID when sinceID is not negative
+func (h *Hub) join(clientID, channelID string, sinceID int64) error {
+	if channelID == "" {
+		return fmt.Errorf("channel ID is required")
+	}
 
+	h.mu.RLock()
 	client, exists := h.clients[clientID]
+	h.mu.RUnlock()
 	if !exists {
 		return fmt.Errorf("client not found")
 	}
 
+	if err := h.authorize(client, channelID, ActionJoin); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if _, exists := h.clients[clientID]; !exists {
+		h.mu.Unlock()
+		return fmt.Errorf("client not found")
+	}
+
 	// Add to channel
 	if h.channels[channelID] == nil {
 		h.channels[channelID] = make(map[string]*Client)
 	}
 	h.channels[channelID][clientID] = client
 
-	// Update client's channel list
+	// Update client's channel list; live traffic is held back until the history is sent
+	replay := sinceID >= 0 && h.history != nil
 	client.mu.Lock()
 	client.Channels[channelID] = true
+	if replay {
+		if client.replays == nil {
+			client.replays = make(map[string][]Message)
+		}
+		client.replays[channelID] = nil
+	}
 	client.mu.Unlock()
+	h.mu.Unlock()
+
+	if replay {
+		h.replayHistory(client, channelID, sinceID)
+	}
+
+	h.updatePresence(client, channelID, true)
 
 	// Broadcast join message
 	joinMsg := Message{
@@ -258,10 +438,9 @@ func (h *Hub) JoinChannel(clientID, channelID string) error {
 // LeaveChannel removes a client from a channel
 func (h *Hub) LeaveChannel(clientID, channelID string) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	client, exists := h.clients[clientID]
 	if !exists {
+		h.mu.Unlock()
 		return fmt.Errorf("client not found")
 	}
 
@@ -276,7 +455,11 @@ func (h *Hub) LeaveChannel(clientID, channelID string) error {
 	// Update client's channel list
 	client.mu.Lock()
 	delete(client.Channels, channelID)
+	delete(client.replays, channelID)
 	client.mu.Unlock()
+	h.mu.Unlock()
+
+	h.updatePresence(client, channelID, false)
 
 	// Broadcast leave message
 	leaveMsg := Message{
@@ -292,103 +475,157 @@ func (h *Hub) LeaveChannel(clientID, channelID string) error {
 	return nil
 }
 
+// acknowledge tells a client its message was accepted, so it can stop retrying it
+func (h *Hub) acknowledge(client *Client, message Message) {
+	if message.MessageID == "" {
+		return
+	}
+
+	ack := Message{
+		Type:      MessageTypeAck,
+		Data:      map[string]string{"message_id": message.MessageID},
+		Timestamp: time.Now(),
+		ChannelID: message.ChannelID,
+		MessageID: message.MessageID,
+		Seq:       message.Seq,
+	}
+	h.sendTo([]*Client{client}, ack, "")
+}
+
+// handleReceipt records a delivered or read receipt and relays it to the channel,
+// where the sender picks it up
+func (h *Hub) handleReceipt(client *Client, message Message) error {
+	client.mu.RLock()
+	member := client.Channels[message.ChannelID]
+	client.mu.RUnlock()
+	if !member {
+		return fmt.Errorf("not a member of channel %s", message.ChannelID)
+	}
+
+	if message.Type == MessageTypeRead {
+		h.mu.RLock()
+		store := h.receipts
+		h.mu.RUnlock()
+
+		if store != nil {
+			messageID, err := strconv.ParseInt(message.MessageID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid message ID %q", message.MessageID)
+			}
+			if err := store.SetReadPosition(context.Background(), client.UserID, message.ChannelID, messageID); err != nil {
+				return fmt.Errorf("failed to save read position: %w", err)
+			}
+		}
+	}
+
+	receipt := Message{
+		Type:      message.Type,
+		Data:      map[string]string{"username": client.Username},
+		Timestamp: time.Now(),
+		UserID:    client.UserID,
+		ChannelID: message.ChannelID,
+		MessageID: message.MessageID,
+	}
+	h.broadcastToChannel(message.ChannelID, receipt)
+	return nil
+}
+
 // SendToChannel sends a message to a specific channel
 func (h *Hub) SendToChannel(channelID string, message Message) {
 	message.ChannelID = channelID
 	message.Timestamp = time.Now()
-	h.broadcast <- message
+	h.submit(message)
 }
 
-// SendToUser sends a message to a specific user
+// SendToUser sends a message to every connection of a user, on any hub
 func (h *Hub) SendToUser(userID string, message Message) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	for _, client := range h.clients {
-		if client.UserID == userID {
-			select {
-			case client.Send <- message:
-			default:
-				h.unregister <- client
-			}
-		}
-	}
+	h.publish(Envelope{Target: TargetUser, TargetID: userID, Message: message})
 }
 
-// GetChannelUsers returns list of users in a channel
+// GetChannelUsers returns list of users in a channel across all hubs
 func (h *Hub) GetChannelUsers(channelID string) []string {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	var users []string
-	if channelClients, exists := h.channels[channelID]; exists {
-		for _, client := range channelClients {
-			users = append(users, client.Username)
-		}
-	}
-	return users
+	return h.presentUsers(channelID)
 }
 
-// GetOnlineUsers returns list of all online users
+// GetOnlineUsers returns list of all online users across all hubs
 func (h *Hub) GetOnlineUsers() []string {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	return h.presentUsers("")
+}
+
+// presentUsers returns the usernames the broker reports in a channel ("" for all)
+func (h *Hub) presentUsers(channelID string) []string {
+	presence, err := h.broker.Presence(context.Background(), channelID)
+	if err != nil {
+		logging.Error("Failed to query presence: %v", err)
+		return nil
+	}
 
 	var users []string
-	for _, client := range h.clients {
-		users = append(users, client.Username)
+	for _, p := range presence {
+		users = append(users, p.Username)
 	}
 	return users
 }
 
-// GetStats returns hub statistics
+// GetStats returns hub statistics for this hub's own connections
 func (h *Hub) GetStats() map[string]interface{} {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	return map[string]interface{}{
+		"hub_id":         h.id,
 		"total_clients":  len(h.clients),
 		"total_channels": len(h.channels),
 		"timestamp":      time.Now(),
 	}
 }
 
-// Upgrader configures the WebSocket upgrader
+// Upgrader configures the WebSocket upgrader. Clients that offer permessage-deflate
+// get compressed frames, and clients that offer a wire subprotocol get its encoding.
 var Upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+	ReadBufferSize:    1024,
+	WriteBufferSize:   1024,
+	EnableCompression: true,
+	Subprotocols:      wire.Subprotocols,
 	CheckOrigin: func(r *http.Request) bool {
 		// In production, implement proper origin checking
 		return true
 	},
 }
 
-// HandleWebSocket handles WebSocket upgrade and client management
-func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request, userID, username string) {
-	// Security validation
+// authenticate checks a connection request's bearer token and user, answering the
+// request with an error if it is rejected. It returns the sanitized user and, when
+// the hub has a token validator, the token's claims.
+func (h *Hub) authenticate(w http.ResponseWriter, r *http.Request, userID, username string) (string, string, *security.JWTClaims, bool) {
 	clientIP := security.GetClientIP(r)
 	userAgent := r.Header.Get("User-Agent")
 
+	if h.isShuttingDown() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(shutdownReconnectAfter/time.Second)))
+		http.Error(w, "Server restarting", http.StatusServiceUnavailable)
+		return "", "", nil, false
+	}
+
 	// Validate authentication token
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		security.LogSecurityEvent("WEBSOCKET_AUTH_MISSING", clientIP, userAgent, "No authorization header")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return "", "", nil, false
 	}
 
 	// Extract and validate token
 	if !strings.HasPrefix(authHeader, "Bearer ") {
 		security.LogSecurityEvent("WEBSOCKET_AUTH_INVALID", clientIP, userAgent, "Invalid authorization format")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return "", "", nil, false
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
 	if len(token) < 10 { // Basic token length validation
 		security.LogSecurityEvent("WEBSOCKET_AUTH_SHORT", clientIP, userAgent, "Token too short")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return "", "", nil, false
 	}
 
 	// Sanitize user inputs
@@ -398,6 +635,36 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request, userID, us
 	if userID == "" || username == "" {
 		security.LogSecurityEvent("WEBSOCKET_INVALID_USER", clientIP, userAgent, "Invalid user data")
 		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return "", "", nil, false
+	}
+
+	// Validate the token itself when the hub knows how, keeping its claims for authorization
+	h.mu.RLock()
+	validateToken := h.validateToken
+	h.mu.RUnlock()
+
+	var claims *security.JWTClaims
+	if validateToken != nil {
+		var err error
+		claims, err = validateToken(token)
+		if err != nil || (claims.UserID != "" && claims.UserID != userID) {
+			security.LogSecurityEvent("WEBSOCKET_AUTH_INVALID", clientIP, userAgent, "Token rejected")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return "", "", nil, false
+		}
+	}
+
+	return userID, username, claims, true
+}
+
+// HandleWebSocket handles WebSocket upgrade and client management
+func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request, userID, username string) {
+	// Security validation
+	clientIP := security.GetClientIP(r)
+	userAgent := r.Header.Get("User-Agent")
+
+	userID, username, claims, ok := h.authenticate(w, r, userID, username)
+	if !ok {
 		return
 	}
 
@@ -420,25 +687,53 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request, userID, us
 		Send:     make(chan Message, 256),
 		Hub:      h,
 		Channels: make(map[string]bool),
+		Claims:   claims,
+		Codec:    wire.ForSubprotocol(conn.Subprotocol()),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 
-	h.register <- client
+	select {
+	case h.register <- client:
+	case <-h.stopped:
+		close(client.Send)
+	}
 
 	// Start goroutines for reading and writing
-	go client.writePump()
-	go client.readPump()
+	var pumps sync.WaitGroup
+	pumps.Add(2)
+	go func() {
+		defer pumps.Done()
+		client.writePump()
+	}()
+	go func() {
+		defer pumps.Done()
+		client.readPump()
+	}()
+	go func() {
+		pumps.Wait()
+		close(client.done)
+	}()
 }
 
 // writePump pumps messages from the hub to the websocket connection
 func (c *Client) writePump() {
 	ticker := time.NewTicker(54 * time.Second)
+	closeConn := true
 	defer func() {
 		ticker.Stop()
-		c.Conn.Close()
+		if closeConn {
+			c.Conn.Close()
+		}
 	}()
 
 	for {
 		select {
+		case <-c.closing:
+			// readPump closes the connection once the peer answers the close frame
+			closeConn = !c.flushAndClose()
+			return
+
 		case message, ok := <-c.Send:
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
@@ -446,7 +741,7 @@ func (c *Client) writePump() {
 				return
 			}
 
-			if err := c.Conn.WriteJSON(message); err != nil {
+			if err := c.writeMessage(message); err != nil {
 				logging.Error("WebSocket write error: %v", err)
 				return
 			}
@@ -460,10 +755,34 @@ func (c *Client) writePump() {
 	}
 }
 
+// writeMessage encodes a message with the client's codec and writes it as one frame
+func (c *Client) writeMessage(message Message) error {
+	codec := c.Codec
+	if codec == nil {
+		codec = wire.JSON
+	}
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return c.Conn.WriteMessage(codec.FrameType(), data)
+}
+
+// readMessage reads one frame, decoding it as JSON if it is text and with the
+// client's codec otherwise
+func (c *Client) readMessage(message *Message) error {
+	frameType, data, err := c.Conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return wire.ForFrame(c.Codec, frameType).Unmarshal(data, message)
+}
+
 // readPump pumps messages from the websocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		c.Hub.unregister <- c
+		// Unregister directly: Run may already have stopped during a shutdown
+		c.Hub.unregisterClient(c)
 		c.Conn.Close()
 	}()
 
@@ -476,7 +795,7 @@ func (c *Client) readPump() {
 
 	for {
 		var message Message
-		err := c.Conn.ReadJSON(&message)
+		err := c.readMessage(&message)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				logging.Error("WebSocket error: %v", err)
@@ -501,20 +820,54 @@ func (c *Client) readPump() {
 		message.Timestamp = time.Now()
 
 		// Rate limiting check
-		if !c.checkRateLimit() {
-			security.LogSecurityEvent("WEBSOCKET_RATE_LIMIT", "", "",
-				fmt.Sprintf("User: %s exceeded rate limit", c.Username))
+		allowed, disconnect := c.Hub.throttle(c, message)
+		if disconnect {
+			// writePump sends the error frames already queued, then the close frame
+			c.requestClose(websocket.ClosePolicyViolation, "rate limit exceeded")
+			c.discardUntilClosed()
+			break
+		}
+		if !allowed {
 			continue
 		}
 
 		// Handle different message types
 		switch message.Type {
 		case MessageTypeChat:
-			c.Hub.broadcast <- message
+			if message.ChannelID != "" && c.Hub.authorize(c, message.ChannelID, ActionSend) != nil {
+				continue
+			}
+			c.Hub.storeChat(c, &message)
+			c.Hub.submit(message)
+			c.Hub.acknowledge(c, message)
+		case MessageTypeJoin:
+			if err := c.Hub.join(c.ID, message.ChannelID, joinMarker(message.Data)); err != nil {
+				logging.Error("Client %s failed to join %s: %v", c.ID, message.ChannelID, err)
+			}
+		case MessageTypeLeave:
+			if err := c.Hub.LeaveChannel(c.ID, message.ChannelID); err != nil {
+				logging.Error("Client %s failed to leave %s: %v", c.ID, message.ChannelID, err)
+			}
+		case MessageTypeDelivered, MessageTypeRead:
+			if err := c.Hub.handleReceipt(c, message); err != nil {
+				logging.Error("Rejected %s receipt from %s: %v", message.Type, c.Username, err)
+			}
 		case MessageTypePong:
 			// Handle pong response
 		default:
-			c.Hub.broadcast <- message
+			if message.ChannelID != "" && c.Hub.authorize(c, message.ChannelID, ActionSend) != nil {
+				continue
+			}
+			c.Hub.submit(message)
+		}
+	}
+}
+
+// discardUntilClosed reads and drops frames until the close handshake finishes
+func (c *Client) discardUntilClosed() {
+	for {
+		if _, _, err := c.Conn.ReadMessage(); err != nil {
+			return
 		}
 	}
 }
@@ -527,6 +880,9 @@ func (c *Client) validateMessage(message *Message) bool {
 		MessageTypeTyping, MessageTypeJoin, MessageTypeLeave, MessageTypeError,
 		MessageTypePing, MessageTypePong:
 		// Valid message types
+	case MessageTypeDelivered, MessageTypeRead:
+		// Receipts carry no data, only the message they refer to
+		return message.MessageID != "" && message.ChannelID != ""
 	default:
 		return false
 	}
@@ -550,30 +906,3 @@ func (c *Client) validateMessage(message *Message) bool {
 
 	return true
 }
-
-// checkRateLimit implements basic rate limiting for WebSocket messages
-func (c *Client) checkRateLimit() bool {
-	now := time.Now()
-
-	// Initialize rate limit tracking if needed
-	if c.lastMessageTime.IsZero() {
-		c.lastMessageTime = now
-		c.messageCount = 1
-		return true
-	}
-
-	// Reset counter if more than 1 minute has passed
-	if now.Sub(c.lastMessageTime) > time.Minute {
-		c.messageCount = 1
-		c.lastMessageTime = now
-		return true
-	}
-
-	// Check if under rate limit (60 messages per minute)
-	if c.messageCount < 60 {
-		c.messageCount++
-		return true
-	}
-
-	return false
-}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"plexichat-client/pkg/wire"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestNewHub(t *testing.T) {
@@ -206,3 +210,96 @@ func TestMessage_Creation(t *testing.T) {
 		t.Errorf("Expected MessageID 'msg789', got %s", message.MessageID)
 	}
 }
+
+// fakeReceiptStore records read positions in memory
+type fakeReceiptStore struct {
+	positions map[string]int64
+}
+
+func (s *fakeReceiptStore) SetReadPosition(ctx context.Context, userID, channelID string, messageID int64) error {
+	s.positions[userID+"/"+channelID] = messageID
+	return nil
+}
+
+func TestHub_Receipts(t *testing.T) {
+	hub := NewHub()
+	store := &fakeReceiptStore{positions: make(map[string]int64)}
+	hub.SetReceiptStore(store)
+
+	sender := newTestClient(hub, "alice_1", "1", "alice")
+	reader := newTestClient(hub, "bob_1", "2", "bob")
+	hub.JoinChannel(sender.ID, "general")
+	hub.JoinChannel(reader.ID, "general")
+
+	hub.acknowledge(sender, Message{Type: MessageTypeChat, ChannelID: "general", MessageID: "42"})
+	if ack := receive(t, sender, MessageTypeAck); ack.MessageID != "42" {
+		t.Errorf("Expected ack for message 42, got %s", ack.MessageID)
+	}
+
+	if err := hub.handleReceipt(reader, Message{Type: MessageTypeRead, ChannelID: "general", MessageID: "42"}); err != nil {
+		t.Fatalf("Expected read receipt to be accepted, got %v", err)
+	}
+	if receipt := receive(t, sender, MessageTypeRead); receipt.UserID != "2" || receipt.MessageID != "42" {
+		t.Errorf("Expected bob's read receipt for 42, got user %s message %s", receipt.UserID, receipt.MessageID)
+	}
+	if store.positions["2/general"] != 42 {
+		t.Errorf("Expected read position 42, got %d", store.positions["2/general"])
+	}
+
+	if err := hub.handleReceipt(reader, Message{Type: MessageTypeRead, ChannelID: "random", MessageID: "7"}); err == nil {
+		t.Error("Expected a receipt for a channel the client is not in to be rejected")
+	}
+	if err := hub.handleReceipt(reader, Message{Type: MessageTypeRead, ChannelID: "general", MessageID: "abc"}); err == nil {
+		t.Error("Expected a non-numeric message ID to be rejected")
+	}
+}
+
+// readFrame reads frames until one of the given type arrives, returning it with its frame type
+func readFrame(t *testing.T, conn *websocket.Conn, msgType MessageType) (int, Message) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		frameType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Expected a %s frame, got %v", msgType, err)
+		}
+		var msg Message
+		if err := wire.ForFrame(wire.ForSubprotocol(conn.Subprotocol()), frameType).Unmarshal(data, &msg); err != nil {
+			t.Fatalf("Expected a decodable frame, got %v", err)
+		}
+		if msg.Type == msgType {
+			return frameType, msg
+		}
+	}
+}
+
+func TestHub_BinarySubprotocol(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	_, url := newHubServer(t, hub)
+	dialer := &websocket.Dialer{Subprotocols: wire.Subprotocols, EnableCompression: true}
+	conn := dialHubWith(t, hub, dialer, url)
+
+	if conn.Subprotocol() != wire.SubprotocolMsgpack {
+		t.Fatalf("Expected the msgpack subprotocol, got %q", conn.Subprotocol())
+	}
+
+	join, _ := wire.Msgpack.Marshal(Message{Type: MessageTypeJoin, Data: "general", ChannelID: "general"})
+	if err := conn.WriteMessage(websocket.BinaryMessage, join); err != nil {
+		t.Fatalf("Expected to write, got %v", err)
+	}
+	if frameType, msg := readFrame(t, conn, MessageTypeJoin); frameType != websocket.BinaryMessage || msg.ChannelID != "general" {
+		t.Errorf("Expected a binary join announcement for general, got frame %d %+v", frameType, msg)
+	}
+
+	// Text frames are still read as JSON on a msgpack connection
+	if err := conn.WriteJSON(Message{Type: MessageTypeChat, Data: "hello", ChannelID: "general"}); err != nil {
+		t.Fatalf("Expected to write, got %v", err)
+	}
+	if frameType, msg := readFrame(t, conn, MessageTypeChat); frameType != websocket.BinaryMessage || msg.Data != "hello" {
+		t.Errorf("Expected the chat message back as a binary frame, got frame %d %+v", frameType, msg)
+	}
+}
@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"plexichat-client/internal/networking"
+	"plexichat-client/pkg/config"
+	"plexichat-client/pkg/errors"
+	"plexichat-client/pkg/security"
+)
+
+// rateLimitIdleTimeout is how long a user's limiters are kept after their last frame
+const rateLimitIdleTimeout = 10 * time.Minute
+
+// rateLimiter enforces a HubRateLimitConfig. Limits are kept per user, so all of a
+// user's connections share one budget, and per message type, so typing indicators
+// do not use up the budget for chat.
+type rateLimiter struct {
+	mu        sync.Mutex
+	config    config.HubRateLimitConfig
+	users     map[string]*userLimits
+	lastPrune time.Time
+}
+
+// userLimits holds one user's limiters
+type userLimits struct {
+	overall    *networking.CompositeRateLimiter
+	types      map[MessageType]*networking.CompositeRateLimiter // Each includes overall
+	violations *networking.SlidingWindowRateLimiter             // Nil when offenders are never disconnected
+	lastSeen   time.Time
+}
+
+// newRateLimiter creates a limiter for the given config
+func newRateLimiter(limits config.HubRateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:    limits,
+		users:     make(map[string]*userLimits),
+		lastPrune: time.Now(),
+	}
+}
+
+// ruleLimiters builds the limiters a rule describes; an unlimited rule has none
+func ruleLimiters(rule config.RateLimitRule) []networking.RateLimiter {
+	var limiters []networking.RateLimiter
+	if rule.Rate > 0 {
+		burst := rule.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		limiters = append(limiters, networking.NewTokenBucketRateLimiter(rule.Rate, burst))
+	}
+	if rule.Limit > 0 && rule.Window > 0 {
+		limiters = append(limiters, networking.NewSlidingWindowRateLimiter(rule.Limit, rule.Window))
+	}
+	return limiters
+}
+
+// allow reports whether a user may send a frame of msgType now. When not, it also
+// returns how long to wait and whether the user has been throttled often enough to
+// be disconnected.
+func (r *rateLimiter) allow(userID string, msgType MessageType) (allowed bool, retryAfter time.Duration, disconnect bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(now)
+
+	user := r.users[userID]
+	if user == nil {
+		user = &userLimits{
+			overall: networking.NewCompositeRateLimiter(ruleLimiters(r.config.User)...),
+			types:   make(map[MessageType]*networking.CompositeRateLimiter),
+		}
+		if r.config.MaxViolations > 0 && r.config.ViolationWindow > 0 {
+			user.violations = networking.NewSlidingWindowRateLimiter(r.config.MaxViolations, r.config.ViolationWindow)
+		}
+		r.users[userID] = user
+	}
+	user.lastSeen = now
+
+	limiter := user.types[msgType]
+	if limiter == nil {
+		rule, ok := r.config.MessageTypes[string(msgType)]
+		if !ok {
+			rule = r.config.Default
+		}
+		// The type's own limit is checked first, so a frame it rejects costs nothing overall
+		limiter = networking.NewCompositeRateLimiter(append(ruleLimiters(rule), user.overall)...)
+		user.types[msgType] = limiter
+	}
+
+	ctx := context.Background()
+	if limiter.Allow(ctx) {
+		return true, 0, false
+	}
+
+	disconnect = user.violations != nil && !user.violations.Allow(ctx)
+	return false, limiter.RetryAfter(), disconnect
+}
+
+// prune forgets users idle for rateLimitIdleTimeout, at most once a minute; callers hold r.mu
+func (r *rateLimiter) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < time.Minute {
+		return
+	}
+	r.lastPrune = now
+
+	for userID, user := range r.users {
+		if now.Sub(user.lastSeen) > rateLimitIdleTimeout {
+			delete(r.users, userID)
+		}
+	}
+}
+
+// SetRateLimits replaces the hub's rate limits, resetting every user's budget.
+// Disabled limits let every frame through.
+func (h *Hub) SetRateLimits(limits config.HubRateLimitConfig) {
+	var limiter *rateLimiter
+	if limits.Enabled {
+		limiter = newRateLimiter(limits)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limiter = limiter
+}
+
+// throttle applies the rate limits to a frame from client. A throttled client is sent
+// an error frame saying when to retry. It reports whether the frame may be handled
+// and whether the client should be disconnected as a repeat offender.
+func (h *Hub) throttle(client *Client, message Message) (allowed, disconnect bool) {
+	h.mu.RLock()
+	limiter := h.limiter
+	h.mu.RUnlock()
+
+	if limiter == nil {
+		return true, false
+	}
+
+	allowed, retryAfter, disconnect := limiter.allow(client.UserID, message.Type)
+	if allowed {
+		return true, false
+	}
+
+	if disconnect {
+		security.LogSecurityEvent("WEBSOCKET_RATE_LIMIT_DISCONNECT", "", "",
+			fmt.Sprintf("User: %s kept exceeding the %s rate limit", client.Username, message.Type))
+		return false, true
+	}
+
+	security.LogSecurityEvent("WEBSOCKET_RATE_LIMIT", "", "",
+		fmt.Sprintf("User: %s exceeded the %s rate limit", client.Username, message.Type))
+
+	h.sendError(client, message.ChannelID, errors.NewError(errors.ErrorTypeRateLimit, "RATE_LIMITED",
+		fmt.Sprintf("Too many %s messages", message.Type)).
+		WithSuggestion(fmt.Sprintf("Wait %s before sending again", retryAfter.Round(time.Millisecond))).
+		WithContext("message_type", string(message.Type)).
+		WithContext("retry_after_ms", retryAfter.Milliseconds()))
+	return false, false
+}
@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"plexichat-client/pkg/config"
+	"plexichat-client/pkg/errors"
+
+	"github.com/gorilla/websocket"
+)
+
+// testRateLimits allows two chat frames and one typing frame, refilling slowly
+func testRateLimits() config.HubRateLimitConfig {
+	return config.HubRateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{Rate: 0.1, Burst: 2},
+		MessageTypes: map[string]config.RateLimitRule{
+			"typing": {Rate: 0.1, Burst: 1},
+		},
+	}
+}
+
+func TestHub_RateLimitPerMessageType(t *testing.T) {
+	hub := NewHub()
+	hub.SetRateLimits(testRateLimits())
+	alice := newTestClient(hub, "alice_1", "1", "alice")
+
+	chat := Message{Type: MessageTypeChat, Data: "hi", ChannelID: "general"}
+	for i := 0; i < 2; i++ {
+		if allowed, _ := hub.throttle(alice, chat); !allowed {
+			t.Fatalf("Expected chat message %d to be allowed", i+1)
+		}
+	}
+	if allowed, disconnect := hub.throttle(alice, chat); allowed || disconnect {
+		t.Fatalf("Expected the third chat message to be throttled without a disconnect")
+	}
+
+	msg := receive(t, alice, MessageTypeError)
+	plexiErr, ok := msg.Data.(*errors.PlexiChatError)
+	if !ok {
+		t.Fatalf("Expected a structured error, got %T", msg.Data)
+	}
+	if plexiErr.Type != errors.ErrorTypeRateLimit || plexiErr.Code != "RATE_LIMITED" {
+		t.Errorf("Expected a RATE_LIMITED error, got %s %s", plexiErr.Type, plexiErr.Code)
+	}
+	if retryAfter, _ := plexiErr.Context["retry_after_ms"].(int64); retryAfter <= 0 {
+		t.Errorf("Expected a positive retry_after_ms, got %v", plexiErr.Context["retry_after_ms"])
+	}
+
+	// Typing has its own budget
+	typing := Message{Type: MessageTypeTyping, Data: true, ChannelID: "general"}
+	if allowed, _ := hub.throttle(alice, typing); !allowed {
+		t.Error("Expected typing to be allowed after chat was throttled")
+	}
+	if allowed, _ := hub.throttle(alice, typing); allowed {
+		t.Error("Expected the second typing message to be throttled")
+	}
+}
+
+func TestHub_RateLimitPerUser(t *testing.T) {
+	limits := testRateLimits()
+	limits.Default = config.RateLimitRule{}
+	limits.User = config.RateLimitRule{Limit: 2, Window: time.Minute}
+
+	hub := NewHub()
+	hub.SetRateLimits(limits)
+
+	// Two connections of the same user share one budget
+	first := newTestClient(hub, "alice_1", "1", "alice")
+	second := newTestClient(hub, "alice_2", "1", "alice")
+	bob := newTestClient(hub, "bob_1", "2", "bob")
+
+	chat := Message{Type: MessageTypeChat, Data: "hi"}
+	hub.throttle(first, chat)
+	hub.throttle(second, chat)
+	if allowed, _ := hub.throttle(second, chat); allowed {
+		t.Error("Expected alice's third message to be throttled across connections")
+	}
+	if allowed, _ := hub.throttle(bob, chat); !allowed {
+		t.Error("Expected bob to have his own budget")
+	}
+}
+
+func TestHub_RateLimitDisabled(t *testing.T) {
+	hub := NewHub()
+	hub.SetRateLimits(config.HubRateLimitConfig{})
+	alice := newTestClient(hub, "alice_1", "1", "alice")
+
+	for i := 0; i < 100; i++ {
+		if allowed, _ := hub.throttle(alice, Message{Type: MessageTypeChat, Data: "hi"}); !allowed {
+			t.Fatalf("Expected message %d to be allowed with rate limiting disabled", i+1)
+		}
+	}
+}
+
+func TestHub_RateLimitDisconnectsRepeatOffenders(t *testing.T) {
+	limits := testRateLimits()
+	limits.MaxViolations = 2
+	limits.ViolationWindow = time.Minute
+
+	hub := NewHub()
+	hub.SetRateLimits(limits)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	_, url := newHubServer(t, hub)
+	conn := dialHub(t, hub, url)
+
+	// Two allowed, two throttled, then the fifth is one violation too many
+	for i := 0; i < 5; i++ {
+		if err := conn.WriteJSON(Message{Type: MessageTypeTyping, Data: "general", ChannelID: "general"}); err != nil {
+			t.Fatalf("Expected to write frame %d, got %v", i+1, err)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	errorFrames := 0
+	for {
+		var msg Message
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Fatalf("Expected a policy violation close, got %v", err)
+			}
+			break
+		}
+		if msg.Type == MessageTypeError {
+			errorFrames++
+		}
+	}
+	if errorFrames != 2 {
+		t.Errorf("Expected 2 rate limit error frames before the disconnect, got %d", errorFrames)
+	}
+}
@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"plexichat-client/pkg/logging"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// shutdownReconnectAfter is how long clients are told to wait before reconnecting
+	shutdownReconnectAfter = 2 * time.Second
+
+	// closeHandshakeTimeout is how long a closing connection waits for the peer's close frame
+	closeHandshakeTimeout = 5 * time.Second
+)
+
+// ShutdownReport summarizes how a Shutdown closed the hub's connections
+type ShutdownReport struct {
+	Closed int `json:"closed"` // Connections that drained and closed cleanly
+	Forced int `json:"forced"` // Connections cut off at the deadline
+}
+
+// Shutdown stops accepting connections, flushes every client's pending messages,
+// closes each connection with 1001 (going away) and a reconnect hint, and waits
+// for the pumps to exit until ctx ends. Connections still open then are closed
+// forcibly; the returned error is non-nil if there were any. Run returns too.
+func (h *Hub) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	h.mu.Lock()
+	h.shuttingDown = true
+	clients := make([]*Client, 0, len(h.clients))
+	for _, client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	h.stopOnce.Do(func() { close(h.stopped) })
+	logging.Info("WebSocket hub draining %d connection(s)", len(clients))
+
+	reason := fmt.Sprintf("server restarting; reconnect after %s", shutdownReconnectAfter)
+	for _, client := range clients {
+		client.requestClose(websocket.CloseGoingAway, reason)
+	}
+
+	var report ShutdownReport
+	for _, client := range clients {
+		// Clients registered without a connection have no pumps to wait for
+		if client.done == nil {
+			h.unregisterClient(client)
+			report.Closed++
+			continue
+		}
+
+		select {
+		case <-client.done:
+			report.Closed++
+		case <-ctx.Done():
+			if client.Conn != nil {
+				client.Conn.Close() // Unblocks both pumps
+			}
+			report.Forced++
+		}
+	}
+
+	if h.unsubscribe != nil {
+		h.unsubscribe()
+	}
+
+	logging.Info("WebSocket hub stopped: %d closed, %d forced", report.Closed, report.Forced)
+	if report.Forced > 0 {
+		return report, fmt.Errorf("forcibly closed %d connection(s): %w", report.Forced, ctx.Err())
+	}
+	return report, nil
+}
+
+// isShuttingDown reports whether Shutdown has been called
+func (h *Hub) isShuttingDown() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.shuttingDown
+}
+
+// requestClose asks writePump to flush and close the connection with the given
+// close code and reason. Only the first request counts.
+func (c *Client) requestClose(code int, reason string) {
+	if c.closing == nil {
+		return
+	}
+	c.closeOnce.Do(func() {
+		c.closeFrame = websocket.FormatCloseMessage(code, reason)
+		close(c.closing)
+	})
+}
+
+// flushAndClose writes the messages already queued for the client, then the close
+// frame given to requestClose. It reports whether the close frame was sent, in
+// which case readPump finishes the close handshake.
+func (c *Client) flushAndClose() bool {
+	for flushing := true; flushing; {
+		select {
+		case message, ok := <-c.Send:
+			if !ok {
+				flushing = false
+				break
+			}
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.writeMessage(message); err != nil {
+				logging.Error("WebSocket write error while draining: %v", err)
+				return false
+			}
+		default:
+			flushing = false
+		}
+	}
+
+	if err := c.Conn.WriteControl(websocket.CloseMessage, c.closeFrame, time.Now().Add(10*time.Second)); err != nil {
+		return false
+	}
+
+	// Give the peer a moment to answer before readPump gives up
+	c.Conn.SetReadDeadline(time.Now().Add(closeHandshakeTimeout))
+	return true
+}
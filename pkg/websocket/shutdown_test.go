@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newHubServer serves hub connections, identifying every client as user 1
+func newHubServer(t *testing.T, hub *Hub) (*httptest.Server, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleWebSocket(w, r, "1", "alice")
+	}))
+	t.Cleanup(server.Close)
+	return server, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// dialHub connects to a hub server and waits until the hub has registered the client
+func dialHub(t *testing.T, hub *Hub, url string) *websocket.Conn {
+	return dialHubWith(t, hub, websocket.DefaultDialer, url)
+}
+
+// dialHubWith is dialHub with a custom dialer
+func dialHubWith(t *testing.T, hub *Hub, dialer *websocket.Dialer, url string) *websocket.Conn {
+	header := http.Header{"Authorization": []string{"Bearer test-token-123"}}
+	conn, _, err := dialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hub.GetOnlineUsers()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the hub to register the client")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func TestHub_Shutdown_DrainsAndCloses(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	_, url := newHubServer(t, hub)
+	conn := dialHub(t, hub, url)
+
+	for i := 0; i < 3; i++ {
+		hub.SendToUser("1", Message{Type: MessageTypeNotification, Data: "queued"})
+	}
+
+	// Read everything in the background so the close handshake completes
+	frames := make(chan Message, 16)
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			var msg Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				closed <- err
+				return
+			}
+			frames <- msg
+		}
+	}()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer shutdownCancel()
+
+	report, err := hub.Shutdown(shutdownCtx)
+	if err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	if report.Closed != 1 || report.Forced != 0 {
+		t.Errorf("Expected 1 clean close and none forced, got %+v", report)
+	}
+
+	closeErr := <-closed
+	if !websocket.IsCloseError(closeErr, websocket.CloseGoingAway) {
+		t.Fatalf("Expected close code 1001, got %v", closeErr)
+	}
+	if !strings.Contains(closeErr.(*websocket.CloseError).Text, "reconnect") {
+		t.Errorf("Expected a reconnect hint, got %q", closeErr.(*websocket.CloseError).Text)
+	}
+
+	queued := 0
+	for len(frames) > 0 {
+		if msg := <-frames; msg.Data == "queued" {
+			queued++
+		}
+	}
+	if queued != 3 {
+		t.Errorf("Expected the 3 queued messages to be flushed, got %d", queued)
+	}
+
+	// New connections are turned away
+	resp, err := http.Get(strings.Replace(url, "ws", "http", 1))
+	if err != nil {
+		t.Fatalf("Expected a response, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestHub_Shutdown_ForcesUnresponsiveClients(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	_, url := newHubServer(t, hub)
+	dialHub(t, hub, url) // Never reads, so never answers the close frame
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer shutdownCancel()
+
+	report, err := hub.Shutdown(shutdownCtx)
+	if err == nil {
+		t.Error("Expected an error reporting the forced close")
+	}
+	if report.Forced != 1 || report.Closed != 0 {
+		t.Errorf("Expected 1 forced close, got %+v", report)
+	}
+}
+
+func TestHub_Shutdown_ChatDuringDrain(t *testing.T) {
+	hub := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	_, url := newHubServer(t, hub)
+	conn := dialHub(t, hub, url)
+
+	// Send a chat frame after Run has stopped, before answering the close frame
+	conn.SetCloseHandler(func(code int, text string) error {
+		conn.WriteJSON(Message{Type: MessageTypeChat, Data: "last words", MessageID: "m1"})
+		return conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""))
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer shutdownCancel()
+
+	report, err := hub.Shutdown(shutdownCtx)
+	if err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	if report.Closed != 1 || report.Forced != 0 {
+		t.Errorf("Expected 1 clean close and none forced, got %+v", report)
+	}
+}
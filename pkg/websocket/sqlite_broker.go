@@ -0,0 +1,304 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"plexichat-client/pkg/logging"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteBrokerConfig configures a SQLiteBroker
+type SQLiteBrokerConfig struct {
+	Path         string        `json:"path"`
+	PollInterval time.Duration `json:"poll_interval"` // How often other hubs' envelopes are picked up
+	Retention    time.Duration `json:"retention"`     // How long published envelopes are kept
+	PresenceTTL  time.Duration `json:"presence_ttl"`  // Presence of a hub that stops heartbeating expires after this
+}
+
+// SQLiteBroker is a Broker that fans out through a shared SQLite file, so hubs in
+// separate processes on one host can deliver to each other's clients
+type SQLiteBroker struct {
+	db     *sql.DB
+	id     string
+	config SQLiteBrokerConfig
+	lastID int64
+
+	mu       sync.RWMutex
+	handlers map[int]func(Envelope)
+	nextID   int
+
+	stopCh chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewSQLiteBroker opens (or creates) the broker database and starts polling it
+func NewSQLiteBroker(config SQLiteBrokerConfig) (*SQLiteBroker, error) {
+	if config.PollInterval == 0 {
+		config.PollInterval = 50 * time.Millisecond
+	}
+	if config.Retention == 0 {
+		config.Retention = time.Minute
+	}
+	if config.PresenceTTL == 0 {
+		config.PresenceTTL = 30 * time.Second
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create broker directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", config.Path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open broker database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS hub_envelopes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS hub_presence (
+		owner TEXT NOT NULL,
+		hub_id TEXT NOT NULL,
+		client_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (client_id, channel_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_hub_envelopes_created ON hub_envelopes(created_at);
+	CREATE INDEX IF NOT EXISTS idx_hub_presence_channel ON hub_presence(channel_id, updated_at);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize broker schema: %w", err)
+	}
+
+	broker := &SQLiteBroker{
+		db:       db,
+		id:       newBrokerID(),
+		config:   config,
+		handlers: make(map[int]func(Envelope)),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// Only envelopes published from now on are delivered
+	if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM hub_envelopes").Scan(&broker.lastID); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read broker position: %w", err)
+	}
+
+	go broker.pollLoop()
+	return broker, nil
+}
+
+// newBrokerID returns a random identifier for this broker instance
+func newBrokerID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Publish stores the envelope for other processes and delivers it locally at once
+func (b *SQLiteBroker) Publish(ctx context.Context, envelope Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	_, err = b.db.ExecContext(ctx,
+		"INSERT INTO hub_envelopes (origin, payload, created_at) VALUES (?, ?, ?)",
+		b.id, string(payload), time.Now().UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to publish envelope: %w", err)
+	}
+
+	b.dispatch(envelope)
+	return nil
+}
+
+// Subscribe registers a handler for published envelopes
+func (b *SQLiteBroker) Subscribe(handler func(Envelope)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// dispatch hands an envelope to every local subscriber
+func (b *SQLiteBroker) dispatch(envelope Envelope) {
+	b.mu.RLock()
+	handlers := make([]func(Envelope), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+}
+
+// UpdatePresence records or removes a presence entry
+func (b *SQLiteBroker) UpdatePresence(ctx context.Context, presence Presence, online bool) error {
+	var err error
+	if online {
+		_, err = b.db.ExecContext(ctx, `
+			INSERT INTO hub_presence (owner, hub_id, client_id, channel_id, user_id, username, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(client_id, channel_id) DO UPDATE SET
+				owner = excluded.owner,
+				hub_id = excluded.hub_id,
+				user_id = excluded.user_id,
+				username = excluded.username,
+				updated_at = excluded.updated_at`,
+			b.id, presence.HubID, presence.ClientID, presence.ChannelID,
+			presence.UserID, presence.Username, time.Now().UnixNano())
+	} else {
+		_, err = b.db.ExecContext(ctx,
+			"DELETE FROM hub_presence WHERE client_id = ? AND channel_id = ?",
+			presence.ClientID, presence.ChannelID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update presence: %w", err)
+	}
+	return nil
+}
+
+// Presence lists the clients present in a channel on any live hub
+func (b *SQLiteBroker) Presence(ctx context.Context, channelID string) ([]Presence, error) {
+	cutoff := time.Now().Add(-b.config.PresenceTTL).UnixNano()
+	rows, err := b.db.QueryContext(ctx, `
+		SELECT hub_id, client_id, channel_id, user_id, username
+		FROM hub_presence
+		WHERE channel_id = ? AND updated_at >= ?`, channelID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query presence: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Presence
+	for rows.Next() {
+		var presence Presence
+		if err := rows.Scan(&presence.HubID, &presence.ClientID, &presence.ChannelID,
+			&presence.UserID, &presence.Username); err != nil {
+			return nil, fmt.Errorf("failed to scan presence: %w", err)
+		}
+		result = append(result, presence)
+	}
+	sortPresence(result)
+	return result, rows.Err()
+}
+
+// pollLoop delivers envelopes published by other brokers and keeps this broker's
+// presence fresh
+func (b *SQLiteBroker) pollLoop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+
+	heartbeat := time.NewTicker(b.config.PresenceTTL / 3)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			if err := b.poll(); err != nil {
+				logging.Error("Broker poll failed: %v", err)
+			}
+		case <-heartbeat.C:
+			now := time.Now()
+			if _, err := b.db.Exec("UPDATE hub_presence SET updated_at = ? WHERE owner = ?", now.UnixNano(), b.id); err != nil {
+				logging.Error("Broker heartbeat failed: %v", err)
+			}
+			if _, err := b.db.Exec("DELETE FROM hub_envelopes WHERE created_at < ?", now.Add(-b.config.Retention).UnixNano()); err != nil {
+				logging.Error("Broker cleanup failed: %v", err)
+			}
+		}
+	}
+}
+
+// poll reads envelopes newer than the last one seen
+func (b *SQLiteBroker) poll() error {
+	rows, err := b.db.Query(
+		"SELECT id, origin, payload FROM hub_envelopes WHERE id > ? ORDER BY id", b.lastID)
+	if err != nil {
+		return err
+	}
+
+	var envelopes []Envelope
+	for rows.Next() {
+		var id int64
+		var origin, payload string
+		if err := rows.Scan(&id, &origin, &payload); err != nil {
+			rows.Close()
+			return err
+		}
+		b.lastID = id
+
+		// Our own envelopes were delivered when they were published
+		if origin == b.id {
+			continue
+		}
+
+		var envelope Envelope
+		if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+			logging.Error("Skipping malformed broker envelope %d: %v", id, err)
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, envelope := range envelopes {
+		b.dispatch(envelope)
+	}
+	return nil
+}
+
+// Close stops polling, withdraws this broker's presence and closes the database
+func (b *SQLiteBroker) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.stopCh)
+		<-b.done
+
+		if _, execErr := b.db.Exec("DELETE FROM hub_presence WHERE owner = ?", b.id); execErr != nil {
+			logging.Error("Failed to clear broker presence: %v", execErr)
+		}
+		err = b.db.Close()
+	})
+	return err
+}
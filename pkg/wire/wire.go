@@ -0,0 +1,100 @@
+// Package wire encodes WebSocket frames as JSON or MessagePack. The encoding is
+// negotiated with the WebSocket subprotocol; JSON is used when none is agreed.
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// Subprotocols naming each encoding
+const (
+	SubprotocolJSON    = "plexichat.v1.json"
+	SubprotocolMsgpack = "plexichat.v1.msgpack"
+)
+
+// Subprotocols lists every supported subprotocol, most compact first
+var Subprotocols = []string{SubprotocolMsgpack, SubprotocolJSON}
+
+// Codec encodes and decodes frame payloads
+type Codec interface {
+	Subprotocol() string
+	FrameType() int // websocket.TextMessage or websocket.BinaryMessage
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON encodes frames as JSON text, the fallback every peer understands
+	JSON Codec = jsonCodec{}
+
+	// Msgpack encodes frames as MessagePack binary. Numbers in untyped fields such
+	// as Data decode as int64, uint64 or float64 rather than JSON's float64.
+	Msgpack Codec = newMsgpackCodec()
+)
+
+// ForSubprotocol returns the codec for a negotiated subprotocol, or JSON
+func ForSubprotocol(subprotocol string) Codec {
+	if subprotocol == SubprotocolMsgpack {
+		return Msgpack
+	}
+	return JSON
+}
+
+// ForFrame returns the codec for a received frame. Text frames are always JSON,
+// so a peer may fall back to JSON at any time.
+func ForFrame(negotiated Codec, frameType int) Codec {
+	if frameType == websocket.TextMessage || negotiated == nil {
+		return JSON
+	}
+	return negotiated
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string { return SubprotocolJSON }
+
+func (jsonCodec) FrameType() int { return websocket.TextMessage }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackCodec() *msgpackCodec {
+	handle := &codec.MsgpackHandle{}
+	handle.WriteExt = true // str8, bin and timestamp types from the current spec
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return &msgpackCodec{handle: handle}
+}
+
+func (c *msgpackCodec) Subprotocol() string { return SubprotocolMsgpack }
+
+func (c *msgpackCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (c *msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, c.handle).Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode msgpack: %w", err)
+	}
+	return data, nil
+}
+
+func (c *msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := codec.NewDecoderBytes(data, c.handle).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode msgpack: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package wire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type testFrame struct {
+	Type      string                 `json:"type"`
+	Data      interface{}            `json:"data"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	MessageID int64                  `json:"message_id,omitempty"`
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	sent := testFrame{
+		Type:      "chat",
+		Data:      map[string]interface{}{"text": "hello", "since": 42},
+		Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		MessageID: 7,
+	}
+
+	for _, c := range []Codec{JSON, Msgpack} {
+		t.Run(c.Subprotocol(), func(t *testing.T) {
+			data, err := c.Marshal(sent)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var received testFrame
+			if err := c.Unmarshal(data, &received); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if received.Type != sent.Type || received.MessageID != sent.MessageID {
+				t.Errorf("Expected %+v, got %+v", sent, received)
+			}
+			if !received.Timestamp.Equal(sent.Timestamp) {
+				t.Errorf("Expected timestamp %v, got %v", sent.Timestamp, received.Timestamp)
+			}
+
+			fields, ok := received.Data.(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected data to decode as map[string]interface{}, got %T", received.Data)
+			}
+			if fields["text"] != "hello" {
+				t.Errorf("Expected text hello, got %v", fields["text"])
+			}
+			if received.Metadata != nil {
+				t.Errorf("Expected omitted metadata, got %v", received.Metadata)
+			}
+		})
+	}
+}
+
+func TestCodec_MsgpackIsSmaller(t *testing.T) {
+	frame := testFrame{
+		Type:      "chat",
+		Data:      map[string]interface{}{"text": "hello", "channel_id": "general", "message_id": 123456},
+		Timestamp: time.Now(),
+	}
+
+	jsonData, _ := JSON.Marshal(frame)
+	msgpackData, _ := Msgpack.Marshal(frame)
+	if len(msgpackData) >= len(jsonData) {
+		t.Errorf("Expected msgpack (%d bytes) to be smaller than JSON (%d bytes)", len(msgpackData), len(jsonData))
+	}
+}
+
+func TestForSubprotocol(t *testing.T) {
+	tests := []struct {
+		subprotocol string
+		expected    Codec
+	}{
+		{SubprotocolMsgpack, Msgpack},
+		{SubprotocolJSON, JSON},
+		{"", JSON},
+		{"unknown", JSON},
+	}
+
+	for _, tt := range tests {
+		if got := ForSubprotocol(tt.subprotocol); got != tt.expected {
+			t.Errorf("Expected %s for %q, got %s", tt.expected.Subprotocol(), tt.subprotocol, got.Subprotocol())
+		}
+	}
+}
+
+func TestForFrame(t *testing.T) {
+	if got := ForFrame(Msgpack, websocket.TextMessage); got != JSON {
+		t.Errorf("Expected text frames to use JSON, got %s", got.Subprotocol())
+	}
+	if got := ForFrame(Msgpack, websocket.BinaryMessage); got != Msgpack {
+		t.Errorf("Expected binary frames to use the negotiated codec, got %s", got.Subprotocol())
+	}
+	if got := ForFrame(nil, websocket.BinaryMessage); got != JSON {
+		t.Errorf("Expected JSON without a negotiated codec, got %s", got.Subprotocol())
+	}
+}